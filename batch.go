@@ -0,0 +1,107 @@
+package gosrm
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchWorkers is the default number of requests of a batch that are executed concurrently.
+const DefaultBatchWorkers int = 8
+
+type (
+	// BatchConfig is the config of a batch of requests.
+	BatchConfig struct {
+		// Workers is the number of requests executed concurrently, defaults to DefaultBatchWorkers.
+		// Concurrency is also limited by the HTTPClient of the OSRM client, see HTTPClientConfig.
+		Workers int
+
+		// Progress is called after each request is done with the number of done requests and the size of the batch.
+		// Calls are serialized, so it doesn't have to be safe for concurrent use.
+		Progress func(done, total int)
+	}
+
+	// BatchResult is the result of a request of a batch.
+	BatchResult[R any] struct {
+		// Response is the response of the request, it's nil if the request failed.
+		Response *R
+
+		// Err is the error of the request.
+		// It's a ResponseError if OSRM couldn't process the request, Response is also set in this case.
+		// It's the context error if the batch was cancelled before the request was executed.
+		Err error
+	}
+)
+
+// Batch executes independent requests concurrently by fn and returns their results in the order of the requests.
+// Failed requests don't stop the batch, their errors are reported in their results.
+// When ctx is cancelled requests that aren't executed yet fail with the context error.
+func Batch[R any](ctx context.Context, reqs []Request, config BatchConfig, fn func(context.Context, Request) (*R, error)) []BatchResult[R] {
+	workers := config.Workers
+	if workers <= 0 {
+		workers = DefaultBatchWorkers
+	}
+	workers = min(workers, len(reqs))
+
+	results := make([]BatchResult[R], len(reqs))
+	indices := make(chan int)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+				} else {
+					results[i].Response, results[i].Err = fn(ctx, reqs[i])
+				}
+
+				if config.Progress != nil {
+					mu.Lock()
+					done++
+					config.Progress(done, len(reqs))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range reqs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return results
+}
+
+// RouteBatch finds the routes of independent requests concurrently, options are applied to all of them.
+// See Batch for the details.
+func RouteBatch[T GeometryType](ctx context.Context, osrm OSRMClient, reqs []Request, config BatchConfig, opts ...Option) []BatchResult[RouteResponse[T]] {
+	return Batch(ctx, reqs, config, func(ctx context.Context, req Request) (*RouteResponse[T], error) {
+		res, err := Route[T](ctx, osrm, req, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return res, res.Err()
+	})
+}
+
+// NearestBatch snaps the coordinates of independent requests concurrently, options are applied to all of them.
+// See Batch for the details.
+func NearestBatch(ctx context.Context, osrm OSRMClient, reqs []Request, config BatchConfig, opts ...Option) []BatchResult[NearestResponse] {
+	return Batch(ctx, reqs, config, func(ctx context.Context, req Request) (*NearestResponse, error) {
+		res, err := Nearest(ctx, osrm, req, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return res, res.Err()
+	})
+}
@@ -0,0 +1,137 @@
+package gosrm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newBatchTestServer returns a server that routes straight between coordinates and snaps coordinates to themselves.
+// Coordinates with a negative longitude can't be routed and the server waits for delay before responding.
+func newBatchTestServer(t *testing.T, delay time.Duration, active, peak *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for p := peak.Load(); n > p; p = peak.Load() {
+			if peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		assert.Equal(t, "false", r.URL.Query().Get("steps"))
+		time.Sleep(delay)
+
+		coordinates := parseTestCoordinates(r.URL.Path)
+		if coordinates[0][0] < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			assert.NoError(t, json.NewEncoder(w).Encode(Response{Code: CodeNoRoute}))
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, nearestServiceURL) {
+			res := NearestResponse{Response: Response{Code: CodeOK}, Waypoints: []NearestWaypoint{{Waypoint: Waypoint{Location: coordinates[0]}}}}
+			assert.NoError(t, json.NewEncoder(w).Encode(res))
+			return
+		}
+
+		res := RouteResponse[string]{
+			Response: Response{Code: CodeOK},
+			Routes:   []RouteType[string]{{Distance: float32(HaversineDistance(coordinates[0], coordinates[1]))}},
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(res))
+	}))
+}
+
+func TestRouteBatch(t *testing.T) {
+	var active, peak atomic.Int32
+	srv := newBatchTestServer(t, 10*time.Millisecond, &active, &peak)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	var reqs []Request
+	for i := range 10 {
+		reqs = append(reqs, Request{Profile: ProfileCar, Coordinates: []Coordinate{{0, 0}, {0, float64(i) / 100}}})
+	}
+	reqs[3].Coordinates[0][0] = -1
+
+	var progress []int
+	results := RouteBatch[string](context.Background(), osrm, reqs, BatchConfig{
+		Workers:  3,
+		Progress: func(done, total int) { progress = append(progress, done, total) },
+	}, WithSteps(false))
+
+	assert.Len(t, results, 10)
+	for i, res := range results {
+		if i == 3 {
+			assert.Equal(t, ResponseError{Code: CodeNoRoute}, res.Err)
+			assert.Equal(t, CodeNoRoute, res.Response.Code)
+			continue
+		}
+
+		assert.NoError(t, res.Err)
+		assert.InDelta(t, float64(i)*1111.95, res.Response.Routes[0].Distance, 1)
+	}
+
+	assert.Equal(t, []int{1, 10, 2, 10, 3, 10, 4, 10, 5, 10, 6, 10, 7, 10, 8, 10, 9, 10, 10, 10}, progress)
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	assert.Greater(t, peak.Load(), int32(1))
+}
+
+func TestNearestBatch(t *testing.T) {
+	var active, peak atomic.Int32
+	srv := newBatchTestServer(t, 0, &active, &peak)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	reqs := []Request{
+		{Profile: ProfileCar, Coordinates: []Coordinate{{1, 2}}},
+		{Profile: ProfileCar, Coordinates: []Coordinate{{3, 4}}},
+	}
+
+	results := NearestBatch(context.Background(), osrm, reqs, BatchConfig{}, WithSteps(false))
+	assert.Len(t, results, 2)
+	for i, res := range results {
+		assert.NoError(t, res.Err)
+		assert.Equal(t, reqs[i].Coordinates[0], res.Response.Waypoints[0].Location)
+	}
+
+	assert.Empty(t, NearestBatch(context.Background(), osrm, nil, BatchConfig{}))
+}
+
+func TestBatch_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reqs := make([]Request, 5)
+	errFailed := errors.New("failed")
+
+	var calls atomic.Int32
+	results := Batch(ctx, reqs, BatchConfig{Workers: 1}, func(ctx context.Context, req Request) (*Response, error) {
+		if calls.Add(1) == 2 {
+			cancel()
+			return nil, errFailed
+		}
+		return &Response{Code: CodeOK}, nil
+	})
+
+	assert.Equal(t, int32(2), calls.Load())
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, CodeOK, results[0].Response.Code)
+	assert.ErrorIs(t, results[1].Err, errFailed)
+	for _, res := range results[2:] {
+		assert.ErrorIs(t, res.Err, context.Canceled)
+		assert.Nil(t, res.Response)
+	}
+}
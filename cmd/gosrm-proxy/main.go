@@ -0,0 +1,89 @@
+// Command gosrm-proxy is an OSRM compatible caching reverse proxy.
+//
+// Usage:
+//
+//	gosrm-proxy -backends http://osrm-1:5000,http://osrm-2:5000 -keys key1:600,key2:0
+//
+// Requests are forwarded to the backends in round-robin order, see package proxy for the details.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mojixcoder/gosrm"
+	"github.com/mojixcoder/gosrm/proxy"
+)
+
+func main() {
+	addr := flag.String("addr", ":5000", "address to listen on")
+	backends := flag.String("backends", "http://localhost:5001", "comma separated base URLs of the OSRM backends")
+	concurrency := flag.Uint("concurrency", 0, "max number of concurrent requests per backend, 0 is unlimited")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout of backend requests")
+	cacheSize := flag.Int("cache-size", proxy.DefaultCacheSize, "max number of cached responses, negative disables caching")
+	cacheTTL := flag.Duration("cache-ttl", proxy.DefaultCacheTTL, "time responses are cached for")
+	keys := flag.String("keys", "", "comma separated API keys and their quotas as key:quota, empty doesn't require keys")
+	quotaWindow := flag.Duration("quota-window", proxy.DefaultQuotaWindow, "window of API key quotas")
+	flag.Parse()
+
+	config := proxy.Config{CacheSize: *cacheSize, CacheTTL: *cacheTTL, QuotaWindow: *quotaWindow}
+
+	for _, u := range strings.Split(*backends, ",") {
+		osrm, err := gosrm.New(strings.TrimSpace(u))
+		if err != nil {
+			log.Fatalf("invalid backend %q: %v", u, err)
+		}
+		// Each backend has its own client, so concurrency is limited per backend.
+		osrm.SetHTTPClient(gosrm.NewHTTPClient(gosrm.HTTPClientConfig{
+			MaxConcurrency: *concurrency,
+			HTTPClient:     &http.Client{Timeout: *timeout},
+		}))
+		config.Backends = append(config.Backends, osrm)
+	}
+
+	apiKeys, err := parseKeys(*keys)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.APIKeys = apiKeys
+
+	s, err := proxy.New(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, s))
+}
+
+// parseKeys parses API keys and their quotas as key:quota, keys without a quota are unlimited.
+func parseKeys(s string) (map[string]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	keys := make(map[string]int)
+	for _, pair := range strings.Split(s, ",") {
+		key, quota, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if key == "" {
+			return nil, errors.New("empty API key")
+		}
+
+		keys[key] = 0
+		if ok {
+			n, err := strconv.Atoi(quota)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quota of API key %q: %w", key, err)
+			}
+			keys[key] = n
+		}
+	}
+
+	return keys, nil
+}
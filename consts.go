@@ -1,8 +1,13 @@
 package gosrm
 
 type (
-	// Geometry is the geometry format.
-	Geometry string
+	// GeometryFormat is the type for geometries option.
+	GeometryFormat string
+
+	// Geometry is the former name of GeometryFormat.
+	//
+	// Deprecated: Use GeometryFormat, Geometry will be removed in a future version.
+	Geometry = GeometryFormat
 
 	// Overview is the type for overview option.
 	Overview string
@@ -99,13 +104,13 @@ const (
 
 const (
 	// GeometryPolyline is the geometry polyline type.
-	GeometryPolyline Geometry = "polyline"
+	GeometryPolyline GeometryFormat = "polyline"
 
 	// GeometryPolyline6 is the geometry polyline6 type.
-	GeometryPolyline6 Geometry = "polyline6"
+	GeometryPolyline6 GeometryFormat = "polyline6"
 
 	// GeometryGeoJSON is the geometry geojson type.
-	GeometryGeoJSON Geometry = "geojson"
+	GeometryGeoJSON GeometryFormat = "geojson"
 )
 
 const (
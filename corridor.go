@@ -0,0 +1,182 @@
+package gosrm
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ErrInvalidCorridor is returned when a corridor request is not valid.
+var ErrInvalidCorridor = errors.New("gosrm: invalid corridor request")
+
+// DefaultCorridorWidth is the default max distance of POIs from the route, in meters.
+const DefaultCorridorWidth float64 = 1000
+
+type (
+	// CorridorRequest is the request of searching POIs along a route.
+	CorridorRequest struct {
+		// Profile is used to compute detours.
+		Profile Profile
+
+		// POIs are the coordinates of the points of interest.
+		POIs []Coordinate
+
+		// Width is the max straight-line distance of POIs from the route, in meters.
+		// Defaults to DefaultCorridorWidth.
+		Width float64
+
+		// RejoinDistance is the distance along the route from the position where detours leave the route
+		// to the position where they rejoin it, in meters. Defaults to 0, detours return to where they left.
+		RejoinDistance float64
+
+		// MaxDetour is the max added duration of detours, in seconds. POIs with longer detours are excluded.
+		// Defaults to 0, which is unlimited.
+		MaxDetour float64
+
+		// ChunkSize is the max number of sources and destinations per table request,
+		// each request evaluates the detours of ChunkSize/2 POIs. Defaults to DefaultTableChunkSize.
+		ChunkSize int
+	}
+
+	// CorridorPOI is a POI along a route.
+	CorridorPOI struct {
+		// Index is the index of the POI in the request.
+		Index int
+
+		// Distance is the straight-line distance of the POI from the route, in meters.
+		Distance float64
+
+		// Position is the distance along the route to where the detour leaves it, in meters.
+		Position float64
+
+		// Leave is the location where the detour leaves the route.
+		Leave Coordinate
+
+		// Rejoin is the location where the detour rejoins the route.
+		Rejoin Coordinate
+
+		// AddedDuration is the duration of the detour minus the duration of the route it replaces, in seconds.
+		AddedDuration float64
+
+		// AddedDistance is the distance of the detour minus the distance of the route it replaces, in meters.
+		// It's positive infinity if the distance of the detour is unknown.
+		AddedDistance float64
+	}
+)
+
+// Corridor returns the POIs within a corridor of the route and within the detour budget, sorted by added duration.
+// POIs are first filtered by their straight-line distance from the geometry of the route, then table service
+// computes the durations of detours from the route to the POIs and back to the route.
+// The route needs a full overview geometry or steps, see NewRouteTracker. POIs that can't be reached are excluded.
+// Options are passed to table service.
+func Corridor[T GeometryType](ctx context.Context, osrm OSRMClient, route RouteType[T], req CorridorRequest, opts ...Option) ([]CorridorPOI, error) {
+	if req.Width == 0 {
+		req.Width = DefaultCorridorWidth
+	}
+	if req.ChunkSize <= 0 {
+		req.ChunkSize = DefaultTableChunkSize
+	}
+
+	if req.Width < 0 || req.RejoinDistance < 0 || req.MaxDetour < 0 {
+		return nil, fmt.Errorf("%w: negative width, rejoin distance or max detour", ErrInvalidCorridor)
+	}
+	if req.ChunkSize < 2 {
+		return nil, fmt.Errorf("%w: chunk size must be at least 2", ErrInvalidCorridor)
+	}
+	// Table requests have 3 coordinates per POI, their indices are uint16.
+	if 3*(req.ChunkSize/2) > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: chunk size is too big", ErrInvalidCorridor)
+	}
+
+	tracker, err := NewRouteTracker(route, TrackerConfig{})
+	if err != nil {
+		return nil, err
+	}
+
+	// candidates are the POIs in the corridor with their durations along the route to leave and rejoin it.
+	type candidate struct {
+		poi           CorridorPOI
+		leave, rejoin float64
+	}
+
+	var candidates []candidate
+	for i, poi := range req.POIs {
+		segment, fraction, distance := tracker.project(poi, 0, len(tracker.points)-2)
+		if distance > req.Width {
+			continue
+		}
+
+		position := tracker.distances[segment] + fraction*(tracker.distances[segment+1]-tracker.distances[segment])
+		rejoinPosition := min(position+req.RejoinDistance, tracker.Length())
+
+		c := candidate{poi: CorridorPOI{Index: i, Distance: distance, Position: position}}
+		c.poi.Leave, c.leave = tracker.locate(position)
+		c.poi.Rejoin, c.rejoin = tracker.locate(rejoinPosition)
+		c.poi.AddedDistance = position - rejoinPosition
+		c.poi.AddedDuration = c.leave - c.rejoin
+		candidates = append(candidates, c)
+	}
+
+	// Each table request has the leave, POI and rejoin coordinates of a batch of candidates.
+	// Sources are the leave and POI coordinates and destinations are the POI and rejoin coordinates.
+	batch := req.ChunkSize / 2
+
+	var pois []CorridorPOI
+	for start := 0; start < len(candidates); start += batch {
+		chunk := candidates[start:min(start+batch, len(candidates))]
+		n := len(chunk)
+
+		table := Request{Profile: req.Profile, Coordinates: make([]Coordinate, 0, 3*n)}
+		for _, c := range chunk {
+			table.Coordinates = append(table.Coordinates, c.poi.Leave)
+		}
+		for _, c := range chunk {
+			table.Coordinates = append(table.Coordinates, req.POIs[c.poi.Index])
+		}
+		for _, c := range chunk {
+			table.Coordinates = append(table.Coordinates, c.poi.Rejoin)
+		}
+
+		indices := make([]uint16, 3*n)
+		for i := range indices {
+			indices[i] = uint16(i)
+		}
+
+		tableOpts := append([]Option{WithAnnotations(AnnotationsDurationDistance)}, opts...)
+		tableOpts = append(tableOpts, WithSources(indices[:2*n]), WithDestinations(indices[n:]))
+
+		res, err := tableInf(ctx, osrm, table, tableOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if err := res.Err(); err != nil {
+			return nil, err
+		}
+
+		// The detour of the k-th candidate is from its leave (row k) to its POI (column k),
+		// then from its POI (row n+k) to its rejoin (column n+k).
+		for k, c := range chunk {
+			duration := float64(tableValue(res.Durations, k, k)) + float64(tableValue(res.Durations, n+k, n+k))
+			distance := float64(tableValue(res.Distances, k, k)) + float64(tableValue(res.Distances, n+k, n+k))
+			if math.IsInf(duration, 1) {
+				continue
+			}
+
+			c.poi.AddedDuration += duration
+			c.poi.AddedDistance += distance
+			if req.MaxDetour > 0 && c.poi.AddedDuration > req.MaxDetour {
+				continue
+			}
+			pois = append(pois, c.poi)
+		}
+	}
+
+	slices.SortStableFunc(pois, func(a, b CorridorPOI) int {
+		return cmp.Or(cmp.Compare(a.AddedDuration, b.AddedDuration), cmp.Compare(a.Position, b.Position))
+	})
+
+	return pois, nil
+}
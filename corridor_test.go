@@ -0,0 +1,105 @@
+package gosrm
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorridor(t *testing.T) {
+	var requests int32
+	srv := newTableTestServer(t, &requests)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	length := HaversineDistance(Coordinate{0, 0}, Coordinate{0.1, 0})
+	route := RouteType[LineString]{
+		Distance: float32(length),
+		Duration: 1000,
+		Geometry: LineString{Coordinates: []Coordinate{{0, 0}, {0.05, 0}, {0.1, 0}}},
+		Legs:     []RouteLeg[LineString]{{Distance: float32(length), Duration: 1000}},
+	}
+
+	req := CorridorRequest{
+		Profile: ProfileCar,
+		POIs: []Coordinate{
+			{0.02, 0.005},     // 0: 500m from the route
+			{0.08, 0.02},      // 1: out of the corridor
+			{0.05, 0.001},     // 2: 100m from the route
+			{-0.001, 0.0005},  // 3: unreachable
+			{0.1006, -0.0006}, // 4: past the end of the route
+		},
+		ChunkSize: 2,
+	}
+
+	pois, err := Corridor(context.Background(), osrm, route, req)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), requests)
+
+	assert.Len(t, pois, 3)
+	assert.Equal(t, 2, pois[0].Index)
+	assert.InDelta(t, 20, pois[0].AddedDuration, 0.01)
+	assert.InDelta(t, 200, pois[0].AddedDistance, 0.1)
+	assert.InDelta(t, 111.2, pois[0].Distance, 0.1)
+	assert.InDelta(t, length/2, pois[0].Position, 0.1)
+	assert.InDelta(t, 0.05, pois[0].Leave[0], 1e-9)
+	assert.Equal(t, pois[0].Leave, pois[0].Rejoin)
+
+	assert.Equal(t, 4, pois[1].Index)
+	assert.InDelta(t, length, pois[1].Position, 0.1)
+	assert.InDelta(t, 24, pois[1].AddedDuration, 0.01)
+
+	assert.Equal(t, 0, pois[2].Index)
+	assert.InDelta(t, 100, pois[2].AddedDuration, 0.01)
+
+	// Detours rejoin the route further along it.
+	req.RejoinDistance = 1000
+	req.MaxDetour = 50
+	pois, err = Corridor(context.Background(), osrm, route, req)
+	assert.NoError(t, err)
+	assert.Len(t, pois, 2)
+
+	rejoin := offsetCoordinate(Coordinate{0.05, 0}, 1000, 0)
+	assert.Equal(t, 2, pois[0].Index)
+	assert.InDelta(t, rejoin[0], pois[0].Rejoin[0], 1e-6)
+	assert.InDelta(t, 10+(rejoin[0]-0.05+0.001)*10000-1000*1000/length, pois[0].AddedDuration, 0.01)
+	assert.InDelta(t, 100+(rejoin[0]-0.05+0.001)*100000-1000, pois[0].AddedDistance, 0.1)
+
+	// Detours past the end of the route rejoin at its end.
+	assert.Equal(t, 4, pois[1].Index)
+	assert.Equal(t, Coordinate{0.1, 0}, pois[1].Rejoin)
+
+	for _, invalid := range []CorridorRequest{{Width: -1}, {RejoinDistance: -1}, {MaxDetour: -1}, {ChunkSize: 1}, {ChunkSize: 43692}} {
+		_, err := Corridor(context.Background(), osrm, route, invalid)
+		assert.ErrorIs(t, err, ErrInvalidCorridor)
+	}
+
+	_, err = Corridor(context.Background(), osrm, RouteType[LineString]{}, req)
+	assert.ErrorIs(t, err, ErrEmptyGeometry)
+}
+
+func TestCorridor_UnknownDistance(t *testing.T) {
+	// Detours have durations but their distances can't be computed.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":"Ok","durations":[[10,0],[0,5]],"distances":[[100,null],[null,null]]}`))
+	}))
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	route := RouteType[LineString]{Geometry: LineString{Coordinates: []Coordinate{{0, 0}, {0.1, 0}}}}
+	req := CorridorRequest{Profile: ProfileCar, POIs: []Coordinate{{0.05, 0.001}}, ChunkSize: 2}
+
+	pois, err := Corridor(context.Background(), osrm, route, req)
+	assert.NoError(t, err)
+	assert.Len(t, pois, 1)
+	assert.InDelta(t, 15, pois[0].AddedDuration, 1e-9)
+	assert.True(t, math.IsInf(pois[0].AddedDistance, 1))
+}
@@ -0,0 +1,475 @@
+package gosrm
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+var (
+	// ErrInvalidEVRequest is returned when an EV request is not valid.
+	ErrInvalidEVRequest = errors.New("gosrm: invalid EV request")
+
+	// ErrNoEVRoute is returned when the destination can't be reached with the chargers of an EV request.
+	ErrNoEVRoute = errors.New("gosrm: no feasible EV route")
+)
+
+const (
+	// DefaultEVResolution is the default number of state of charge levels used to plan charging stops.
+	DefaultEVResolution int = 20
+
+	// DefaultEVReserve is the default min state of charge of EVs.
+	DefaultEVReserve float64 = 0.1
+)
+
+type (
+	// EVConsumption is the energy consumption of a vehicle at a speed.
+	EVConsumption struct {
+		// Speed in km/h.
+		Speed float64
+
+		// Consumption in kWh per km.
+		Consumption float64
+	}
+
+	// EVVehicle is the model of an electric vehicle.
+	EVVehicle struct {
+		// BatteryCapacity is the usable capacity of the battery, in kWh.
+		BatteryCapacity float64
+
+		// Consumption is the energy consumption in kWh per km, it's used if ConsumptionCurve is empty.
+		Consumption float64
+
+		// ConsumptionCurve is the consumption by speed, sorted by speed.
+		// Consumption between speeds is interpolated linearly and it's constant beyond the first and last speeds.
+		ConsumptionCurve []EVConsumption
+
+		// MaxChargingPower is the max charging power of the vehicle in kW, 0 is unlimited.
+		MaxChargingPower float64
+	}
+
+	// EVCharger is a charging station.
+	EVCharger struct {
+		// Location of the charger.
+		Location Coordinate
+
+		// Power of the charger, in kW.
+		Power float64
+	}
+
+	// EVRequest is the request of an EV route with charging stops.
+	EVRequest struct {
+		// Profile is used to compute routes.
+		Profile Profile
+
+		// Start is the coordinate the route starts from.
+		Start Coordinate
+
+		// Destination is the coordinate the route ends at.
+		Destination Coordinate
+
+		// Vehicle is the model of the vehicle.
+		Vehicle EVVehicle
+
+		// Chargers are the charging stations that can be used.
+		Chargers []EVCharger
+
+		// InitialSoC is the state of charge at the start, as a fraction of the battery capacity. Defaults to 1.
+		// A negative value means the battery is empty at the start.
+		InitialSoC float64
+
+		// Reserve is the min state of charge along the route, as a fraction of the battery capacity.
+		// Defaults to DefaultEVReserve, a negative value means no reserve.
+		Reserve float64
+
+		// MaxSoC is the max state of charge the vehicle is charged to, as a fraction of the battery capacity.
+		// Defaults to 1.
+		MaxSoC float64
+
+		// StopDuration is the fixed duration of each charging stop in seconds, e.g. for parking and plugging in.
+		StopDuration float64
+
+		// Resolution is the number of state of charge levels used to plan charging stops, defaults to DefaultEVResolution.
+		// Higher resolutions find faster plans but take longer.
+		Resolution int
+
+		// ChunkSize is the max number of sources and destinations per table request, see TableChunked.
+		ChunkSize int
+	}
+
+	// EVStop is a charging stop of an EV route.
+	EVStop struct {
+		// Charger is the index of the charger in the request.
+		Charger int
+
+		// Location of the charger.
+		Location Coordinate
+
+		// ArrivalSoC is the state of charge when the vehicle arrives, as a fraction of the battery capacity.
+		ArrivalSoC float64
+
+		// DepartureSoC is the state of charge when the vehicle leaves, as a fraction of the battery capacity.
+		DepartureSoC float64
+
+		// Energy is the charged energy, in kWh.
+		Energy float64
+
+		// Duration is the duration of the stop including StopDuration, in seconds.
+		Duration float64
+	}
+
+	// EVLeg is the state of charge along a leg of an EV route.
+	EVLeg struct {
+		// DepartureSoC is the state of charge at the start of the leg, as a fraction of the battery capacity.
+		DepartureSoC float64
+
+		// ArrivalSoC is the state of charge at the end of the leg, as a fraction of the battery capacity.
+		ArrivalSoC float64
+
+		// Energy is the consumed energy, in kWh.
+		Energy float64
+
+		// SoC is the state of charge after each pair of coordinates of the annotation of the leg.
+		SoC []float64
+	}
+
+	// EVPlan is an EV route with charging stops.
+	EVPlan[T GeometryType] struct {
+		// Route is the response of route service through the start, the chargers of the stops and the destination,
+		// so its k-th leg ends at the k-th stop.
+		Route *RouteResponse[T]
+
+		// Stops are the charging stops in order.
+		Stops []EVStop
+
+		// Legs are the states of charge along the legs of the route.
+		Legs []EVLeg
+
+		// DrivingTime is the duration of driving, in seconds.
+		DrivingTime float64
+
+		// ChargingTime is the total duration of the stops, in seconds.
+		ChargingTime float64
+
+		// Duration is the total duration of the route including the stops, in seconds.
+		Duration float64
+	}
+)
+
+// consumption returns the energy consumption at a speed in km/h, in kWh per km.
+func (v EVVehicle) consumption(speed float64) float64 {
+	curve := v.ConsumptionCurve
+	if len(curve) == 0 {
+		return v.Consumption
+	}
+
+	i, _ := slices.BinarySearchFunc(curve, speed, func(c EVConsumption, speed float64) int {
+		switch {
+		case c.Speed < speed:
+			return -1
+		case c.Speed > speed:
+			return 1
+		}
+		return 0
+	})
+
+	switch {
+	case i == 0:
+		return curve[0].Consumption
+	case i == len(curve):
+		return curve[len(curve)-1].Consumption
+	}
+
+	a, b := curve[i-1], curve[i]
+	return a.Consumption + (b.Consumption-a.Consumption)*(speed-a.Speed)/(b.Speed-a.Speed)
+}
+
+// energy returns the energy consumed by driving distance meters in duration seconds, in kWh.
+func (v EVVehicle) energy(distance, duration float64) float64 {
+	speed := 0.0
+	if duration > 0 {
+		speed = distance / duration * 3.6
+	}
+	return v.consumption(speed) * distance / 1000
+}
+
+// chargingPower returns the charging power of the vehicle at a charger, in kW.
+func (v EVVehicle) chargingPower(c EVCharger) float64 {
+	if v.MaxChargingPower > 0 {
+		return min(c.Power, v.MaxChargingPower)
+	}
+	return c.Power
+}
+
+// PlanEVRoute finds the fastest route from the start to the destination including the durations of charging stops.
+// Durations and distances between the start, the chargers and the destination are computed by TableChunked,
+// then charging stops are planned on discrete levels of state of charge, so arrival levels are rounded down.
+// Vehicles are charged with the constant power of the charger limited by the vehicle.
+// The route through the stops is computed by route service with annotations, and the states of charge of the legs
+// are computed from their annotation speeds, so they can differ slightly from the plan.
+// Options are passed to table and route services, so they must be valid for both, e.g. WithExclude. ErrNoEVRoute is returned if the destination can't be reached,
+// including when the state of charge of the route drops below the reserve.
+func PlanEVRoute[T GeometryType](ctx context.Context, osrm OSRMClient, req EVRequest, opts ...Option) (*EVPlan[T], error) {
+	switch {
+	case req.InitialSoC == 0:
+		req.InitialSoC = 1
+	case req.InitialSoC < 0:
+		req.InitialSoC = 0
+	}
+	switch {
+	case req.Reserve == 0:
+		req.Reserve = DefaultEVReserve
+	case req.Reserve < 0:
+		req.Reserve = 0
+	}
+	if req.MaxSoC == 0 {
+		req.MaxSoC = 1
+	}
+	if req.Resolution <= 0 {
+		req.Resolution = DefaultEVResolution
+	}
+
+	if err := validateEVRequest(req); err != nil {
+		return nil, err
+	}
+
+	// Nodes are the start, the chargers and the destination.
+	coordinates := []Coordinate{req.Start}
+	for _, c := range req.Chargers {
+		coordinates = append(coordinates, c.Location)
+	}
+	coordinates = append(coordinates, req.Destination)
+
+	indices := allIndices(len(coordinates))
+	table, err := TableChunked(ctx, osrm, Request{Profile: req.Profile, Coordinates: coordinates},
+		indices[:len(indices)-1], indices[1:], req.ChunkSize, append(slices.Clone(opts), WithAnnotations(AnnotationsDurationDistance))...)
+	if err != nil {
+		return nil, err
+	}
+
+	planner := evPlanner{req: req, durations: table.Durations, distances: table.Distances, nodes: len(coordinates)}
+	path, levels, ok := planner.search()
+	if !ok {
+		return nil, ErrNoEVRoute
+	}
+
+	// The route goes through the chargers of the stops, path has the nodes the vehicle charges at.
+	route := Request{Profile: req.Profile, Coordinates: []Coordinate{req.Start}}
+	for _, node := range path {
+		route.Coordinates = append(route.Coordinates, coordinates[node])
+	}
+	route.Coordinates = append(route.Coordinates, req.Destination)
+
+	res, err := Route[T](ctx, osrm, route, append(slices.Clone(opts), WithAnnotations(AnnotationsTrue))...)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	if len(res.Routes) == 0 || len(res.Routes[0].Legs) != len(path)+1 {
+		return nil, fmt.Errorf("%w: route has no legs for the stops", ErrNoEVRoute)
+	}
+
+	plan := EVPlan[T]{Route: res, DrivingTime: float64(res.Routes[0].Duration)}
+	capacity := req.Vehicle.BatteryCapacity
+	soc := req.InitialSoC
+
+	for k, leg := range res.Routes[0].Legs {
+		if k > 0 {
+			charger := path[k-1] - 1
+			stop := EVStop{
+				Charger:      charger,
+				Location:     req.Chargers[charger].Location,
+				ArrivalSoC:   soc,
+				DepartureSoC: max(soc, levels[k-1]),
+			}
+			stop.Energy = (stop.DepartureSoC - stop.ArrivalSoC) * capacity
+			stop.Duration = req.StopDuration + stop.Energy/req.Vehicle.chargingPower(req.Chargers[charger])*3600
+
+			plan.Stops = append(plan.Stops, stop)
+			plan.ChargingTime += stop.Duration
+			soc = stop.DepartureSoC
+		}
+
+		evLeg := EVLeg{DepartureSoC: soc}
+		annotation := leg.Annotation
+		if len(annotation.Distance) > 0 && len(annotation.Speed) == len(annotation.Distance) {
+			evLeg.SoC = make([]float64, len(annotation.Distance))
+			for i, d := range annotation.Distance {
+				evLeg.Energy += req.Vehicle.consumption(float64(annotation.Speed[i])*3.6) * float64(d) / 1000
+				evLeg.SoC[i] = soc - evLeg.Energy/capacity
+			}
+		} else {
+			evLeg.Energy = req.Vehicle.energy(float64(leg.Distance), float64(leg.Duration))
+		}
+
+		soc -= evLeg.Energy / capacity
+		evLeg.ArrivalSoC = soc
+		if soc < req.Reserve-1e-9 || slices.ContainsFunc(evLeg.SoC, func(s float64) bool { return s < req.Reserve-1e-9 }) {
+			return nil, fmt.Errorf("%w: state of charge of leg %d drops below the reserve", ErrNoEVRoute, k)
+		}
+
+		plan.Legs = append(plan.Legs, evLeg)
+	}
+
+	plan.Duration = plan.DrivingTime + plan.ChargingTime
+
+	return &plan, nil
+}
+
+// validateEVRequest returns ErrInvalidEVRequest if the request is not valid.
+func validateEVRequest(req EVRequest) error {
+	v := req.Vehicle
+
+	if v.BatteryCapacity <= 0 {
+		return fmt.Errorf("%w: battery capacity must be positive", ErrInvalidEVRequest)
+	}
+	if v.Consumption < 0 || (len(v.ConsumptionCurve) == 0 && v.Consumption == 0) {
+		return fmt.Errorf("%w: consumption must be positive", ErrInvalidEVRequest)
+	}
+	for i, c := range v.ConsumptionCurve {
+		if c.Consumption < 0 || (i > 0 && c.Speed <= v.ConsumptionCurve[i-1].Speed) {
+			return fmt.Errorf("%w: consumption curve must be sorted by speed", ErrInvalidEVRequest)
+		}
+	}
+	for _, c := range req.Chargers {
+		if c.Power <= 0 {
+			return fmt.Errorf("%w: charger power must be positive", ErrInvalidEVRequest)
+		}
+	}
+	if req.InitialSoC < 0 || req.InitialSoC > 1 || req.Reserve < 0 || req.MaxSoC > 1 || req.Reserve >= req.MaxSoC {
+		return fmt.Errorf("%w: states of charge must be fractions and reserve must be less than max", ErrInvalidEVRequest)
+	}
+	if req.StopDuration < 0 {
+		return fmt.Errorf("%w: negative stop duration", ErrInvalidEVRequest)
+	}
+	if len(req.Chargers)+2 > math.MaxUint16 {
+		return fmt.Errorf("%w: too many chargers", ErrInvalidEVRequest)
+	}
+
+	return nil
+}
+
+// evPlanner plans charging stops with Dijkstra's algorithm on states of nodes and levels of charge.
+// Node 0 is the start, nodes 1 to n-2 are the chargers and node n-1 is the destination.
+type evPlanner struct {
+	req   EVRequest
+	nodes int
+
+	// durations[u][v-1] and distances[u][v-1] are the travel from node u to node v.
+	durations, distances [][]float32
+}
+
+// evState is a state of the planner, the vehicle is at a node with a level of charge.
+// Charged is true after the vehicle is charged at the node, so it's charged once per stop.
+type evState struct {
+	node, level int
+	charged     bool
+}
+
+// evQueue is a priority queue of states by their durations.
+type evQueue struct {
+	states    []evState
+	durations map[evState]float64
+}
+
+func (q *evQueue) Len() int { return len(q.states) }
+func (q *evQueue) Less(i, j int) bool {
+	return q.durations[q.states[i]] < q.durations[q.states[j]]
+}
+func (q *evQueue) Swap(i, j int) { q.states[i], q.states[j] = q.states[j], q.states[i] }
+func (q *evQueue) Push(x any)    { q.states = append(q.states, x.(evState)) }
+func (q *evQueue) Pop() any {
+	s := q.states[len(q.states)-1]
+	q.states = q.states[:len(q.states)-1]
+	return s
+}
+
+// search returns the nodes of the chargers of the fastest plan and the states of charge the vehicle is charged to.
+func (p *evPlanner) search() (path []int, levels []float64, ok bool) {
+	resolution := float64(p.req.Resolution)
+	maxLevel := int(math.Floor(p.req.MaxSoC*resolution + 1e-9))
+	destination := p.nodes - 1
+	capacity := p.req.Vehicle.BatteryCapacity
+
+	start := evState{node: 0, level: int(math.Floor(p.req.InitialSoC*resolution + 1e-9)), charged: true}
+	durations := map[evState]float64{start: 0}
+	prev := make(map[evState]evState)
+	done := make(map[evState]bool)
+
+	q := &evQueue{durations: durations}
+	heap.Push(q, start)
+
+	relax := func(from, to evState, duration float64) {
+		if d, ok := durations[to]; ok && d <= duration {
+			return
+		}
+		durations[to] = duration
+		prev[to] = from
+		heap.Push(q, to)
+	}
+
+	var goal *evState
+	for q.Len() > 0 {
+		s := heap.Pop(q).(evState)
+		if done[s] {
+			continue
+		}
+		done[s] = true
+
+		if s.node == destination {
+			goal = &s
+			break
+		}
+
+		// Charging at a charger, to any higher level.
+		if s.node > 0 && !s.charged {
+			power := p.req.Vehicle.chargingPower(p.req.Chargers[s.node-1])
+			for level := s.level + 1; level <= maxLevel; level++ {
+				energy := float64(level-s.level) / resolution * capacity
+				relax(s, evState{node: s.node, level: level, charged: true}, durations[s]+p.req.StopDuration+energy/power*3600)
+			}
+		}
+
+		// Driving to a charger or the destination.
+		for next := 1; next < p.nodes; next++ {
+			if next == s.node {
+				continue
+			}
+
+			duration := float64(tableValue(p.durations, s.node, next-1))
+			distance := float64(tableValue(p.distances, s.node, next-1))
+			if math.IsInf(duration, 1) || math.IsInf(distance, 1) {
+				continue
+			}
+
+			soc := float64(s.level)/resolution - p.req.Vehicle.energy(distance, duration)/capacity
+			if soc < p.req.Reserve-1e-9 {
+				continue
+			}
+
+			level := int(math.Floor(soc*resolution + 1e-9))
+			relax(s, evState{node: next, level: level}, durations[s]+duration)
+		}
+	}
+
+	if goal == nil {
+		return nil, nil, false
+	}
+
+	// Charging states are the stops, they're collected from the goal back to the start.
+	for s := *goal; s != start; s = prev[s] {
+		if s.charged {
+			path = append(path, s.node)
+			levels = append(levels, float64(s.level)/resolution)
+		}
+	}
+	slices.Reverse(path)
+	slices.Reverse(levels)
+
+	return path, levels, true
+}
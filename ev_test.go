@@ -0,0 +1,203 @@
+package gosrm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newEVTestServer returns a fake OSRM table and route service, see tableTestHandler.
+// Routes have a leg between each pair of coordinates with the distance and duration of the table service,
+// and annotations with the given speed in m/s.
+func newEVTestServer(t *testing.T, speed float32) *httptest.Server {
+	table := tableTestHandler(t, nil)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, routeServiceURL) {
+			table(w, r)
+			return
+		}
+
+		assert.Equal(t, "true", r.URL.Query().Get("annotations"))
+
+		coordinates := parseTestCoordinates(r.URL.Path)
+		route := RouteType[string]{}
+		for i := 1; i < len(coordinates); i++ {
+			d := testTableDistance(coordinates[i-1], coordinates[i])
+			route.Legs = append(route.Legs, RouteLeg[string]{
+				Distance:   d,
+				Duration:   d / 10,
+				Annotation: Annotation{Distance: []float32{d / 2, d / 2}, Duration: []float32{d / 20, d / 20}, Speed: []float32{speed, speed}},
+			})
+			route.Distance += d
+			route.Duration += d / 10
+		}
+
+		res := RouteResponse[string]{Response: Response{Code: CodeOK}, Routes: []RouteType[string]{route}}
+		assert.NoError(t, json.NewEncoder(w).Encode(res))
+	}))
+}
+
+func TestPlanEVRoute(t *testing.T) {
+	srv := newEVTestServer(t, 10)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	req := EVRequest{
+		Profile:     ProfileCar,
+		Start:       Coordinate{0, 0},
+		Destination: Coordinate{0.8, 0},
+		Vehicle: EVVehicle{
+			BatteryCapacity:  10,
+			ConsumptionCurve: []EVConsumption{{Speed: 0, Consumption: 0.1}, {Speed: 72, Consumption: 0.3}},
+			MaxChargingPower: 80,
+		},
+		Chargers: []EVCharger{
+			{Location: Coordinate{-1, 0}, Power: 50},      // unreachable
+			{Location: Coordinate{0.4, 0.05}, Power: 100}, // faster but off the way
+			{Location: Coordinate{0.3, 0}, Power: 50},     // too far from the destination
+			{Location: Coordinate{0.4, 0}, Power: 50},
+		},
+		StopDuration: 60,
+		ChunkSize:    3,
+	}
+
+	plan, err := PlanEVRoute[string](context.Background(), osrm, req)
+	assert.NoError(t, err)
+
+	assert.Len(t, plan.Stops, 1)
+	stop := plan.Stops[0]
+	assert.Equal(t, 3, stop.Charger)
+	assert.Equal(t, Coordinate{0.4, 0}, stop.Location)
+	assert.InDelta(t, 0.2, stop.ArrivalSoC, 1e-6)
+	assert.InDelta(t, 0.9, stop.DepartureSoC, 1e-6)
+	assert.InDelta(t, 7, stop.Energy, 1e-6)
+	assert.InDelta(t, 60+504, stop.Duration, 1e-3)
+
+	assert.Len(t, plan.Route.Routes[0].Legs, 2)
+	assert.Len(t, plan.Legs, 2)
+	assert.InDelta(t, 1, plan.Legs[0].DepartureSoC, 1e-6)
+	assert.InDelta(t, 0.6, plan.Legs[0].SoC[0], 1e-6)
+	assert.InDelta(t, 0.2, plan.Legs[0].SoC[1], 1e-6)
+	assert.InDelta(t, 8, plan.Legs[0].Energy, 1e-6)
+	assert.InDelta(t, 0.9, plan.Legs[1].DepartureSoC, 1e-6)
+	assert.InDelta(t, 0.1, plan.Legs[1].ArrivalSoC, 1e-6)
+
+	assert.InDelta(t, 8000, plan.DrivingTime, 1e-3)
+	assert.InDelta(t, 564, plan.ChargingTime, 1e-3)
+	assert.InDelta(t, 8564, plan.Duration, 1e-3)
+
+	// No stops are needed when the destination is in range.
+	req.Destination = Coordinate{0.2, 0}
+	plan, err = PlanEVRoute[string](context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.Empty(t, plan.Stops)
+	assert.Len(t, plan.Legs, 1)
+	assert.InDelta(t, 0.6, plan.Legs[0].ArrivalSoC, 1e-6)
+
+	req.Destination = Coordinate{2, 0}
+	_, err = PlanEVRoute[string](context.Background(), osrm, req)
+	assert.ErrorIs(t, err, ErrNoEVRoute)
+
+	for _, invalid := range []EVRequest{
+		{},
+		{Vehicle: EVVehicle{BatteryCapacity: 10}},
+		{Vehicle: EVVehicle{BatteryCapacity: 10, ConsumptionCurve: []EVConsumption{{Speed: 50}, {Speed: 10}}}},
+		{Vehicle: EVVehicle{BatteryCapacity: 10, Consumption: 0.2}, Chargers: []EVCharger{{}}},
+		{Vehicle: EVVehicle{BatteryCapacity: 10, Consumption: 0.2}, Reserve: 0.5, MaxSoC: 0.5},
+		{Vehicle: EVVehicle{BatteryCapacity: 10, Consumption: 0.2}, StopDuration: -1},
+	} {
+		_, err := PlanEVRoute[string](context.Background(), osrm, invalid)
+		assert.ErrorIs(t, err, ErrInvalidEVRequest)
+	}
+}
+
+func TestPlanEVRoute_Reserve(t *testing.T) {
+	// Annotation speeds of the route are faster than the table, so legs consume more than planned.
+	srv := newEVTestServer(t, 20)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	req := EVRequest{
+		Profile:     ProfileCar,
+		Start:       Coordinate{0, 0},
+		Destination: Coordinate{0.2, 0},
+		Vehicle: EVVehicle{
+			BatteryCapacity:  10,
+			ConsumptionCurve: []EVConsumption{{Speed: 0, Consumption: 0.1}, {Speed: 72, Consumption: 0.3}},
+		},
+	}
+
+	plan, err := PlanEVRoute[string](context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.4, plan.Legs[0].ArrivalSoC, 1e-6)
+
+	req.Destination = Coordinate{0.4, 0}
+	_, err = PlanEVRoute[string](context.Background(), osrm, req)
+	assert.ErrorIs(t, err, ErrNoEVRoute)
+
+	// The route arrives with 0.04 which is below the default reserve, but a negative reserve means no reserve.
+	req.Destination = Coordinate{0.32, 0}
+	_, err = PlanEVRoute[string](context.Background(), osrm, req)
+	assert.ErrorIs(t, err, ErrNoEVRoute)
+
+	req.Reserve = -1
+	plan, err = PlanEVRoute[string](context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.04, plan.Legs[0].ArrivalSoC, 1e-6)
+
+	// A negative initial state of charge means the battery is empty.
+	req.InitialSoC = -1
+	_, err = PlanEVRoute[string](context.Background(), osrm, req)
+	assert.ErrorIs(t, err, ErrNoEVRoute)
+}
+
+func TestPlanEVRoute_Options(t *testing.T) {
+	srv := newEVTestServer(t, 10)
+	defer srv.Close()
+
+	var excludes []string
+	recorder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		excludes = append(excludes, r.URL.Query().Get("exclude"))
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer recorder.Close()
+
+	osrm, err := New(recorder.URL)
+	assert.NoError(t, err)
+
+	req := EVRequest{
+		Profile:     ProfileCar,
+		Start:       Coordinate{0, 0},
+		Destination: Coordinate{0.2, 0},
+		Vehicle:     EVVehicle{BatteryCapacity: 10, Consumption: 0.2},
+	}
+
+	_, err = PlanEVRoute[string](context.Background(), osrm, req, WithExclude([]string{"toll"}))
+	assert.NoError(t, err)
+
+	// Options are passed to the table and route requests.
+	assert.Equal(t, []string{"toll", "toll"}, excludes)
+}
+
+func TestEVVehicle_Consumption(t *testing.T) {
+	v := EVVehicle{Consumption: 0.2}
+	assert.Equal(t, 0.2, v.consumption(100))
+	assert.InDelta(t, 2, v.energy(10000, 500), 1e-9)
+
+	v.ConsumptionCurve = []EVConsumption{{Speed: 20, Consumption: 0.1}, {Speed: 60, Consumption: 0.2}, {Speed: 120, Consumption: 0.3}}
+	assert.Equal(t, 0.1, v.consumption(0))
+	assert.InDelta(t, 0.15, v.consumption(40), 1e-9)
+	assert.Equal(t, 0.2, v.consumption(60))
+	assert.InDelta(t, 0.25, v.consumption(90), 1e-9)
+	assert.Equal(t, 0.3, v.consumption(200))
+}
@@ -0,0 +1,67 @@
+package gosrm
+
+import (
+	"math"
+
+	"github.com/mojixcoder/gosrm/internal/geo"
+)
+
+// earthRadius is the mean radius of the earth, in meters.
+const earthRadius float64 = geo.EarthRadius
+
+// metersPerDegree is the length of a degree of latitude, in meters.
+const metersPerDegree float64 = geo.MetersPerDegree
+
+// HaversineDistance returns the great-circle distance between two coordinates, in meters.
+func HaversineDistance(a, b Coordinate) float64 {
+	lat1, lat2 := a[1]*math.Pi/180, b[1]*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b[0] - a[0]) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(min(h, 1)))
+}
+
+// offsetCoordinate returns the coordinate which is dx meters east and dy meters north of c.
+// It's an equirectangular approximation, accurate for offsets of a few kilometers.
+func offsetCoordinate(c Coordinate, dx, dy float64) Coordinate {
+	return geo.Offset(c, dx, dy)
+}
+
+// InitialBearing returns the initial bearing from a to b, in degrees clockwise from north in [0, 360).
+func InitialBearing(a, b Coordinate) float64 {
+	lat1, lat2 := a[1]*math.Pi/180, b[1]*math.Pi/180
+	dLng := (b[0] - a[0]) * math.Pi / 180
+
+	y := math.Sin(dLng) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLng)
+
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// bearingDifference returns the absolute difference of two bearings, in degrees in [0, 180].
+func bearingDifference(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 360)
+	return math.Min(d, 360-d)
+}
+
+// projectOnSegment projects p on the segment from a to b using an equirectangular approximation around p.
+// It returns the fraction of the segment before the projection and the distance of p from it, in meters.
+func projectOnSegment(p, a, b Coordinate) (t, distance float64) {
+	scale := math.Cos(p[1] * math.Pi / 180)
+	ax, ay := (a[0]-p[0])*scale*metersPerDegree, (a[1]-p[1])*metersPerDegree
+	bx, by := (b[0]-p[0])*scale*metersPerDegree, (b[1]-p[1])*metersPerDegree
+
+	dx, dy := bx-ax, by-ay
+	if l := dx*dx + dy*dy; l > 0 {
+		t = min(max(-(ax*dx+ay*dy)/l, 0), 1)
+	}
+
+	return t, math.Hypot(ax+t*dx, ay+t*dy)
+}
+
+// interpolate returns the coordinate at fraction t of the segment from a to b.
+func interpolate(a, b Coordinate, t float64) Coordinate {
+	return geo.Interpolate(a, b, t)
+}
@@ -0,0 +1,62 @@
+package gosrm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHaversineDistance(t *testing.T) {
+	assert.Equal(t, float64(0), HaversineDistance(Coordinate{13.4, 52.5}, Coordinate{13.4, 52.5}))
+	assert.InDelta(t, 111195, HaversineDistance(Coordinate{0, 0}, Coordinate{0, 1}), 1)
+	assert.InDelta(t, 111195, HaversineDistance(Coordinate{0, 0}, Coordinate{1, 0}), 1)
+
+	// Berlin to Paris.
+	assert.InDelta(t, 877500, HaversineDistance(Coordinate{13.4050, 52.5200}, Coordinate{2.3522, 48.8566}), 1000)
+}
+
+func TestOffsetCoordinate(t *testing.T) {
+	origin := Coordinate{13.4, 52.5}
+
+	c := offsetCoordinate(origin, 1000, 0)
+	assert.Equal(t, origin[1], c[1])
+	assert.InDelta(t, 1000, HaversineDistance(origin, c), 1)
+
+	c = offsetCoordinate(origin, 0, -2000)
+	assert.Equal(t, origin[0], c[0])
+	assert.InDelta(t, 2000, HaversineDistance(origin, c), 1)
+}
+
+func TestInitialBearing(t *testing.T) {
+	assert.InDelta(t, 0, InitialBearing(Coordinate{0, 0}, Coordinate{0, 1}), 1e-9)
+	assert.InDelta(t, 90, InitialBearing(Coordinate{0, 0}, Coordinate{1, 0}), 1e-9)
+	assert.InDelta(t, 180, InitialBearing(Coordinate{0, 1}, Coordinate{0, 0}), 1e-9)
+	assert.InDelta(t, 270, InitialBearing(Coordinate{1, 0}, Coordinate{0, 0}), 1e-9)
+	assert.InDelta(t, 45, InitialBearing(Coordinate{0, 0}, Coordinate{0.001, 0.001}), 0.01)
+}
+
+func TestBearingDifference(t *testing.T) {
+	assert.Equal(t, float64(20), bearingDifference(350, 10))
+	assert.Equal(t, float64(180), bearingDifference(0, 180))
+	assert.Equal(t, float64(90), bearingDifference(45, 315))
+}
+
+func TestProjectOnSegment(t *testing.T) {
+	a, b := Coordinate{0, 0}, Coordinate{0.01, 0}
+
+	tt, d := projectOnSegment(Coordinate{0.005, 0.001}, a, b)
+	assert.InDelta(t, 0.5, tt, 1e-9)
+	assert.InDelta(t, 111.2, d, 0.1)
+	assert.Equal(t, Coordinate{0.005, 0}, interpolate(a, b, tt))
+
+	tt, d = projectOnSegment(Coordinate{-0.001, 0}, a, b)
+	assert.Equal(t, float64(0), tt)
+	assert.InDelta(t, 111.2, d, 0.1)
+
+	tt, _ = projectOnSegment(Coordinate{1, 0}, a, b)
+	assert.Equal(t, float64(1), tt)
+
+	tt, d = projectOnSegment(Coordinate{0, 0.001}, a, a)
+	assert.Equal(t, float64(0), tt)
+	assert.InDelta(t, 111.2, d, 0.1)
+}
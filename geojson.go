@@ -0,0 +1,284 @@
+package gosrm
+
+type (
+	// FeatureCollection is a GeoJSON feature collection.
+	FeatureCollection struct {
+		// Type is always FeatureCollection.
+		Type string `json:"type"`
+
+		// Features of the collection.
+		Features []Feature `json:"features"`
+	}
+
+	// Feature is a GeoJSON feature.
+	Feature struct {
+		// Type is always Feature.
+		Type string `json:"type"`
+
+		// Geometry of the feature.
+		Geometry FeatureGeometry `json:"geometry"`
+
+		// Properties of the feature.
+		Properties map[string]any `json:"properties"`
+	}
+
+	// FeatureGeometry is a GeoJSON geometry.
+	FeatureGeometry struct {
+		// Type of the geometry, Point, LineString, Polygon or MultiPolygon.
+		Type string `json:"type"`
+
+		// Coordinates of the geometry.
+		// It's a Coordinate for points, []Coordinate for line strings, [][]Coordinate for polygons
+		// and [][][]Coordinate for multi polygons.
+		Coordinates any `json:"coordinates"`
+	}
+)
+
+// NewFeatureCollection returns a new feature collection of given features.
+func NewFeatureCollection(features ...Feature) FeatureCollection {
+	if features == nil {
+		features = []Feature{}
+	}
+	return FeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+// NewPointFeature returns a new point feature.
+func NewPointFeature(c Coordinate, properties map[string]any) Feature {
+	return newFeature("Point", c, properties)
+}
+
+// NewLineStringFeature returns a new line string feature.
+func NewLineStringFeature(coordinates []Coordinate, properties map[string]any) Feature {
+	return newFeature("LineString", coordinates, properties)
+}
+
+// NewPolygonFeature returns a new polygon feature.
+// The first ring is the exterior ring and the others are holes, rings have to be closed.
+func NewPolygonFeature(rings [][]Coordinate, properties map[string]any) Feature {
+	return newFeature("Polygon", rings, properties)
+}
+
+// NewMultiPolygonFeature returns a new multi polygon feature, rings of each polygon are the same as NewPolygonFeature.
+func NewMultiPolygonFeature(polygons [][][]Coordinate, properties map[string]any) Feature {
+	if polygons == nil {
+		polygons = [][][]Coordinate{}
+	}
+	return newFeature("MultiPolygon", polygons, properties)
+}
+
+// newFeature returns a new feature.
+func newFeature(geometryType string, coordinates any, properties map[string]any) Feature {
+	if properties == nil {
+		properties = map[string]any{}
+	}
+
+	return Feature{
+		Type:       "Feature",
+		Geometry:   FeatureGeometry{Type: geometryType, Coordinates: coordinates},
+		Properties: properties,
+	}
+}
+
+// GeoJSON converts the routes and waypoints of the response to a feature collection.
+// Routes, legs and steps are line strings and waypoints are points.
+// Legs and steps are only present if steps were requested.
+func (res RouteResponse[T]) GeoJSON() (FeatureCollection, error) {
+	fc := NewFeatureCollection()
+
+	for i, route := range res.Routes {
+		features, err := routeFeatures(route, map[string]any{"kind": "route", "route_index": i})
+		if err != nil {
+			return fc, err
+		}
+		fc.Features = append(fc.Features, features...)
+	}
+
+	for i, wp := range res.Waypoints {
+		fc.Features = append(fc.Features, waypointFeature(wp, map[string]any{"kind": "waypoint", "waypoint_index": i}))
+	}
+
+	return fc, nil
+}
+
+// GeoJSON converts the matchings and tracepoints of the response to a feature collection.
+// Matchings, legs and steps are line strings and tracepoints are points.
+// Tracepoints that were omitted by map matching are skipped.
+func (res MatchResponse[T]) GeoJSON() (FeatureCollection, error) {
+	fc := NewFeatureCollection()
+
+	for i, matching := range res.Matchings {
+		features, err := routeFeatures(matching.RouteType, map[string]any{
+			"kind":           "matching",
+			"matching_index": i,
+			"confidence":     matching.Confidence,
+		})
+		if err != nil {
+			return fc, err
+		}
+		fc.Features = append(fc.Features, features...)
+	}
+
+	for i, tp := range res.Tracepoints {
+		if tp.Omitted() {
+			continue
+		}
+
+		fc.Features = append(fc.Features, waypointFeature(tp.Waypoint, map[string]any{
+			"kind":               "tracepoint",
+			"tracepoint_index":   i,
+			"waypoint_index":     tp.WaypointIndex,
+			"matchings_index":    tp.MatchingIndex,
+			"alternatives_count": tp.AlternativesCount,
+		}))
+	}
+
+	return fc, nil
+}
+
+// GeoJSON converts the trips and waypoints of the response to a feature collection.
+// Trips, legs and steps are line strings and waypoints are points.
+func (res TripResponse[T]) GeoJSON() (FeatureCollection, error) {
+	fc := NewFeatureCollection()
+
+	for i, trip := range res.Trips {
+		features, err := routeFeatures(trip, map[string]any{"kind": "trip", "trips_index": i})
+		if err != nil {
+			return fc, err
+		}
+		fc.Features = append(fc.Features, features...)
+	}
+
+	for i, wp := range res.Waypoints {
+		fc.Features = append(fc.Features, waypointFeature(wp.Waypoint, map[string]any{
+			"kind":           "waypoint",
+			"input_index":    i,
+			"trips_index":    wp.TripsIndex,
+			"waypoint_index": wp.WaypointIndex,
+		}))
+	}
+
+	return fc, nil
+}
+
+// GeoJSON converts the waypoints of the response to a feature collection of points.
+func (res NearestResponse) GeoJSON() FeatureCollection {
+	fc := NewFeatureCollection()
+
+	for i, wp := range res.Waypoints {
+		fc.Features = append(fc.Features, waypointFeature(wp.Waypoint, map[string]any{
+			"kind":           "waypoint",
+			"waypoint_index": i,
+			"nodes":          wp.Nodes,
+		}))
+	}
+
+	return fc
+}
+
+// GeoJSON converts the sources and destinations of the response to a feature collection of points.
+func (res TableResponse) GeoJSON() FeatureCollection {
+	fc := NewFeatureCollection()
+
+	for i, wp := range res.Sources {
+		fc.Features = append(fc.Features, waypointFeature(wp, map[string]any{"kind": "source", "source_index": i}))
+	}
+
+	for i, wp := range res.Destinations {
+		fc.Features = append(fc.Features, waypointFeature(wp, map[string]any{"kind": "destination", "destination_index": i}))
+	}
+
+	return fc
+}
+
+// waypointFeature returns the point feature of a waypoint.
+func waypointFeature(wp Waypoint, properties map[string]any) Feature {
+	properties["name"] = wp.Name
+	properties["distance"] = wp.Distance
+
+	return NewPointFeature(wp.Location, properties)
+}
+
+// routeFeatures returns the line string features of a route, its legs and steps.
+// properties are copied to all of the features.
+func routeFeatures[T GeometryType](route RouteType[T], properties map[string]any) ([]Feature, error) {
+	var features []Feature
+
+	coordinates, err := coordinatesOf(route.Geometry)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(coordinates) > 0 {
+		features = append(features, NewLineStringFeature(coordinates, withProperties(properties, map[string]any{
+			"distance":    route.Distance,
+			"duration":    route.Duration,
+			"weight":      route.Weight,
+			"weight_name": route.WeightName,
+		})))
+	}
+
+	for i, leg := range route.Legs {
+		var legCoordinates []Coordinate
+		var stepFeatures []Feature
+
+		for j, step := range leg.Steps {
+			stepCoordinates, err := coordinatesOf(step.Geometry)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(stepCoordinates) == 0 {
+				continue
+			}
+
+			// Consecutive steps share their first and last coordinates.
+			if n := len(legCoordinates); n > 0 && legCoordinates[n-1] == stepCoordinates[0] {
+				legCoordinates = append(legCoordinates, stepCoordinates[1:]...)
+			} else {
+				legCoordinates = append(legCoordinates, stepCoordinates...)
+			}
+
+			stepFeatures = append(stepFeatures, NewLineStringFeature(stepCoordinates, withProperties(properties, map[string]any{
+				"kind":              "step",
+				"leg_index":         i,
+				"step_index":        j,
+				"distance":          step.Distance,
+				"duration":          step.Duration,
+				"name":              step.Name,
+				"ref":               step.Ref,
+				"mode":              step.Mode,
+				"maneuver_type":     step.Maneuver.Type,
+				"maneuver_modifier": step.Maneuver.Modifier,
+			})))
+		}
+
+		if len(legCoordinates) > 0 {
+			features = append(features, NewLineStringFeature(legCoordinates, withProperties(properties, map[string]any{
+				"kind":      "leg",
+				"leg_index": i,
+				"distance":  leg.Distance,
+				"duration":  leg.Duration,
+				"summary":   leg.Summary,
+			})))
+		}
+
+		features = append(features, stepFeatures...)
+	}
+
+	return features, nil
+}
+
+// withProperties returns a new map containing both base and extra properties.
+// extra properties take precedence.
+func withProperties(base, extra map[string]any) map[string]any {
+	properties := make(map[string]any, len(base)+len(extra))
+
+	for k, v := range base {
+		properties[k] = v
+	}
+	for k, v := range extra {
+		properties[k] = v
+	}
+
+	return properties
+}
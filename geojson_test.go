@@ -0,0 +1,175 @@
+package gosrm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRoute[T GeometryType](geometry, step1, step2 T) RouteType[T] {
+	return RouteType[T]{
+		Distance: 100,
+		Duration: 10,
+		Geometry: geometry,
+		Legs: []RouteLeg[T]{{
+			Distance: 100,
+			Duration: 10,
+			Summary:  "A, B",
+			Steps: []RouteStep[T]{
+				{Name: "A", Distance: 60, Geometry: step1, Maneuver: StepManeuver{Type: "depart"}},
+				{Name: "B", Distance: 40, Geometry: step2, Maneuver: StepManeuver{Type: "turn", Modifier: "left"}},
+			},
+		}},
+	}
+}
+
+func TestRouteResponse_GeoJSON(t *testing.T) {
+	coordinates := []Coordinate{{13.1, 52.1}, {13.2, 52.2}, {13.3, 52.3}}
+
+	t.Run("string", func(t *testing.T) {
+		res := RouteResponse[string]{
+			Routes: []RouteType[string]{newTestRoute(
+				EncodePolyline(coordinates, 5),
+				EncodePolyline(coordinates[:2], 5),
+				EncodePolyline(coordinates[1:], 5),
+			)},
+			Waypoints: []Waypoint{{Name: "A", Location: coordinates[0], Distance: 2}, {Name: "B", Location: coordinates[2]}},
+		}
+
+		fc, err := res.GeoJSON()
+		assert.NoError(t, err)
+		assert.Equal(t, "FeatureCollection", fc.Type)
+		assert.Len(t, fc.Features, 6)
+
+		route := fc.Features[0]
+		assert.Equal(t, "LineString", route.Geometry.Type)
+		assert.Equal(t, "route", route.Properties["kind"])
+		assert.Equal(t, float32(100), route.Properties["distance"])
+		assert.Len(t, route.Geometry.Coordinates, 3)
+
+		leg := fc.Features[1]
+		assert.Equal(t, "leg", leg.Properties["kind"])
+		assert.Equal(t, 0, leg.Properties["route_index"])
+		assert.Len(t, leg.Geometry.Coordinates, 3)
+
+		step := fc.Features[3]
+		assert.Equal(t, "step", step.Properties["kind"])
+		assert.Equal(t, 1, step.Properties["step_index"])
+		assert.Equal(t, "B", step.Properties["name"])
+		assert.Equal(t, "left", step.Properties["maneuver_modifier"])
+
+		wp := fc.Features[4]
+		assert.Equal(t, "Point", wp.Geometry.Type)
+		assert.Equal(t, coordinates[0], wp.Geometry.Coordinates)
+		assert.Equal(t, float32(2), wp.Properties["distance"])
+
+		_, err = json.Marshal(fc)
+		assert.NoError(t, err)
+
+		res.Routes[0].Geometry = "invalid polyline"
+		_, err = res.GeoJSON()
+		assert.ErrorIs(t, err, ErrInvalidPolyline)
+	})
+
+	t.Run("LineString", func(t *testing.T) {
+		res := RouteResponse[LineString]{
+			Routes: []RouteType[LineString]{newTestRoute(
+				LineString{Coordinates: coordinates},
+				LineString{Coordinates: coordinates[:2]},
+				LineString{},
+			)},
+		}
+
+		fc, err := res.GeoJSON()
+		assert.NoError(t, err)
+		assert.Len(t, fc.Features, 3)
+		assert.Equal(t, coordinates[:2], fc.Features[1].Geometry.Coordinates)
+	})
+}
+
+func TestMatchResponse_GeoJSON(t *testing.T) {
+	res := MatchResponse[AnyGeometry]{
+		Matchings: []Matching[AnyGeometry]{{
+			RouteType:  RouteType[AnyGeometry]{Geometry: NewGeometry(GeometryPolyline, []Coordinate{{1, 2}, {3, 4}})},
+			Confidence: 0.9,
+		}},
+		Tracepoints: []Tracepoint{{Waypoint: Waypoint{Location: Coordinate{1, 2}}, AlternativesCount: 1}, {}},
+	}
+
+	fc, err := res.GeoJSON()
+	assert.NoError(t, err)
+	assert.Len(t, fc.Features, 2)
+	assert.Equal(t, float32(0.9), fc.Features[0].Properties["confidence"])
+	assert.Equal(t, "tracepoint", fc.Features[1].Properties["kind"])
+	assert.Equal(t, uint16(1), fc.Features[1].Properties["alternatives_count"])
+
+	// Null tracepoints are decoded as omitted ones and encoded as null again.
+	data := `[{"hint":"h","location":[1,2],"name":"","distance":0,"waypoint_index":0,"matchings_index":0,"alternatives_count":0},null]`
+	assert.NoError(t, json.Unmarshal([]byte(data), &res.Tracepoints))
+	assert.False(t, res.Tracepoints[0].Omitted())
+	assert.True(t, res.Tracepoints[1].Omitted())
+
+	fc, err = res.GeoJSON()
+	assert.NoError(t, err)
+	assert.Len(t, fc.Features, 2)
+
+	encoded, err := json.Marshal(res.Tracepoints)
+	assert.NoError(t, err)
+	assert.JSONEq(t, data, string(encoded))
+}
+
+func TestTripResponse_GeoJSON(t *testing.T) {
+	res := TripResponse[LineString]{
+		Trips:     []RouteType[LineString]{{Geometry: LineString{Coordinates: []Coordinate{{1, 2}, {3, 4}}}}},
+		Waypoints: []TripWaypoint{{WaypointIndex: 1}, {WaypointIndex: 0}},
+	}
+
+	fc, err := res.GeoJSON()
+	assert.NoError(t, err)
+	assert.Len(t, fc.Features, 3)
+	assert.Equal(t, "trip", fc.Features[0].Properties["kind"])
+	assert.Equal(t, uint16(1), fc.Features[1].Properties["waypoint_index"])
+	assert.Equal(t, 1, fc.Features[2].Properties["input_index"])
+}
+
+func TestNearestResponse_GeoJSON(t *testing.T) {
+	res := NearestResponse{Waypoints: []NearestWaypoint{{Waypoint: Waypoint{Location: Coordinate{1, 2}}, Nodes: []uint64{1, 2}}}}
+
+	fc := res.GeoJSON()
+	assert.Len(t, fc.Features, 1)
+	assert.Equal(t, []uint64{1, 2}, fc.Features[0].Properties["nodes"])
+}
+
+func TestTableResponse_GeoJSON(t *testing.T) {
+	res := TableResponse{
+		Sources:      []Waypoint{{Location: Coordinate{1, 2}}},
+		Destinations: []Waypoint{{Location: Coordinate{3, 4}}, {Location: Coordinate{5, 6}}},
+	}
+
+	fc := res.GeoJSON()
+	assert.Len(t, fc.Features, 3)
+	assert.Equal(t, "source", fc.Features[0].Properties["kind"])
+	assert.Equal(t, "destination", fc.Features[2].Properties["kind"])
+	assert.Equal(t, 1, fc.Features[2].Properties["destination_index"])
+
+	data, err := json.Marshal(NewFeatureCollection())
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"FeatureCollection","features":[]}`, string(data))
+}
+
+func TestNewPolygonFeature(t *testing.T) {
+	f := NewPolygonFeature([][]Coordinate{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}, nil)
+	assert.Equal(t, "Polygon", f.Geometry.Type)
+	assert.NotNil(t, f.Properties)
+}
+
+func TestNewMultiPolygonFeature(t *testing.T) {
+	f := NewMultiPolygonFeature(nil, map[string]any{"a": 1})
+	assert.Equal(t, "MultiPolygon", f.Geometry.Type)
+	assert.Equal(t, 1, f.Properties["a"])
+
+	data, err := json.Marshal(f)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"Feature","geometry":{"type":"MultiPolygon","coordinates":[]},"properties":{"a":1}}`, string(data))
+}
@@ -0,0 +1,258 @@
+package gosrm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+)
+
+// ErrGeometryMismatch is returned when the geometry type of a response can't hold the requested geometries.
+var ErrGeometryMismatch = errors.New("gosrm: geometry type doesn't match the requested geometries")
+
+// ErrInvalidPolyline is returned when an encoded polyline is malformed.
+var ErrInvalidPolyline = errors.New("gosrm: invalid polyline")
+
+// AnyGeometry is a geometry that can hold all of the geometry formats, polyline, polyline6 and geojson.
+// Geometries of the responses of the services are decoded according to the requested format, so Coordinates can be used.
+// Polylines unmarshaled from other JSON aren't decoded, as polyline and polyline6 can't be told apart,
+// so their Coordinates are nil, see DecodePolyline.
+type AnyGeometry struct {
+	// Format is the format of the geometry.
+	Format GeometryFormat
+
+	// Polyline is the encoded polyline, it's empty if format is geojson.
+	Polyline string
+
+	// coordinates are the decoded coordinates of the geometry.
+	coordinates []Coordinate
+}
+
+// NewGeometry returns a new geometry in the given format from the coordinates.
+func NewGeometry(format GeometryFormat, coordinates []Coordinate) AnyGeometry {
+	g := AnyGeometry{Format: format, coordinates: coordinates}
+
+	if format != GeometryGeoJSON {
+		g.Polyline = EncodePolyline(coordinates, polylinePrecision(format))
+	}
+
+	return g
+}
+
+// Coordinates returns the {Lng, Lat} coordinates of the geometry.
+func (g AnyGeometry) Coordinates() []Coordinate {
+	return g.coordinates
+}
+
+// LineString returns the geometry as a GeoJSON line string.
+func (g AnyGeometry) LineString() LineString {
+	return LineString{Type: "LineString", Coordinates: g.coordinates}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Polylines are kept encoded until the format of the geometry is known.
+func (g *AnyGeometry) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		*g = AnyGeometry{Format: GeometryPolyline}
+		return json.Unmarshal(data, &g.Polyline)
+	default:
+		var ls LineString
+		if err := json.Unmarshal(data, &ls); err != nil {
+			return err
+		}
+		*g = AnyGeometry{Format: GeometryGeoJSON, coordinates: ls.Coordinates}
+		return nil
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// The geometry is marshaled the same way OSRM returns it.
+func (g AnyGeometry) MarshalJSON() ([]byte, error) {
+	if g.Format == GeometryGeoJSON {
+		return json.Marshal(g.LineString())
+	}
+	return json.Marshal(g.Polyline)
+}
+
+// decode decodes the polyline of the geometry in the given format.
+func (g *AnyGeometry) decode(format GeometryFormat) error {
+	if g.Format == GeometryGeoJSON || format == GeometryGeoJSON {
+		return nil
+	}
+
+	g.Format = format
+	if g.Polyline == "" {
+		return nil
+	}
+
+	coordinates, err := DecodePolyline(g.Polyline, polylinePrecision(format))
+	if err != nil {
+		return err
+	}
+	g.coordinates = coordinates
+
+	return nil
+}
+
+// polylinePrecision returns the precision of the polyline format.
+func polylinePrecision(format GeometryFormat) uint8 {
+	if format == GeometryPolyline6 {
+		return 6
+	}
+	return 5
+}
+
+// requestedGeometries returns the geometries format of the request URL.
+func requestedGeometries(u *url.URL) GeometryFormat {
+	if format := u.Query().Get("geometries"); format != "" {
+		return GeometryFormat(format)
+	}
+	return GeometryPolyline
+}
+
+// checkGeometryType returns an error if T can't hold geometries of the given format.
+func checkGeometryType[T GeometryType](format GeometryFormat) error {
+	var zero T
+
+	switch any(zero).(type) {
+	case string:
+		// Polylines in strings are decoded with precision of 5, since strings don't know their format.
+		if format != GeometryPolyline {
+			return fmt.Errorf("%w: string can't hold %s geometries, use gosrm.AnyGeometry", ErrGeometryMismatch, format)
+		}
+	case LineString:
+		if format != GeometryGeoJSON {
+			return fmt.Errorf("%w: gosrm.LineString can't hold %s geometries, use string or gosrm.AnyGeometry", ErrGeometryMismatch, format)
+		}
+	}
+
+	return nil
+}
+
+// decodeGeometries decodes the polylines of the route and its steps if T is AnyGeometry.
+func decodeGeometries[T GeometryType](route *RouteType[T], format GeometryFormat) error {
+	decode := func(g *T) error {
+		if geom, ok := any(g).(*AnyGeometry); ok {
+			return geom.decode(format)
+		}
+		return nil
+	}
+
+	if err := decode(&route.Geometry); err != nil {
+		return err
+	}
+
+	for i := range route.Legs {
+		for j := range route.Legs[i].Steps {
+			if err := decode(&route.Legs[i].Steps[j].Geometry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// coordinatesOf returns the coordinates of a geometry.
+// string geometries are decoded as polyline with precision of 5, responses with other formats can't have them,
+// see checkGeometryType.
+func coordinatesOf[T GeometryType](g T) ([]Coordinate, error) {
+	switch geom := any(g).(type) {
+	case string:
+		return DecodePolyline(geom, 5)
+	case LineString:
+		return geom.Coordinates, nil
+	case AnyGeometry:
+		return geom.Coordinates(), nil
+	}
+
+	return nil, nil
+}
+
+// DecodePolyline decodes an encoded polyline with the given precision to {Lng, Lat} coordinates.
+// OSRM uses a precision of 5 for polyline and 6 for polyline6.
+func DecodePolyline(encoded string, precision uint8) ([]Coordinate, error) {
+	factor := math.Pow(10, float64(precision))
+	coordinates := make([]Coordinate, 0, len(encoded)/4)
+
+	var lat, lng int64
+	for i := 0; i < len(encoded); {
+		var deltas [2]int64
+
+		for j := range deltas {
+			var result int64
+			var shift uint
+
+			for {
+				if i >= len(encoded) {
+					return nil, ErrInvalidPolyline
+				}
+
+				b := int64(encoded[i]) - 63
+				i++
+
+				if b < 0 || b > 63 {
+					return nil, ErrInvalidPolyline
+				}
+
+				result |= (b & 0x1f) << shift
+				shift += 5
+
+				if b < 0x20 {
+					break
+				}
+			}
+
+			if result&1 != 0 {
+				deltas[j] = ^(result >> 1)
+			} else {
+				deltas[j] = result >> 1
+			}
+		}
+
+		lat += deltas[0]
+		lng += deltas[1]
+
+		coordinates = append(coordinates, Coordinate{float64(lng) / factor, float64(lat) / factor})
+	}
+
+	return coordinates, nil
+}
+
+// EncodePolyline encodes {Lng, Lat} coordinates to a polyline with the given precision.
+// OSRM uses a precision of 5 for polyline and 6 for polyline6.
+func EncodePolyline(coordinates []Coordinate, precision uint8) string {
+	factor := math.Pow(10, float64(precision))
+
+	var b bytes.Buffer
+	var prevLat, prevLng int64
+
+	for _, c := range coordinates {
+		lat := int64(math.Round(c[1] * factor))
+		lng := int64(math.Round(c[0] * factor))
+
+		for _, delta := range [2]int64{lat - prevLat, lng - prevLng} {
+			v := delta << 1
+			if delta < 0 {
+				v = ^v
+			}
+
+			for v >= 0x20 {
+				b.WriteByte(byte((0x20 | (v & 0x1f)) + 63))
+				v >>= 5
+			}
+			b.WriteByte(byte(v + 63))
+		}
+
+		prevLat, prevLng = lat, lng
+	}
+
+	return b.String()
+}
@@ -0,0 +1,154 @@
+package gosrm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var polylineCoordinates = []Coordinate{{-120.2, 38.5}, {-120.95, 40.7}, {-126.453, 43.252}}
+
+const encodedPolyline string = "_p~iF~ps|U_ulLnnqC_mqNvxq`@"
+
+func TestDecodePolyline(t *testing.T) {
+	coordinates, err := DecodePolyline(encodedPolyline, 5)
+	assert.NoError(t, err)
+	assert.Len(t, coordinates, 3)
+	for i := range coordinates {
+		assert.InDelta(t, polylineCoordinates[i][0], coordinates[i][0], 1e-9)
+		assert.InDelta(t, polylineCoordinates[i][1], coordinates[i][1], 1e-9)
+	}
+
+	coordinates, err = DecodePolyline("", 5)
+	assert.NoError(t, err)
+	assert.Empty(t, coordinates)
+
+	_, err = DecodePolyline("_p~iF~ps|", 5)
+	assert.ErrorIs(t, err, ErrInvalidPolyline)
+
+	_, err = DecodePolyline("_p~iF~ps|U\x01", 5)
+	assert.ErrorIs(t, err, ErrInvalidPolyline)
+}
+
+func TestEncodePolyline(t *testing.T) {
+	assert.Equal(t, encodedPolyline, EncodePolyline(polylineCoordinates, 5))
+
+	encoded := EncodePolyline(polylineCoordinates, 6)
+	coordinates, err := DecodePolyline(encoded, 6)
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{-120.2, 38.5}, coordinates[0][:], 1e-9)
+	assert.InDeltaSlice(t, []float64{-126.453, 43.252}, coordinates[2][:], 1e-9)
+}
+
+func TestGeometry_JSON(t *testing.T) {
+	var g AnyGeometry
+
+	assert.NoError(t, json.Unmarshal([]byte(`"`+encodedPolyline+`"`), &g))
+	assert.Equal(t, GeometryPolyline, g.Format)
+	assert.Equal(t, encodedPolyline, g.Polyline)
+
+	// Polylines aren't decoded until their precision is known.
+	assert.Nil(t, g.Coordinates())
+
+	assert.NoError(t, g.decode(GeometryPolyline))
+	assert.Len(t, g.Coordinates(), 3)
+
+	data, err := json.Marshal(g)
+	assert.NoError(t, err)
+	assert.Equal(t, `"`+encodedPolyline+`"`, string(data))
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"type":"LineString","coordinates":[[1,2],[3,4]]}`), &g))
+	assert.Equal(t, GeometryGeoJSON, g.Format)
+	assert.Equal(t, []Coordinate{{1, 2}, {3, 4}}, g.Coordinates())
+	assert.NoError(t, g.decode(GeometryPolyline))
+	assert.Equal(t, []Coordinate{{1, 2}, {3, 4}}, g.Coordinates())
+
+	data, err = json.Marshal(g)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"LineString","coordinates":[[1,2],[3,4]]}`, string(data))
+
+	g = AnyGeometry{}
+	assert.NoError(t, json.Unmarshal([]byte(`null`), &g))
+	assert.Equal(t, AnyGeometry{}, g)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"coordinates":"invalid"}`), &g))
+}
+
+func TestNewGeometry(t *testing.T) {
+	g := NewGeometry(GeometryPolyline, polylineCoordinates)
+	assert.Equal(t, encodedPolyline, g.Polyline)
+	assert.Equal(t, polylineCoordinates, g.Coordinates())
+
+	g = NewGeometry(GeometryGeoJSON, polylineCoordinates)
+	assert.Empty(t, g.Polyline)
+	assert.Equal(t, LineString{Type: "LineString", Coordinates: polylineCoordinates}, g.LineString())
+}
+
+func TestCheckGeometryType(t *testing.T) {
+	assert.NoError(t, checkGeometryType[string](GeometryPolyline))
+	assert.ErrorIs(t, checkGeometryType[string](GeometryPolyline6), ErrGeometryMismatch)
+	assert.ErrorIs(t, checkGeometryType[string](GeometryGeoJSON), ErrGeometryMismatch)
+
+	assert.NoError(t, checkGeometryType[LineString](GeometryGeoJSON))
+	assert.ErrorIs(t, checkGeometryType[LineString](GeometryPolyline), ErrGeometryMismatch)
+
+	assert.NoError(t, checkGeometryType[AnyGeometry](GeometryPolyline))
+	assert.NoError(t, checkGeometryType[AnyGeometry](GeometryPolyline6))
+	assert.NoError(t, checkGeometryType[AnyGeometry](GeometryGeoJSON))
+}
+
+func TestCoordinatesOf(t *testing.T) {
+	coordinates, err := coordinatesOf(encodedPolyline)
+	assert.NoError(t, err)
+	assert.Len(t, coordinates, 3)
+
+	coordinates, err = coordinatesOf(LineString{Coordinates: []Coordinate{{1, 2}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []Coordinate{{1, 2}}, coordinates)
+
+	coordinates, err = coordinatesOf(NewGeometry(GeometryPolyline6, []Coordinate{{1, 2}}))
+	assert.NoError(t, err)
+	assert.Equal(t, []Coordinate{{1, 2}}, coordinates)
+}
+
+func TestRoute_Geometry(t *testing.T) {
+	polyline6 := EncodePolyline(polylineCoordinates, 6)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":"Ok","routes":[{"geometry":"` + polyline6 + `","legs":[{"steps":[{"geometry":"` + polyline6 + `"}]}]}]}`))
+	}))
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	req := Request{Profile: ProfileCar, Coordinates: []Coordinate{{1, 2}, {3, 4}}}
+
+	res, err := Route[AnyGeometry](context.Background(), osrm, req, WithGeometries(GeometryPolyline6), WithSteps(true))
+	assert.NoError(t, err)
+	assert.Equal(t, GeometryPolyline6, res.Routes[0].Geometry.Format)
+	assert.Len(t, res.Routes[0].Geometry.Coordinates(), 3)
+	assert.InDeltaSlice(t, []float64{-120.2, 38.5}, res.Routes[0].Geometry.Coordinates()[0][:], 1e-9)
+	assert.Len(t, res.Routes[0].Legs[0].Steps[0].Geometry.Coordinates(), 3)
+
+	anyRes, err := RouteAny(context.Background(), osrm, req, WithGeometries(GeometryPolyline6))
+	assert.NoError(t, err)
+	assert.Len(t, anyRes.Routes[0].Geometry.Coordinates(), 3)
+
+	_, err = Route[LineString](context.Background(), osrm, req)
+	assert.ErrorIs(t, err, ErrGeometryMismatch)
+
+	_, err = Match[string](context.Background(), osrm, req, WithGeometries(GeometryGeoJSON))
+	assert.ErrorIs(t, err, ErrGeometryMismatch)
+
+	_, err = Trip[LineString](context.Background(), osrm, req, WithGeometries(GeometryPolyline6))
+	assert.ErrorIs(t, err, ErrGeometryMismatch)
+}
+
+func TestGeometry_Alias(t *testing.T) {
+	assert.Equal(t, GeometryPolyline6, Geometry("polyline6"))
+	assert.Equal(t, GeometryPolyline6, GeometryFormat(Geometry("polyline6")))
+}
@@ -3,6 +3,7 @@ package gosrm
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -20,6 +21,8 @@ type (
 		baseURL *url.URL
 
 		client HTTPClient
+
+		hints *HintStore
 	}
 
 	// Request is the OSRM's request structure.
@@ -31,6 +34,10 @@ type (
 
 		// Coordinates is the coordinate of the request.
 		Coordinates []Coordinate
+
+		// IDs are optional caller-defined IDs of the coordinates, IDs[i] belongs to Coordinates[i].
+		// They are used as keys of the hint store if they are set.
+		IDs []string
 	}
 )
 
@@ -57,21 +64,67 @@ func (osrm *OSRMClient) SetHTTPClient(client HTTPClient) {
 	osrm.client = client
 }
 
+// SetHintStore sets the hint store that is used to reuse hints across requests.
+// Hints of the responses are stored in it and stored hints are added to route, table and trip requests.
+// Hints passed by WithHints option take precedence over stored hints.
+// Passing nil disables the hint store.
+func (osrm *OSRMClient) SetHintStore(store *HintStore) {
+	osrm.hints = store
+}
+
 // get calls the given URL and parses the response.
 func (osrm OSRMClient) get(ctx context.Context, url string, out any) error {
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	body, err := osrm.open(ctx, url)
 	if err != nil {
 		return err
 	}
+	defer body.Close()
+
+	return json.NewDecoder(body).Decode(out)
+}
+
+// open calls the given URL and returns the body of the response, it has to be closed by the caller.
+func (osrm OSRMClient) open(ctx context.Context, url string) (io.ReadCloser, error) {
+	res, err := osrm.do(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Body, nil
+}
+
+// do calls the given URL and returns the response, its body has to be closed by the caller.
+func (osrm OSRMClient) do(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
 	req = req.WithContext(ctx)
 
-	res, err := osrm.client.Do(req)
+	return osrm.client.Do(req)
+}
+
+// Raw calls a service, e.g. "route", and returns the status code and the body of the response as OSRM sent them.
+// It's useful for forwarding responses without decoding them. Stored hints are added to the request,
+// but the hint store isn't updated since the response isn't decoded.
+func (osrm OSRMClient) Raw(ctx context.Context, service string, req Request, opts ...Option) (int, []byte, error) {
+	u := req.buildURLPath(*osrm.baseURL, "/"+service+"/v1")
+
+	osrm.injectHints(u, req)
+	osrm.applyOpts(u, opts)
+
+	res, err := osrm.do(ctx, u.String())
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 	defer res.Body.Close()
 
-	return json.NewDecoder(res.Body).Decode(out)
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return res.StatusCode, body, nil
 }
 
 // applyOpts applys options to the URL.
@@ -81,6 +134,28 @@ func (osrm OSRMClient) applyOpts(u *url.URL, opts []Option) {
 	}
 }
 
+// injectHints adds stored hints of the request to the URL if a hint store is set.
+func (osrm OSRMClient) injectHints(u *url.URL, req Request) {
+	if osrm.hints != nil {
+		osrm.hints.inject(u, req)
+	}
+}
+
+// hintKeys returns the hint keys of the request if a hint store is set.
+func (osrm OSRMClient) hintKeys(req Request) []string {
+	if osrm.hints == nil {
+		return nil
+	}
+	return osrm.hints.Keys(req)
+}
+
+// updateHints stores hints of the waypoints of a response if a hint store is set and the response is Ok.
+func (osrm OSRMClient) updateHints(keys []string, waypoints []Waypoint, res Response) {
+	if osrm.hints != nil && res.IsOk() {
+		osrm.hints.Update(keys, waypoints, res.DataVersion)
+	}
+}
+
 // buildURLPath builds the path of OSRM's services.
 func (req Request) buildURLPath(u url.URL, servicePath string) *url.URL {
 	path := strings.TrimSuffix(u.Path, "/")
@@ -76,6 +76,26 @@ func TestOSRMClient_get(t *testing.T) {
 	assert.Equal(t, "Ok", res["message"])
 }
 
+func TestOSRMClient_Raw(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/route/v1/car/1.000000,2.000000;3.000000,4.000000.json", r.URL.Path)
+		assert.Equal(t, "false", r.URL.Query().Get("steps"))
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"NoRoute"}`))
+	}))
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	req := Request{Profile: ProfileCar, Coordinates: []Coordinate{{1, 2}, {3, 4}}}
+	status, body, err := osrm.Raw(context.Background(), "route", req, WithSteps(false))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, `{"code":"NoRoute"}`, string(body))
+}
+
 func TestOSRMClient_applyOpts(t *testing.T) {
 	osrm := newOSRMClient()
 	u := url.URL{}
@@ -0,0 +1,188 @@
+package gosrm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// gpxNamespace is the namespace of GPX 1.1 documents.
+const gpxNamespace string = "http://www.topografix.com/GPX/1/1"
+
+type (
+	// GPXTrace is a GPX track segment converted to a match request.
+	GPXTrace struct {
+		// Name of the track the segment belongs to.
+		Name string
+
+		// Request holds the coordinates of the track points.
+		Request Request
+
+		// Timestamps are the times of the track points in UNIX seconds.
+		// It's nil if any of the points doesn't have a time.
+		Timestamps []int64
+	}
+
+	// gpxDocument is the root element of a GPX document.
+	gpxDocument struct {
+		XMLName   xml.Name   `xml:"gpx"`
+		Version   string     `xml:"version,attr"`
+		Creator   string     `xml:"creator,attr"`
+		Xmlns     string     `xml:"xmlns,attr,omitempty"`
+		Waypoints []gpxPoint `xml:"wpt"`
+		Routes    []gpxRoute `xml:"rte"`
+		Tracks    []gpxTrack `xml:"trk"`
+	}
+
+	// gpxPoint is a GPX wpt, rtept or trkpt element.
+	gpxPoint struct {
+		Lat  float64 `xml:"lat,attr"`
+		Lon  float64 `xml:"lon,attr"`
+		Time string  `xml:"time,omitempty"`
+		Name string  `xml:"name,omitempty"`
+		Desc string  `xml:"desc,omitempty"`
+	}
+
+	// gpxRoute is a GPX rte element.
+	gpxRoute struct {
+		Name   string     `xml:"name,omitempty"`
+		Points []gpxPoint `xml:"rtept"`
+	}
+
+	// gpxTrack is a GPX trk element.
+	gpxTrack struct {
+		Name     string            `xml:"name,omitempty"`
+		Segments []gpxTrackSegment `xml:"trkseg"`
+	}
+
+	// gpxTrackSegment is a GPX trkseg element.
+	gpxTrackSegment struct {
+		Points []gpxPoint `xml:"trkpt"`
+	}
+)
+
+// Options returns the match options of the trace, WithTimestamps if the trace has timestamps.
+func (t GPXTrace) Options() []Option {
+	if t.Timestamps == nil {
+		return nil
+	}
+	return []Option{WithTimestamps(t.Timestamps)}
+}
+
+// ParseGPX parses the tracks of a GPX document.
+// Each track segment is returned as a trace which is ready to be used in match service.
+func ParseGPX(r io.Reader, profile Profile) ([]GPXTrace, error) {
+	var doc gpxDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var traces []GPXTrace
+	for _, trk := range doc.Tracks {
+		for _, seg := range trk.Segments {
+			trace := GPXTrace{
+				Name:       trk.Name,
+				Request:    Request{Profile: profile, Coordinates: make([]Coordinate, len(seg.Points))},
+				Timestamps: make([]int64, len(seg.Points)),
+			}
+
+			for i, pt := range seg.Points {
+				trace.Request.Coordinates[i] = Coordinate{pt.Lon, pt.Lat}
+
+				if pt.Time == "" {
+					trace.Timestamps = nil
+					continue
+				}
+
+				if trace.Timestamps != nil {
+					t, err := time.Parse(time.RFC3339, strings.TrimSpace(pt.Time))
+					if err != nil {
+						return nil, fmt.Errorf("gosrm: invalid gpx time %q: %w", pt.Time, err)
+					}
+					trace.Timestamps[i] = t.Unix()
+				}
+			}
+
+			traces = append(traces, trace)
+		}
+	}
+
+	return traces, nil
+}
+
+// WriteGPX writes the route as a GPX document.
+// The geometry of the route is written as a track, steps as a route with an instruction for each maneuver
+// and waypoints as GPX waypoints.
+// Steps are only written if they were requested.
+func WriteGPX[T GeometryType](w io.Writer, name string, route RouteType[T], waypoints []Waypoint) error {
+	doc := gpxDocument{Version: "1.1", Creator: "gosrm", Xmlns: gpxNamespace}
+
+	for _, wp := range waypoints {
+		doc.Waypoints = append(doc.Waypoints, gpxPoint{Lat: wp.Location[1], Lon: wp.Location[0], Name: wp.Name})
+	}
+
+	steps := routeSteps(route)
+	if len(steps) > 0 {
+		rte := gpxRoute{Name: name}
+		for _, step := range steps {
+			rte.Points = append(rte.Points, gpxPoint{
+				Lat:  step.Maneuver.Location[1],
+				Lon:  step.Maneuver.Location[0],
+				Name: step.Name,
+				Desc: stepDescription(step),
+			})
+		}
+		doc.Routes = append(doc.Routes, rte)
+	}
+
+	coordinates, err := coordinatesOf(route.Geometry)
+	if err != nil {
+		return err
+	}
+
+	if len(coordinates) > 0 {
+		seg := gpxTrackSegment{Points: make([]gpxPoint, len(coordinates))}
+		for i, c := range coordinates {
+			seg.Points[i] = gpxPoint{Lat: c[1], Lon: c[0]}
+		}
+		doc.Tracks = append(doc.Tracks, gpxTrack{Name: name, Segments: []gpxTrackSegment{seg}})
+	}
+
+	return writeXML(w, doc)
+}
+
+// routeSteps returns the steps of all legs of the route.
+func routeSteps[T GeometryType](route RouteType[T]) []RouteStep[T] {
+	var steps []RouteStep[T]
+	for _, leg := range route.Legs {
+		steps = append(steps, leg.Steps...)
+	}
+	return steps
+}
+
+// stepDescription returns a short description of the step maneuver, e.g. "turn left onto Hardenbergstraße".
+func stepDescription[T GeometryType](step RouteStep[T]) string {
+	desc := strings.TrimSpace(step.Maneuver.Type + " " + step.Maneuver.Modifier)
+	if step.Name != "" {
+		desc += " onto " + step.Name
+	}
+	return desc
+}
+
+// writeXML writes the XML header and the indented document.
+func writeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,82 @@
+package gosrm
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGPX string = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <trk>
+    <name>morning</name>
+    <trkseg>
+      <trkpt lat="52.517037" lon="13.388860"><time>2023-01-01T10:00:00Z</time></trkpt>
+      <trkpt lat="52.529407" lon="13.397634"><time>2023-01-01T10:00:30Z</time></trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="52.523219" lon="13.428555"></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+func TestParseGPX(t *testing.T) {
+	traces, err := ParseGPX(strings.NewReader(testGPX), ProfileCar)
+	assert.NoError(t, err)
+	assert.Len(t, traces, 2)
+
+	assert.Equal(t, "morning", traces[0].Name)
+	assert.Equal(t, ProfileCar, traces[0].Request.Profile)
+	assert.Equal(t, []Coordinate{{13.388860, 52.517037}, {13.397634, 52.529407}}, traces[0].Request.Coordinates)
+	assert.Equal(t, []int64{1672567200, 1672567230}, traces[0].Timestamps)
+
+	var u url.URL
+	opts := traces[0].Options()
+	assert.Len(t, opts, 1)
+	opts[0].apply(&u)
+	assert.Equal(t, "1672567200;1672567230", u.Query().Get("timestamps"))
+
+	assert.Nil(t, traces[1].Timestamps)
+	assert.Nil(t, traces[1].Options())
+
+	_, err = ParseGPX(strings.NewReader(strings.Replace(testGPX, "10:00:30Z", "invalid", 1)), ProfileCar)
+	assert.Error(t, err)
+
+	_, err = ParseGPX(strings.NewReader("<gpx"), ProfileCar)
+	assert.Error(t, err)
+}
+
+func TestWriteGPX(t *testing.T) {
+	coordinates := []Coordinate{{13.1, 52.1}, {13.2, 52.2}}
+	route := RouteType[LineString]{
+		Geometry: LineString{Coordinates: coordinates},
+		Legs: []RouteLeg[LineString]{{Steps: []RouteStep[LineString]{
+			{Name: "Hardenbergstraße", Maneuver: StepManeuver{Type: "turn", Modifier: "left", Location: coordinates[0]}},
+			{Maneuver: StepManeuver{Type: "arrive", Location: coordinates[1]}},
+		}}},
+	}
+
+	var buf bytes.Buffer
+	err := WriteGPX(&buf, "route", route, []Waypoint{{Name: "start", Location: coordinates[0]}})
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "<?xml"))
+	assert.Contains(t, out, `<gpx version="1.1" creator="gosrm" xmlns="http://www.topografix.com/GPX/1/1">`)
+	assert.Contains(t, out, `<wpt lat="52.1" lon="13.1">`)
+	assert.Contains(t, out, `<desc>turn left onto Hardenbergstraße</desc>`)
+	assert.Contains(t, out, `<desc>arrive</desc>`)
+	assert.Contains(t, out, `<trkpt lat="52.2" lon="13.2"></trkpt>`)
+
+	// Written documents can be parsed back.
+	traces, err := ParseGPX(&buf, ProfileCar)
+	assert.NoError(t, err)
+	assert.Len(t, traces, 1)
+	assert.Equal(t, coordinates, traces[0].Request.Coordinates)
+
+	err = WriteGPX(&buf, "route", RouteType[string]{Geometry: "invalid polyline"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidPolyline)
+}
@@ -0,0 +1,171 @@
+package gosrm
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHintPrecision is the default number of decimal places coordinates are rounded to when used as hint keys.
+// 5 decimal places is roughly 1 meter.
+const defaultHintPrecision uint8 = 5
+
+// HintStore keeps OSRM hints of locations so they can be reused in subsequent requests.
+// Locations are keyed by Request.IDs if they are set, otherwise by their rounded coordinates.
+// Hints are ephemeral, so the store is flushed whenever the data version of a response changes.
+// It's safe for concurrent use.
+type HintStore struct {
+	mu          sync.RWMutex
+	hints       map[string]string
+	dataVersion time.Time
+	precision   uint8
+}
+
+// NewHintStore returns a new hint store.
+// Precision is the number of decimal places coordinates are rounded to when they are used as keys.
+// If it's 0 then 5 decimal places are used.
+func NewHintStore(precision uint8) *HintStore {
+	if precision == 0 {
+		precision = defaultHintPrecision
+	}
+
+	return &HintStore{
+		hints:     make(map[string]string),
+		precision: precision,
+	}
+}
+
+// CoordinateKey returns the key of a coordinate rounded to given decimal places.
+func CoordinateKey(c Coordinate, precision uint8) string {
+	p := math.Pow(10, float64(precision))
+	lng := math.Round(c[0]*p) / p
+	lat := math.Round(c[1]*p) / p
+
+	return strconv.FormatFloat(lng, 'f', int(precision), 64) + "," + strconv.FormatFloat(lat, 'f', int(precision), 64)
+}
+
+// Get returns the hint of the given key.
+func (s *HintStore) Get(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hint, ok := s.hints[key]
+	return hint, ok
+}
+
+// Set sets the hint of the given key.
+func (s *HintStore) Set(key, hint string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hints[key] = hint
+}
+
+// Len returns the number of stored hints.
+func (s *HintStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.hints)
+}
+
+// DataVersion returns the data version of the stored hints.
+func (s *HintStore) DataVersion() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.dataVersion
+}
+
+// Flush removes all of the stored hints.
+func (s *HintStore) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hints = make(map[string]string)
+}
+
+// Keys returns the hint keys of the request coordinates.
+// Request.IDs are used if they are set, otherwise the rounded coordinates.
+func (s *HintStore) Keys(req Request) []string {
+	if len(req.IDs) == len(req.Coordinates) {
+		return req.IDs
+	}
+
+	keys := make([]string, len(req.Coordinates))
+	for i, c := range req.Coordinates {
+		keys[i] = CoordinateKey(c, s.precision)
+	}
+
+	return keys
+}
+
+// Hints returns the stored hints of given keys.
+// Unknown keys have an empty hint. ok is false if none of the keys are known.
+func (s *HintStore) Hints(keys []string) (hints []string, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hints = make([]string, len(keys))
+	for i, key := range keys {
+		if hint, found := s.hints[key]; found {
+			hints[i] = hint
+			ok = true
+		}
+	}
+
+	return hints, ok
+}
+
+// Update stores the hints of waypoints under given keys, keys[i] belongs to waypoints[i].
+// Empty keys and waypoints without a hint are ignored.
+// If dataVersion differs from the one of the stored hints, the store is flushed first.
+// A zero dataVersion, of OSRM servers without a data version, doesn't flush the store.
+func (s *HintStore) Update(keys []string, waypoints []Waypoint, dataVersion time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !dataVersion.IsZero() && !dataVersion.Equal(s.dataVersion) {
+		s.hints = make(map[string]string)
+		s.dataVersion = dataVersion
+	}
+
+	for i := 0; i < len(keys) && i < len(waypoints); i++ {
+		if keys[i] != "" && waypoints[i].Hint != "" {
+			s.hints[keys[i]] = waypoints[i].Hint
+		}
+	}
+}
+
+// inject adds the stored hints of the request as hints option to the URL.
+func (s *HintStore) inject(u *url.URL, req Request) {
+	if hints, ok := s.Hints(s.Keys(req)); ok {
+		WithHints(hints).apply(u)
+	}
+}
+
+// selectKeys returns the keys of the indices in the given query param.
+// All of the keys are returned if the param is missing or it's "all".
+func selectKeys(u *url.URL, param string, keys []string) []string {
+	v := u.Query().Get(param)
+	if v == "" || v == "all" {
+		return keys
+	}
+
+	parts := strings.Split(v, ";")
+	selected := make([]string, len(parts))
+
+	for i, part := range parts {
+		idx, err := strconv.Atoi(part)
+		if err != nil || idx < 0 || idx >= len(keys) {
+			// Unknown index, an empty key is ignored by Update.
+			continue
+		}
+		selected[i] = keys[idx]
+	}
+
+	return selected
+}
@@ -0,0 +1,153 @@
+package gosrm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoordinateKey(t *testing.T) {
+	assert.Equal(t, "13.38886,52.51704", CoordinateKey(Coordinate{13.388860, 52.517037}, 5))
+	assert.Equal(t, "13.4,52.5", CoordinateKey(Coordinate{13.388860, 52.517037}, 1))
+}
+
+func TestHintStore(t *testing.T) {
+	store := NewHintStore(0)
+	assert.Equal(t, defaultHintPrecision, store.precision)
+
+	req := Request{Coordinates: []Coordinate{{13.388860, 52.517037}, {13.397634, 52.529407}}}
+	keys := store.Keys(req)
+	assert.Equal(t, []string{"13.38886,52.51704", "13.39763,52.52941"}, keys)
+
+	req.IDs = []string{"depot", "customer"}
+	keys = store.Keys(req)
+	assert.Equal(t, []string{"depot", "customer"}, keys)
+
+	hints, ok := store.Hints(keys)
+	assert.False(t, ok)
+	assert.Equal(t, []string{"", ""}, hints)
+
+	v1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.Update(keys, []Waypoint{{Hint: "a"}, {Hint: ""}}, v1)
+	assert.Equal(t, 1, store.Len())
+	assert.Equal(t, v1, store.DataVersion())
+
+	hints, ok = store.Hints(keys)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", ""}, hints)
+
+	store.Update([]string{"", "customer"}, []Waypoint{{Hint: "x"}, {Hint: "b"}}, v1)
+	hint, ok := store.Get("customer")
+	assert.True(t, ok)
+	assert.Equal(t, "b", hint)
+	assert.Equal(t, 2, store.Len())
+
+	// Data version changed so previous hints are stale.
+	v2 := v1.Add(time.Hour)
+	store.Update([]string{"depot"}, []Waypoint{{Hint: "c"}}, v2)
+	assert.Equal(t, 1, store.Len())
+	hint, _ = store.Get("depot")
+	assert.Equal(t, "c", hint)
+
+	// Hints without a data version don't flush the store.
+	store.Update([]string{"customer"}, []Waypoint{{Hint: "e"}}, time.Time{})
+	assert.Equal(t, 2, store.Len())
+	assert.Equal(t, v2, store.DataVersion())
+
+	store.Set("customer", "d")
+	assert.Equal(t, 2, store.Len())
+	hint, _ = store.Get("customer")
+	assert.Equal(t, "d", hint)
+
+	store.Flush()
+	assert.Equal(t, 0, store.Len())
+
+	// Hints are stored under a zero data version until the data version is known.
+	store = NewHintStore(0)
+	store.Update([]string{"depot"}, []Waypoint{{Hint: "a"}}, time.Time{})
+	assert.Equal(t, 1, store.Len())
+	assert.True(t, store.DataVersion().IsZero())
+
+	store.Update([]string{"customer"}, []Waypoint{{Hint: "b"}}, v1)
+	assert.Equal(t, 1, store.Len())
+	assert.Equal(t, v1, store.DataVersion())
+}
+
+func TestSelectKeys(t *testing.T) {
+	keys := []string{"a", "b", "c"}
+
+	u := &url.URL{}
+	assert.Equal(t, keys, selectKeys(u, "sources", keys))
+
+	WithSources(nil).apply(u)
+	assert.Equal(t, keys, selectKeys(u, "sources", keys))
+
+	WithSources([]uint16{2, 0, 5}).apply(u)
+	assert.Equal(t, []string{"c", "a", ""}, selectKeys(u, "sources", keys))
+}
+
+func TestOSRMClient_SetHintStore(t *testing.T) {
+	var queries []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Write([]byte(`{"code":"Ok","data_version":"2023-01-01T00:00:00Z","sources":[{"hint":"h1"}],"destinations":[{"hint":"h1"},{"hint":"h2"}]}`))
+	}))
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	store := NewHintStore(0)
+	osrm.SetHintStore(store)
+
+	req := Request{
+		Profile:     ProfileCar,
+		Coordinates: []Coordinate{{13.388860, 52.517037}, {13.397634, 52.529407}},
+		IDs:         []string{"depot", "customer"},
+	}
+
+	_, err = Table(context.Background(), osrm, req, WithSources([]uint16{0}))
+	assert.NoError(t, err)
+	assert.Equal(t, "", queries[0].Get("hints"))
+	assert.Equal(t, 2, store.Len())
+
+	_, err = Table(context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "h1;h2", queries[1].Get("hints"))
+
+	// Explicit hints take precedence.
+	_, err = Table(context.Background(), osrm, req, WithHints([]string{"x", "y"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "x;y", queries[2].Get("hints"))
+
+	osrm.SetHintStore(nil)
+	_, err = Table(context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "", queries[3].Get("hints"))
+}
+
+func TestOSRMClient_SetHintStore_NotOk(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":"NoRoute","data_version":"2023-01-01T00:00:00Z","waypoints":[{"hint":"h1"},{"hint":"h2"}]}`))
+	}))
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	store := NewHintStore(0)
+	osrm.SetHintStore(store)
+
+	req := Request{Profile: ProfileCar, Coordinates: []Coordinate{{1, 2}, {3, 4}}}
+
+	res, err := Route[string](context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.False(t, res.IsOk())
+	assert.Equal(t, 0, store.Len())
+	assert.True(t, store.DataVersion().IsZero())
+}
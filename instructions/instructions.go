@@ -0,0 +1,404 @@
+// Package instructions compiles human readable turn-by-turn instructions from OSRM route steps.
+// It's modeled on osrm-text-instructions and uses the same structure for language files.
+// English (en) and German (de) are built in, more languages can be loaded from JSON.
+// The built-in languages are derived from osrm-text-instructions, see languages/LICENSE.
+package instructions
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mojixcoder/gosrm"
+)
+
+// ErrUnknownLanguage is returned when a language is not loaded.
+var ErrUnknownLanguage = errors.New("instructions: unknown language")
+
+// ErrMissingInstruction is returned when a language has no instruction for a maneuver.
+var ErrMissingInstruction = errors.New("instructions: missing instruction")
+
+//go:embed languages/*.json
+var builtinLanguages embed.FS
+
+type (
+	// Compiler compiles instructions of route steps in the loaded languages.
+	// It's safe for concurrent use.
+	Compiler struct {
+		mu        sync.RWMutex
+		languages map[string]*Language
+	}
+
+	// Options are the options used to compile an instruction.
+	Options struct {
+		// LegIndex is the index of the leg the step belongs to.
+		// It's used to tell the number of the reached waypoint in arrive instructions.
+		LegIndex int
+
+		// LegCount is the number of legs of the route.
+		// If it's 0 the waypoint number is omitted in arrive instructions.
+		LegCount int
+
+		// WaypointName is the name of the waypoint that is reached in arrive instructions.
+		WaypointName string
+	}
+)
+
+// NewCompiler returns a new compiler with the built in languages loaded.
+func NewCompiler() *Compiler {
+	c := Compiler{languages: make(map[string]*Language)}
+
+	entries, err := builtinLanguages.ReadDir("languages")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, entry := range entries {
+		data, err := builtinLanguages.ReadFile("languages/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+
+		lang, err := ParseLanguage(data)
+		if err != nil {
+			panic(err)
+		}
+
+		c.languages[strings.TrimSuffix(entry.Name(), ".json")] = lang
+	}
+
+	return &c
+}
+
+// Load loads a language from its JSON representation, an existing language with the same code is replaced.
+func (c *Compiler) Load(code string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	lang, err := ParseLanguage(data)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.languages[code] = lang
+
+	return nil
+}
+
+// Languages returns the sorted codes of the loaded languages.
+func (c *Compiler) Languages() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	codes := make([]string, 0, len(c.languages))
+	for code := range c.languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	return codes
+}
+
+// language returns the language of the given code.
+func (c *Compiler) language(code string) (*Language, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	lang, ok := c.languages[code]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownLanguage, code)
+	}
+
+	return lang, nil
+}
+
+// Compile compiles the instruction of a route step in the given language.
+func Compile[T gosrm.GeometryType](c *Compiler, code string, step gosrm.RouteStep[T], opts Options) (string, error) {
+	lang, err := c.language(code)
+	if err != nil {
+		return "", err
+	}
+
+	return lang.compile(newStep(step), opts)
+}
+
+// CompileRoute compiles the instructions of all steps of a route in the given language.
+// instructions[i][j] is the instruction of the j-th step of the i-th leg.
+func CompileRoute[T gosrm.GeometryType](c *Compiler, code string, route gosrm.RouteType[T]) ([][]string, error) {
+	lang, err := c.language(code)
+	if err != nil {
+		return nil, err
+	}
+
+	instructions := make([][]string, len(route.Legs))
+	for i, leg := range route.Legs {
+		instructions[i] = make([]string, len(leg.Steps))
+
+		for j, step := range leg.Steps {
+			opts := Options{LegIndex: i, LegCount: len(route.Legs)}
+			if instructions[i][j], err = lang.compile(newStep(step), opts); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return instructions, nil
+}
+
+// step holds the fields of a route step which are used in instructions.
+type step struct {
+	maneuver     gosrm.StepManeuver
+	name         string
+	ref          string
+	destinations string
+	exits        string
+	rotaryName   string
+	mode         string
+	drivingSide  string
+	lanes        []gosrm.Lane
+}
+
+// newStep returns the step of a route step.
+func newStep[T gosrm.GeometryType](s gosrm.RouteStep[T]) step {
+	st := step{
+		maneuver:     s.Maneuver,
+		name:         s.Name,
+		ref:          s.Ref,
+		destinations: s.Destinations,
+		exits:        s.Exits,
+		rotaryName:   s.RotaryName,
+		mode:         s.Mode,
+		drivingSide:  s.DrivingSide,
+	}
+
+	if len(s.Intersections) > 0 {
+		st.lanes = s.Intersections[0].Lanes
+	}
+
+	return st
+}
+
+// compile compiles the instruction of a step.
+func (lang *Language) compile(s step, opts Options) (string, error) {
+	typ, modifier := s.maneuver.Type, s.maneuver.Modifier
+	if typ == "" {
+		return "", fmt.Errorf("%w: step has no maneuver type", ErrMissingInstruction)
+	}
+
+	// Unknown maneuver types are treated as turns.
+	maneuver, ok := lang.maneuvers[typ]
+	if !ok {
+		typ, maneuver = "turn", lang.maneuvers["turn"]
+	}
+
+	// Use mode specific instructions if they are available, otherwise modifier specific ones.
+	var v variants
+	if p, ok := lang.modes[s.mode]; ok {
+		v = variants{"": p}
+	} else {
+		omitSide := typ == "off ramp" && s.drivingSide != "" && strings.Contains(modifier, s.drivingSide)
+		if mv, ok := maneuver[modifier]; ok && !omitSide {
+			v = mv
+		} else {
+			v = maneuver["default"]
+		}
+	}
+
+	var laneInstruction string
+	if typ == "use lane" {
+		laneInstruction = lang.lanes[laneConfig(s.lanes)]
+		if laneInstruction == "" {
+			v = maneuver["no_lanes"]
+		}
+	}
+
+	p := v[""]
+	if typ == "rotary" || typ == "roundabout" {
+		switch {
+		case s.rotaryName != "" && s.maneuver.Exit > 0 && v["name_exit"] != nil:
+			p = v["name_exit"]
+		case s.rotaryName != "" && v["name"] != nil:
+			p = v["name"]
+		case s.maneuver.Exit > 0 && v["exit"] != nil:
+			p = v["exit"]
+		default:
+			p = v["default"]
+		}
+	}
+
+	wayName := s.wayName()
+
+	var instruction string
+	switch {
+	case s.destinations != "" && s.exits != "" && p["exit_destination"] != "":
+		instruction = p["exit_destination"]
+	case s.destinations != "" && p["destination"] != "":
+		instruction = p["destination"]
+	case s.exits != "" && p["exit"] != "":
+		instruction = p["exit"]
+	case wayName != "" && p["name"] != "":
+		instruction = p["name"]
+	case opts.WaypointName != "" && p["named"] != "":
+		instruction = p["named"]
+	default:
+		instruction = p["default"]
+	}
+
+	if instruction == "" {
+		return "", fmt.Errorf("%w: %s %s", ErrMissingInstruction, typ, modifier)
+	}
+
+	var nth string
+	if opts.LegCount > 0 && opts.LegIndex != opts.LegCount-1 {
+		nth = lang.ordinal(opts.LegIndex + 1)
+	}
+
+	exitNumber := int(s.maneuver.Exit)
+	if exitNumber == 0 {
+		exitNumber = 1
+	}
+
+	instruction = replaceTokens(instruction, map[string]string{
+		"way_name":         wayName,
+		"destination":      s.firstDestination(),
+		"exit":             strings.Split(s.exits, ";")[0],
+		"exit_number":      lang.ordinal(exitNumber),
+		"rotary_name":      s.rotaryName,
+		"lane_instruction": laneInstruction,
+		"modifier":         lang.modifier[modifier],
+		"direction":        lang.direction[directionFromDegree(s.maneuver.BearingAfter)],
+		"nth":              nth,
+		"waypoint_name":    opts.WaypointName,
+	})
+
+	if lang.capitalize {
+		instruction = capitalizeFirstLetter(instruction)
+	}
+
+	return instruction, nil
+}
+
+// ordinal returns the ordinal of a number, e.g. 1st, numbers without an ordinal are returned as is.
+func (lang *Language) ordinal(n int) string {
+	key := strconv.Itoa(n)
+	if o, ok := lang.ordinalize[key]; ok {
+		return o
+	}
+	return key
+}
+
+// wayName returns the name of the way with its reference if both are available, e.g. Hardenbergstraße (B 2).
+func (s step) wayName() string {
+	ref := strings.Split(s.ref, ";")[0]
+
+	switch {
+	case s.name != "" && ref != "" && s.name != ref:
+		return s.name + " (" + ref + ")"
+	case s.name == "" && ref != "":
+		return ref
+	default:
+		return s.name
+	}
+}
+
+// firstDestination returns the first destination of the step, prefixed by its reference if it's available.
+// Destinations are in {refs}: {names} format where both refs and names are separated by commas.
+func (s step) firstDestination() string {
+	if s.destinations == "" {
+		return ""
+	}
+
+	parts := strings.SplitN(s.destinations, ": ", 2)
+	ref := strings.Split(parts[0], ",")[0]
+
+	var dest string
+	if len(parts) > 1 {
+		dest = strings.Split(parts[1], ",")[0]
+	}
+
+	switch {
+	case dest != "" && ref != "":
+		return ref + ": " + dest
+	case ref != "":
+		return ref
+	default:
+		return dest
+	}
+}
+
+// laneConfig returns the lane configuration of lanes, valid lanes are o and invalid ones are x.
+// Consecutive lanes with the same validity are collapsed, e.g. xxoo becomes xo.
+func laneConfig(lanes []gosrm.Lane) string {
+	var b strings.Builder
+
+	var prev byte
+	for _, lane := range lanes {
+		c := byte('x')
+		if lane.Valid {
+			c = 'o'
+		}
+
+		if c != prev {
+			b.WriteByte(c)
+			prev = c
+		}
+	}
+
+	return b.String()
+}
+
+// directionFromDegree returns the cardinal direction of a bearing.
+func directionFromDegree(degree float32) string {
+	switch {
+	case degree < 0 || degree > 360:
+		return ""
+	case degree <= 20 || degree > 340:
+		return "north"
+	case degree <= 70:
+		return "northeast"
+	case degree <= 110:
+		return "east"
+	case degree <= 160:
+		return "southeast"
+	case degree <= 200:
+		return "south"
+	case degree <= 250:
+		return "southwest"
+	case degree <= 290:
+		return "west"
+	default:
+		return "northwest"
+	}
+}
+
+// replaceTokens replaces {token}s of the instruction and removes the extra spaces of empty tokens.
+func replaceTokens(instruction string, tokens map[string]string) string {
+	for token, value := range tokens {
+		instruction = strings.ReplaceAll(instruction, "{"+token+"}", value)
+	}
+
+	instruction = strings.Join(strings.Fields(instruction), " ")
+
+	return strings.ReplaceAll(instruction, " ,", ",")
+}
+
+// capitalizeFirstLetter capitalizes the first letter of s.
+func capitalizeFirstLetter(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
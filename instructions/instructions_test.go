@@ -0,0 +1,234 @@
+package instructions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mojixcoder/gosrm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCompiler(t *testing.T) {
+	c := NewCompiler()
+	assert.Equal(t, []string{"de", "en"}, c.Languages())
+}
+
+func TestCompile(t *testing.T) {
+	c := NewCompiler()
+
+	testCases := []struct {
+		name     string
+		lang     string
+		step     gosrm.RouteStep[string]
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "depart",
+			lang:     "en",
+			step:     gosrm.RouteStep[string]{Name: "Unter den Linden", Maneuver: gosrm.StepManeuver{Type: "depart", BearingAfter: 95}},
+			expected: "Head east on Unter den Linden",
+		},
+		{
+			name:     "turn_with_ref",
+			lang:     "en",
+			step:     gosrm.RouteStep[string]{Name: "Hardenbergstraße", Ref: "B 2;B 5", Maneuver: gosrm.StepManeuver{Type: "turn", Modifier: "left"}},
+			expected: "Turn left onto Hardenbergstraße (B 2)",
+		},
+		{
+			name:     "turn_default_modifier",
+			lang:     "en",
+			step:     gosrm.RouteStep[string]{Maneuver: gosrm.StepManeuver{Type: "turn", Modifier: "sharp right"}},
+			expected: "Make a sharp right",
+		},
+		{
+			name:     "unknown_type",
+			lang:     "en",
+			step:     gosrm.RouteStep[string]{Maneuver: gosrm.StepManeuver{Type: "unknown", Modifier: "uturn"}},
+			expected: "Make a U-turn",
+		},
+		{
+			name: "rotary_name_exit",
+			lang: "en",
+			step: gosrm.RouteStep[string]{
+				Name:       "Hardenbergstraße",
+				RotaryName: "Ernst-Reuter-Platz",
+				Maneuver:   gosrm.StepManeuver{Type: "rotary", Modifier: "right", Exit: 2},
+			},
+			expected: "Enter Ernst-Reuter-Platz and take the 2nd exit onto Hardenbergstraße",
+		},
+		{
+			name:     "roundabout_exit",
+			lang:     "en",
+			step:     gosrm.RouteStep[string]{Maneuver: gosrm.StepManeuver{Type: "roundabout", Exit: 3}},
+			expected: "Enter the traffic circle and take the 3rd exit",
+		},
+		{
+			name:     "off_ramp_exit_destination",
+			lang:     "en",
+			step:     gosrm.RouteStep[string]{Exits: "12;13", Destinations: "A 100, A 113: Dresden, Schönefeld", Maneuver: gosrm.StepManeuver{Type: "off ramp", Modifier: "slight right"}},
+			expected: "Take exit 12 on the right towards A 100: Dresden",
+		},
+		{
+			name:     "off_ramp_omit_driving_side",
+			lang:     "en",
+			step:     gosrm.RouteStep[string]{DrivingSide: "right", Maneuver: gosrm.StepManeuver{Type: "off ramp", Modifier: "slight right"}},
+			expected: "Take the ramp",
+		},
+		{
+			name: "use_lane",
+			lang: "en",
+			step: gosrm.RouteStep[string]{
+				Maneuver:      gosrm.StepManeuver{Type: "use lane", Modifier: "straight"},
+				Intersections: []gosrm.Intersection{{Lanes: []gosrm.Lane{{Valid: false}, {Valid: true}, {Valid: true}}}},
+			},
+			expected: "Keep right",
+		},
+		{
+			name:     "use_lane_no_lanes",
+			lang:     "en",
+			step:     gosrm.RouteStep[string]{Maneuver: gosrm.StepManeuver{Type: "use lane", Modifier: "straight"}},
+			expected: "Continue straight",
+		},
+		{
+			name:     "ferry",
+			lang:     "en",
+			step:     gosrm.RouteStep[string]{Mode: "ferry", Maneuver: gosrm.StepManeuver{Type: "turn", Modifier: "left"}},
+			expected: "Take the ferry",
+		},
+		{
+			name:     "arrive_nth",
+			lang:     "en",
+			step:     gosrm.RouteStep[string]{Maneuver: gosrm.StepManeuver{Type: "arrive", Modifier: "left"}},
+			opts:     Options{LegIndex: 0, LegCount: 2},
+			expected: "You have arrived at your 1st destination, on the left",
+		},
+		{
+			name:     "arrive_last",
+			lang:     "en",
+			step:     gosrm.RouteStep[string]{Maneuver: gosrm.StepManeuver{Type: "arrive"}},
+			opts:     Options{LegIndex: 1, LegCount: 2},
+			expected: "You have arrived at your destination",
+		},
+		{
+			name:     "arrive_named",
+			lang:     "en",
+			step:     gosrm.RouteStep[string]{Maneuver: gosrm.StepManeuver{Type: "arrive"}},
+			opts:     Options{WaypointName: "the depot"},
+			expected: "You have arrived at the depot",
+		},
+		{
+			name:     "de_turn",
+			lang:     "de",
+			step:     gosrm.RouteStep[string]{Name: "Hardenbergstraße", Maneuver: gosrm.StepManeuver{Type: "end of road", Modifier: "slight left"}},
+			expected: "Leicht links abbiegen auf Hardenbergstraße",
+		},
+		{
+			name: "de_rotary",
+			lang: "de",
+			step: gosrm.RouteStep[string]{
+				RotaryName: "Ernst-Reuter-Platz",
+				Maneuver:   gosrm.StepManeuver{Type: "rotary", Exit: 2},
+			},
+			expected: "In Ernst-Reuter-Platz die 2. Ausfahrt nehmen",
+		},
+		{
+			name:     "de_depart",
+			lang:     "de",
+			step:     gosrm.RouteStep[string]{Maneuver: gosrm.StepManeuver{Type: "depart", BearingAfter: 180}},
+			expected: "Fahren Sie Richtung Süden",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			instruction, err := Compile(c, testCase.lang, testCase.step, testCase.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, instruction)
+		})
+	}
+
+	_, err := Compile(c, "fr", gosrm.RouteStep[string]{}, Options{})
+	assert.ErrorIs(t, err, ErrUnknownLanguage)
+
+	_, err = Compile(c, "en", gosrm.RouteStep[string]{}, Options{})
+	assert.ErrorIs(t, err, ErrMissingInstruction)
+}
+
+func TestCompileRoute(t *testing.T) {
+	c := NewCompiler()
+
+	route := gosrm.RouteType[gosrm.LineString]{Legs: []gosrm.RouteLeg[gosrm.LineString]{
+		{Steps: []gosrm.RouteStep[gosrm.LineString]{
+			{Maneuver: gosrm.StepManeuver{Type: "depart", BearingAfter: 0}},
+			{Maneuver: gosrm.StepManeuver{Type: "arrive"}},
+		}},
+		{Steps: []gosrm.RouteStep[gosrm.LineString]{
+			{Maneuver: gosrm.StepManeuver{Type: "arrive"}},
+		}},
+	}}
+
+	instructions, err := CompileRoute(c, "en", route)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Head north", "You have arrived at your 1st destination"},
+		{"You have arrived at your destination"},
+	}, instructions)
+
+	_, err = CompileRoute(c, "fr", route)
+	assert.ErrorIs(t, err, ErrUnknownLanguage)
+
+	route.Legs[1].Steps[0].Maneuver.Type = ""
+	_, err = CompileRoute(c, "en", route)
+	assert.ErrorIs(t, err, ErrMissingInstruction)
+}
+
+func TestCompiler_Load(t *testing.T) {
+	c := NewCompiler()
+
+	lang := `{
+		"meta": {"capitalizeFirstLetter": false},
+		"v5": {
+			"constants": {"ordinalize": {"1": "primera"}, "modifier": {"left": "izquierda"}},
+			"phrase": {"two linked": "{instruction_one}, {instruction_two}"},
+			"turn": {"default": {"default": "gire a {modifier}", "name": "gire a {modifier} en {way_name}"}}
+		}
+	}`
+
+	assert.NoError(t, c.Load("es", strings.NewReader(lang)))
+	assert.Equal(t, []string{"de", "en", "es"}, c.Languages())
+
+	instruction, err := Compile(c, "es", gosrm.RouteStep[string]{Name: "Calle Mayor", Maneuver: gosrm.StepManeuver{Type: "turn", Modifier: "left"}}, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, "gire a izquierda en Calle Mayor", instruction)
+
+	err = c.Load("xx", strings.NewReader(`{"v5": {"turn": {"default": {}}}}`))
+	assert.ErrorIs(t, err, ErrInvalidLanguage)
+
+	err = c.Load("xx", strings.NewReader(`{"v5": {"turn": []}}`))
+	assert.ErrorIs(t, err, ErrInvalidLanguage)
+
+	err = c.Load("xx", strings.NewReader(`{"v5": {"turn": {"default": {"exit": 1}}}}`))
+	assert.ErrorIs(t, err, ErrInvalidLanguage)
+
+	err = c.Load("xx", strings.NewReader(`invalid`))
+	assert.ErrorIs(t, err, ErrInvalidLanguage)
+	assert.Equal(t, []string{"de", "en", "es"}, c.Languages())
+}
+
+func TestLaneConfig(t *testing.T) {
+	assert.Equal(t, "", laneConfig(nil))
+	assert.Equal(t, "xox", laneConfig([]gosrm.Lane{{}, {}, {Valid: true}, {}}))
+	assert.Equal(t, "o", laneConfig([]gosrm.Lane{{Valid: true}, {Valid: true}}))
+}
+
+func TestDirectionFromDegree(t *testing.T) {
+	assert.Equal(t, "north", directionFromDegree(0))
+	assert.Equal(t, "north", directionFromDegree(350))
+	assert.Equal(t, "northeast", directionFromDegree(45))
+	assert.Equal(t, "southeast", directionFromDegree(120))
+	assert.Equal(t, "southwest", directionFromDegree(230))
+	assert.Equal(t, "west", directionFromDegree(270))
+	assert.Equal(t, "northwest", directionFromDegree(300))
+	assert.Equal(t, "", directionFromDegree(-1))
+}
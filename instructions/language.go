@@ -0,0 +1,116 @@
+package instructions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidLanguage is returned when a language file is malformed.
+var ErrInvalidLanguage = errors.New("instructions: invalid language")
+
+type (
+	// Language holds the instruction phrases of a language.
+	// The structure of language files is the same as the v5 translations of osrm-text-instructions.
+	Language struct {
+		capitalize bool
+
+		ordinalize map[string]string
+		direction  map[string]string
+		modifier   map[string]string
+		lanes      map[string]string
+
+		modes     map[string]phrases
+		maneuvers map[string]map[string]variants
+	}
+
+	// phrases maps the kind of an instruction (default, name, destination, exit, ...) to its phrase.
+	phrases map[string]string
+
+	// variants maps the variants of rotaries and roundabouts (default, name, exit, name_exit) to their phrases.
+	// Other maneuvers only have the "" variant.
+	variants map[string]phrases
+
+	// languageFile is the structure of a language file.
+	languageFile struct {
+		Meta struct {
+			CapitalizeFirstLetter bool `json:"capitalizeFirstLetter"`
+		} `json:"meta"`
+
+		V5 map[string]json.RawMessage `json:"v5"`
+	}
+
+	// constants is the structure of the v5 constants of a language file.
+	constants struct {
+		Ordinalize map[string]string `json:"ordinalize"`
+		Direction  map[string]string `json:"direction"`
+		Modifier   map[string]string `json:"modifier"`
+		Lanes      map[string]string `json:"lanes"`
+	}
+)
+
+// ParseLanguage parses a language from its JSON representation.
+func ParseLanguage(data []byte) (*Language, error) {
+	var file languageFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidLanguage, err)
+	}
+
+	lang := Language{
+		capitalize: file.Meta.CapitalizeFirstLetter,
+		modes:      make(map[string]phrases),
+		maneuvers:  make(map[string]map[string]variants),
+	}
+
+	for key, raw := range file.V5 {
+		var err error
+
+		switch key {
+		case "constants":
+			var c constants
+			err = json.Unmarshal(raw, &c)
+			lang.ordinalize, lang.direction, lang.modifier, lang.lanes = c.Ordinalize, c.Direction, c.Modifier, c.Lanes
+		case "modes":
+			err = json.Unmarshal(raw, &lang.modes)
+		case "phrase":
+			// Phrases are used to combine instructions, they're not supported.
+		default:
+			lang.maneuvers[key], err = parseManeuver(raw)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidLanguage, key, err)
+		}
+	}
+
+	if lang.maneuvers["turn"]["default"][""]["default"] == "" {
+		return nil, fmt.Errorf("%w: missing default turn instruction", ErrInvalidLanguage)
+	}
+
+	return &lang, nil
+}
+
+// parseManeuver parses the instructions of a maneuver type.
+func parseManeuver(raw json.RawMessage) (map[string]variants, error) {
+	var modifiers map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &modifiers); err != nil {
+		return nil, err
+	}
+
+	maneuver := make(map[string]variants, len(modifiers))
+	for modifier, raw := range modifiers {
+		var p phrases
+		if err := json.Unmarshal(raw, &p); err == nil {
+			maneuver[modifier] = variants{"": p}
+			continue
+		}
+
+		var v variants
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		maneuver[modifier] = v
+	}
+
+	return maneuver, nil
+}
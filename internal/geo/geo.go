@@ -0,0 +1,25 @@
+// Package geo has the coordinate helpers shared by gosrm and its subpackages.
+// Coordinates are [longitude, latitude] in degrees, like gosrm.Coordinate.
+package geo
+
+import "math"
+
+// EarthRadius is the mean radius of the earth, in meters.
+const EarthRadius float64 = 6371008.8
+
+// MetersPerDegree is the length of a degree of latitude, in meters.
+const MetersPerDegree float64 = EarthRadius * math.Pi / 180
+
+// Offset returns the coordinate which is dx meters east and dy meters north of c.
+// It's an equirectangular approximation, accurate for offsets of a few kilometers.
+func Offset(c [2]float64, dx, dy float64) [2]float64 {
+	return [2]float64{
+		c[0] + dx/(MetersPerDegree*math.Cos(c[1]*math.Pi/180)),
+		c[1] + dy/MetersPerDegree,
+	}
+}
+
+// Interpolate returns the coordinate at fraction t of the segment from a to b.
+func Interpolate(a, b [2]float64, t float64) [2]float64 {
+	return [2]float64{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1])}
+}
@@ -0,0 +1,27 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffset(t *testing.T) {
+	origin := [2]float64{13.4, 52.5}
+
+	c := Offset(origin, 0, MetersPerDegree)
+	assert.Equal(t, origin[0], c[0])
+	assert.InDelta(t, origin[1]+1, c[1], 1e-9)
+
+	c = Offset(origin, -1000, 0)
+	assert.Equal(t, origin[1], c[1])
+	assert.Less(t, c[0], origin[0])
+}
+
+func TestInterpolate(t *testing.T) {
+	a, b := [2]float64{0, 0}, [2]float64{2, 4}
+
+	assert.Equal(t, a, Interpolate(a, b, 0))
+	assert.Equal(t, b, Interpolate(a, b, 1))
+	assert.Equal(t, [2]float64{0.5, 1}, Interpolate(a, b, 0.25))
+}
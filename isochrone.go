@@ -0,0 +1,323 @@
+package gosrm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidIsochrone is returned when an isochrone request is not valid.
+var ErrInvalidIsochrone = errors.New("gosrm: invalid isochrone request")
+
+// DefaultIsochroneResolution is the default number of grid cells per side of an isochrone.
+const DefaultIsochroneResolution int = 32
+
+// IsochroneMetric is the metric which thresholds of an isochrone are compared to.
+type IsochroneMetric string
+
+const (
+	// IsochroneDuration is travel duration, thresholds are in seconds.
+	IsochroneDuration IsochroneMetric = "duration"
+
+	// IsochroneDistance is travel distance, thresholds are in meters.
+	IsochroneDistance IsochroneMetric = "distance"
+)
+
+// IsochroneRequest is the request of an isochrone.
+type IsochroneRequest struct {
+	// Profile is used to compute travel durations and distances.
+	Profile Profile
+
+	// Origin is the coordinate travels start from.
+	Origin Coordinate
+
+	// Metric of the thresholds, defaults to IsochroneDuration.
+	Metric IsochroneMetric
+
+	// Thresholds are the max values of the metric, a polygon is built for each of them.
+	Thresholds []float64
+
+	// Radius is the half size of the square around the origin that is sampled, in meters.
+	// It has to be big enough to contain the biggest polygon.
+	Radius float64
+
+	// Resolution is the number of grid cells per side of the sampled square, defaults to DefaultIsochroneResolution.
+	// The grid has (Resolution+1)^2 points, so higher resolutions are more accurate but need more table requests.
+	Resolution int
+
+	// ChunkSize is the max number of destinations per table request, see TableChunked.
+	ChunkSize int
+}
+
+// Isochrone returns the areas reachable from the origin within each of the thresholds.
+// OSRM has no isochrone service, so a grid of points around the origin is evaluated by table service
+// and the contours of each threshold are built using marching squares.
+// Grid points that snap farther than a grid cell away are treated as unreachable.
+// The result has a multi polygon feature for each threshold in the same order, with kind, metric and threshold properties.
+// Options are passed to table service.
+func Isochrone(ctx context.Context, osrm OSRMClient, req IsochroneRequest, opts ...Option) (FeatureCollection, error) {
+	if req.Metric == "" {
+		req.Metric = IsochroneDuration
+	}
+	if req.Resolution == 0 {
+		req.Resolution = DefaultIsochroneResolution
+	}
+
+	if req.Metric != IsochroneDuration && req.Metric != IsochroneDistance {
+		return FeatureCollection{}, fmt.Errorf("%w: unknown metric %q", ErrInvalidIsochrone, req.Metric)
+	}
+	if len(req.Thresholds) == 0 {
+		return FeatureCollection{}, fmt.Errorf("%w: no thresholds", ErrInvalidIsochrone)
+	}
+	if req.Radius <= 0 {
+		return FeatureCollection{}, fmt.Errorf("%w: radius must be positive", ErrInvalidIsochrone)
+	}
+	if req.Resolution < 2 {
+		return FeatureCollection{}, fmt.Errorf("%w: resolution must be at least 2", ErrInvalidIsochrone)
+	}
+
+	g := newIsochroneGrid(req.Origin, req.Radius, req.Resolution)
+
+	table := Request{Profile: req.Profile, Coordinates: append([]Coordinate{req.Origin}, g.points()...)}
+	tableOpts := append([]Option{WithAnnotations(Annotations(req.Metric))}, opts...)
+
+	res, err := TableChunked(ctx, osrm, table, []int{0}, allIndices(len(table.Coordinates))[1:], req.ChunkSize, tableOpts...)
+	if err != nil {
+		return FeatureCollection{}, err
+	}
+
+	matrix := res.Durations
+	if req.Metric == IsochroneDistance {
+		matrix = res.Distances
+	}
+	if len(matrix) == 0 {
+		return FeatureCollection{}, fmt.Errorf("%w: table response has no %ss", ErrInvalidIsochrone, req.Metric)
+	}
+
+	for k, v := range matrix[0] {
+		value := float64(v)
+		if len(res.Destinations) > k && float64(res.Destinations[k].Distance) > g.cell {
+			value = math.Inf(1)
+		}
+		g.set(k, value)
+	}
+
+	fc := NewFeatureCollection()
+	for _, threshold := range req.Thresholds {
+		fc.Features = append(fc.Features, NewMultiPolygonFeature(g.contours(threshold), map[string]any{
+			"kind":      "isochrone",
+			"metric":    string(req.Metric),
+			"threshold": threshold,
+		}))
+	}
+
+	return fc, nil
+}
+
+// isochroneGrid is a square grid of values around an origin.
+// It's padded by a border of NaN values so contours are always closed.
+type isochroneGrid struct {
+	origin Coordinate
+	radius float64
+	n      int
+
+	// cell is the size of grid cells, in meters.
+	cell float64
+
+	// values are indexed by padded grid indices, values[j][i] is the value of the i-th column and j-th row from the south-west corner.
+	values [][]float64
+}
+
+// newIsochroneGrid returns a new grid of n by n cells with NaN values.
+func newIsochroneGrid(origin Coordinate, radius float64, n int) *isochroneGrid {
+	g := isochroneGrid{origin: origin, radius: radius, n: n, cell: 2 * radius / float64(n)}
+
+	g.values = make([][]float64, n+3)
+	for j := range g.values {
+		g.values[j] = make([]float64, n+3)
+		for i := range g.values[j] {
+			g.values[j][i] = math.NaN()
+		}
+	}
+
+	return &g
+}
+
+// position returns the coordinate of a padded grid point.
+func (g *isochroneGrid) position(i, j int) Coordinate {
+	return offsetCoordinate(g.origin, -g.radius+float64(i-1)*g.cell, -g.radius+float64(j-1)*g.cell)
+}
+
+// points returns the coordinates of the grid points, row by row from the south-west corner.
+func (g *isochroneGrid) points() []Coordinate {
+	points := make([]Coordinate, 0, (g.n+1)*(g.n+1))
+	for j := 1; j <= g.n+1; j++ {
+		for i := 1; i <= g.n+1; i++ {
+			points = append(points, g.position(i, j))
+		}
+	}
+	return points
+}
+
+// set sets the value of the k-th grid point, in the order of points.
+func (g *isochroneGrid) set(k int, value float64) {
+	g.values[k/(g.n+1)+1][k%(g.n+1)+1] = value
+}
+
+// isochroneEdge is the edge between two padded grid points, identified by their indices in row-major order.
+type isochroneEdge [2]int
+
+// contours returns the polygons of the area where values are at most threshold.
+// Exterior rings are counterclockwise and holes are clockwise.
+func (g *isochroneGrid) contours(threshold float64) [][][]Coordinate {
+	size := g.n + 3
+	inside := func(i, j int) bool { return g.values[j][i] <= threshold }
+
+	next := make(map[isochroneEdge]isochroneEdge)
+	var starts []isochroneEdge
+
+	// Corners of a cell in counterclockwise order, relative to its south-west corner.
+	corners := [4][2]int{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+
+	for j := 0; j < size-1; j++ {
+		for i := 0; i < size-1; i++ {
+			// Crossings are where the counterclockwise boundary of the cell leaves (exit) or enters (entry) the area.
+			// Contours go from exits to entries, so the area is on their left.
+			var crossings []isochroneEdge
+			var exits []bool
+			var sum float64
+
+			for k, c := range corners {
+				d := corners[(k+1)%4]
+				a, b := [2]int{i + c[0], j + c[1]}, [2]int{i + d[0], j + d[1]}
+				sum += g.values[a[1]][a[0]]
+
+				if inA, inB := inside(a[0], a[1]), inside(b[0], b[1]); inA != inB {
+					edge := isochroneEdge{a[1]*size + a[0], b[1]*size + b[0]}
+					if edge[0] > edge[1] {
+						edge[0], edge[1] = edge[1], edge[0]
+					}
+					crossings = append(crossings, edge)
+					exits = append(exits, inA)
+				}
+			}
+
+			switch len(crossings) {
+			case 2:
+				if exits[0] {
+					next[crossings[0]] = crossings[1]
+				} else {
+					next[crossings[1]] = crossings[0]
+				}
+				starts = append(starts, crossings...)
+			case 4:
+				// Saddle, the inside corners are connected if the center of the cell is inside.
+				step := 3
+				if sum/4 <= threshold {
+					step = 1
+				}
+				for k := range crossings {
+					if exits[k] {
+						next[crossings[k]] = crossings[(k+step)%4]
+					}
+				}
+				starts = append(starts, crossings...)
+			}
+		}
+	}
+
+	var exteriors, holes [][]Coordinate
+	visited := make(map[isochroneEdge]bool)
+
+	for _, start := range starts {
+		if visited[start] {
+			continue
+		}
+		if _, ok := next[start]; !ok {
+			continue
+		}
+
+		var ring []Coordinate
+		for e, ok := start, true; ok && !visited[e]; e, ok = next[e] {
+			visited[e] = true
+			ring = append(ring, g.crossing(e, threshold))
+		}
+		if len(ring) < 3 {
+			continue
+		}
+		ring = append(ring, ring[0])
+
+		if ringArea(ring) > 0 {
+			exteriors = append(exteriors, ring)
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+
+	polygons := make([][][]Coordinate, len(exteriors))
+	for i, ring := range exteriors {
+		polygons[i] = [][]Coordinate{ring}
+	}
+
+	// Holes belong to the smallest exterior that contains them.
+	for _, hole := range holes {
+		best := -1
+		for i, ring := range exteriors {
+			if pointInRing(hole[0], ring) && (best == -1 || ringArea(ring) < ringArea(exteriors[best])) {
+				best = i
+			}
+		}
+		if best != -1 {
+			polygons[best] = append(polygons[best], hole)
+		}
+	}
+
+	return polygons
+}
+
+// crossing returns the coordinate where the contour of threshold crosses an edge.
+// It's linearly interpolated between the grid points, or the middle of the edge if one of them is unreachable.
+// Contours never cross the padding, they follow the outermost grid points instead.
+func (g *isochroneGrid) crossing(e isochroneEdge, threshold float64) Coordinate {
+	size := g.n + 3
+	ai, aj, bi, bj := e[0]%size, e[0]/size, e[1]%size, e[1]/size
+	va, vb := g.values[aj][ai], g.values[bj][bi]
+
+	var t float64
+	switch {
+	case math.IsNaN(va):
+		t = 1
+	case math.IsNaN(vb):
+		t = 0
+	case math.IsInf(va, 0) || math.IsInf(vb, 0):
+		t = 0.5
+	default:
+		t = min(max((threshold-va)/(vb-va), 0), 1)
+	}
+
+	a, b := g.position(ai, aj), g.position(bi, bj)
+
+	return Coordinate{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1])}
+}
+
+// ringArea returns the signed area of a closed ring in square degrees, it's positive if the ring is counterclockwise.
+func ringArea(ring []Coordinate) float64 {
+	var area float64
+	for k := 1; k < len(ring); k++ {
+		area += ring[k-1][0]*ring[k][1] - ring[k][0]*ring[k-1][1]
+	}
+	return area / 2
+}
+
+// pointInRing reports whether a point is inside a closed ring, using ray casting.
+func pointInRing(p Coordinate, ring []Coordinate) bool {
+	in := false
+	for k := 1; k < len(ring); k++ {
+		a, b := ring[k-1], ring[k]
+		if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < a[0]+(p[1]-a[1])/(b[1]-a[1])*(b[0]-a[0]) {
+			in = !in
+		}
+	}
+	return in
+}
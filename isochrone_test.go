@@ -0,0 +1,143 @@
+package gosrm
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsochroneGrid_Contours(t *testing.T) {
+	g := newIsochroneGrid(Coordinate{0, 0}, 2*metersPerDegree, 4)
+	for k := range (g.n + 1) * (g.n + 1) {
+		g.set(k, 10)
+	}
+
+	// Nothing is inside.
+	assert.Empty(t, g.contours(5))
+
+	// The center point only.
+	g.set(12, 0)
+	polygons := g.contours(5)
+	assert.Len(t, polygons, 1)
+	assert.Len(t, polygons[0], 1)
+	assert.Equal(t, []Coordinate{{0, -0.5}, {0.5, 0}, {0, 0.5}, {-0.5, 0}, {0, -0.5}}, roundRing(polygons[0][0]))
+	assert.Greater(t, ringArea(polygons[0][0]), float64(0))
+
+	// A ring around an unreachable center.
+	for k := range (g.n + 1) * (g.n + 1) {
+		g.set(k, 10)
+	}
+	for _, k := range []int{6, 7, 8, 11, 13, 16, 17, 18} {
+		g.set(k, 0)
+	}
+	g.set(12, math.Inf(1))
+	polygons = g.contours(5)
+	assert.Len(t, polygons, 1)
+	assert.Len(t, polygons[0], 2)
+	assert.Greater(t, ringArea(polygons[0][0]), float64(0))
+	assert.Less(t, ringArea(polygons[0][1]), float64(0))
+	assert.Equal(t, []Coordinate{{0, -0.5}, {-0.5, 0}, {0, 0.5}, {0.5, 0}, {0, -0.5}}, roundRing(polygons[0][1]))
+
+	// Everything is inside, the contour follows the outermost points.
+	polygons = g.contours(20)
+	assert.Len(t, polygons, 1)
+	assert.Len(t, polygons[0], 2)
+	assert.InDelta(t, 16, ringArea(polygons[0][0]), 1e-6)
+
+	// Two separate areas.
+	for k := range (g.n + 1) * (g.n + 1) {
+		g.set(k, 10)
+	}
+	g.set(0, 0)
+	g.set(24, 0)
+	polygons = g.contours(5)
+	assert.Len(t, polygons, 2)
+}
+
+// roundRing rounds the coordinates of a ring to 6 decimals.
+func roundRing(ring []Coordinate) []Coordinate {
+	rounded := make([]Coordinate, len(ring))
+	for i, c := range ring {
+		rounded[i] = Coordinate{math.Round(c[0]*1e6) / 1e6, math.Round(c[1]*1e6) / 1e6}
+	}
+	return rounded
+}
+
+func TestIsochrone(t *testing.T) {
+	var requests int32
+	srv := newTableTestServer(t, &requests)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	origin := Coordinate{1, 1}
+	fc, err := Isochrone(context.Background(), osrm, IsochroneRequest{
+		Profile:    ProfileCar,
+		Origin:     origin,
+		Thresholds: []float64{200, 1e6},
+		Radius:     5000,
+		Resolution: 10,
+		ChunkSize:  50,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	assert.Len(t, fc.Features, 2)
+
+	f := fc.Features[0]
+	assert.Equal(t, "MultiPolygon", f.Geometry.Type)
+	assert.Equal(t, map[string]any{"kind": "isochrone", "metric": "duration", "threshold": float64(200)}, f.Properties)
+
+	// Test server uses manhattan distance of degrees, so the isochrone is a diamond.
+	polygons := f.Geometry.Coordinates.([][][]Coordinate)
+	assert.Len(t, polygons, 1)
+	assert.Len(t, polygons[0], 1)
+	for _, c := range polygons[0][0] {
+		assert.InDelta(t, 0.02, math.Abs(c[0]-origin[0])+math.Abs(c[1]-origin[1]), 1e-6)
+	}
+	diamond := polygons[0][0]
+
+	// The biggest threshold covers the sampled square.
+	polygons = fc.Features[1].Geometry.Coordinates.([][][]Coordinate)
+	assert.Len(t, polygons, 1)
+	for _, c := range polygons[0][0] {
+		assert.InDelta(t, 5000, math.Max(
+			HaversineDistance(origin, Coordinate{c[0], origin[1]}),
+			HaversineDistance(origin, Coordinate{origin[0], c[1]}),
+		), 1)
+	}
+
+	fc, err = Isochrone(context.Background(), osrm, IsochroneRequest{
+		Origin:     origin,
+		Metric:     IsochroneDistance,
+		Thresholds: []float64{2000},
+		Radius:     5000,
+		Resolution: 10,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "distance", fc.Features[0].Properties["metric"])
+	assert.Equal(t, roundRing(diamond), roundRing(fc.Features[0].Geometry.Coordinates.([][][]Coordinate)[0][0]))
+}
+
+func TestIsochrone_Invalid(t *testing.T) {
+	srv := newTableTestServer(t, nil)
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	for _, req := range []IsochroneRequest{
+		{Radius: 1000},
+		{Thresholds: []float64{60}},
+		{Thresholds: []float64{60}, Radius: 1000, Resolution: 1},
+		{Thresholds: []float64{60}, Radius: 1000, Metric: "weight"},
+	} {
+		_, err := Isochrone(context.Background(), osrm, req)
+		assert.ErrorIs(t, err, ErrInvalidIsochrone)
+	}
+
+	srv.Close()
+	_, err = Isochrone(context.Background(), osrm, IsochroneRequest{Thresholds: []float64{60}, Radius: 1000})
+	assert.Error(t, err)
+}
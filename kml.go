@@ -0,0 +1,167 @@
+package gosrm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// kmlNamespace is the namespace of KML 2.2 documents.
+const kmlNamespace string = "http://www.opengis.net/kml/2.2"
+
+type (
+	// kmlDocument is the root element of a KML document.
+	kmlDocument struct {
+		XMLName  xml.Name `xml:"kml"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Document kmlFolder
+	}
+
+	// kmlFolder is a KML Document or Folder element.
+	kmlFolder struct {
+		XMLName    xml.Name
+		Name       string         `xml:"name,omitempty"`
+		Folders    []kmlFolder    `xml:"Folder"`
+		Placemarks []kmlPlacemark `xml:"Placemark"`
+	}
+
+	// kmlPlacemark is a KML Placemark element.
+	kmlPlacemark struct {
+		Name        string         `xml:"name,omitempty"`
+		Description string         `xml:"description,omitempty"`
+		Point       *kmlPoint      `xml:"Point"`
+		LineString  *kmlLineString `xml:"LineString"`
+	}
+
+	// kmlPoint is a KML Point element.
+	kmlPoint struct {
+		Coordinates string `xml:"coordinates"`
+	}
+
+	// kmlLineString is a KML LineString element.
+	kmlLineString struct {
+		Tessellate  int    `xml:"tessellate"`
+		Coordinates string `xml:"coordinates"`
+	}
+)
+
+// WriteKML writes the route as a KML document.
+// The geometry of the route is written as a line string placemark,
+// waypoints and step maneuvers are written as point placemarks in separate folders.
+// Steps are only written if they were requested.
+func WriteKML[T GeometryType](w io.Writer, name string, route RouteType[T], waypoints []Waypoint) error {
+	doc := kmlDocument{
+		Xmlns:    kmlNamespace,
+		Document: kmlFolder{XMLName: xml.Name{Local: "Document"}, Name: name},
+	}
+
+	coordinates, err := coordinatesOf(route.Geometry)
+	if err != nil {
+		return err
+	}
+
+	if len(coordinates) > 0 {
+		doc.Document.Placemarks = append(doc.Document.Placemarks, kmlPlacemark{
+			Name:       name,
+			LineString: &kmlLineString{Tessellate: 1, Coordinates: kmlCoordinates(coordinates...)},
+		})
+	}
+
+	if len(waypoints) > 0 {
+		folder := kmlFolder{XMLName: xml.Name{Local: "Folder"}, Name: "Waypoints"}
+		for _, wp := range waypoints {
+			folder.Placemarks = append(folder.Placemarks, kmlPlacemark{
+				Name:  wp.Name,
+				Point: &kmlPoint{Coordinates: kmlCoordinates(wp.Location)},
+			})
+		}
+		doc.Document.Folders = append(doc.Document.Folders, folder)
+	}
+
+	if steps := routeSteps(route); len(steps) > 0 {
+		folder := kmlFolder{XMLName: xml.Name{Local: "Folder"}, Name: "Steps"}
+		for _, step := range steps {
+			folder.Placemarks = append(folder.Placemarks, kmlPlacemark{
+				Name:        step.Name,
+				Description: stepDescription(step),
+				Point:       &kmlPoint{Coordinates: kmlCoordinates(step.Maneuver.Location)},
+			})
+		}
+		doc.Document.Folders = append(doc.Document.Folders, folder)
+	}
+
+	return writeXML(w, doc)
+}
+
+// ParseKML parses the line string placemarks of a KML document.
+// Each line string is returned as a request with the given profile, placemarks in nested folders are included.
+func ParseKML(r io.Reader, profile Profile) ([]Request, error) {
+	var doc kmlDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var requests []Request
+	var walk func(folder kmlFolder) error
+	walk = func(folder kmlFolder) error {
+		for _, pm := range folder.Placemarks {
+			if pm.LineString == nil {
+				continue
+			}
+
+			coordinates, err := parseKMLCoordinates(pm.LineString.Coordinates)
+			if err != nil {
+				return err
+			}
+			requests = append(requests, Request{Profile: profile, Coordinates: coordinates})
+		}
+
+		for _, f := range folder.Folders {
+			if err := walk(f); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(doc.Document); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// parseKMLCoordinates parses KML coordinates, {lng},{lat}[,{alt}] tuples separated by whitespaces.
+func parseKMLCoordinates(s string) ([]Coordinate, error) {
+	tuples := strings.Fields(s)
+	coordinates := make([]Coordinate, len(tuples))
+
+	for i, tuple := range tuples {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("gosrm: invalid kml coordinates %q", tuple)
+		}
+
+		for j := 0; j < 2; j++ {
+			v, err := strconv.ParseFloat(parts[j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("gosrm: invalid kml coordinates %q: %w", tuple, err)
+			}
+			coordinates[i][j] = v
+		}
+	}
+
+	return coordinates, nil
+}
+
+// kmlCoordinates returns the KML representation of coordinates, {lng},{lat} tuples separated by spaces.
+func kmlCoordinates(coordinates ...Coordinate) string {
+	tuples := make([]string, len(coordinates))
+	for i, c := range coordinates {
+		tuples[i] = strconv.FormatFloat(c[0], 'f', -1, 64) + "," + strconv.FormatFloat(c[1], 'f', -1, 64)
+	}
+	return strings.Join(tuples, " ")
+}
@@ -0,0 +1,59 @@
+package gosrm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteKML(t *testing.T) {
+	coordinates := []Coordinate{{13.1, 52.1}, {13.2, 52.2}}
+	route := RouteType[AnyGeometry]{
+		Geometry: NewGeometry(GeometryPolyline, coordinates),
+		Legs: []RouteLeg[AnyGeometry]{{Steps: []RouteStep[AnyGeometry]{
+			{Name: "A", Maneuver: StepManeuver{Type: "depart", Location: coordinates[0]}},
+		}}},
+	}
+
+	var buf bytes.Buffer
+	err := WriteKML(&buf, "route", route, []Waypoint{{Name: "start", Location: coordinates[0]}})
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, `<kml xmlns="http://www.opengis.net/kml/2.2">`)
+	assert.Contains(t, out, `<coordinates>13.1,52.1 13.2,52.2</coordinates>`)
+	assert.Contains(t, out, `<name>Waypoints</name>`)
+	assert.Contains(t, out, `<description>depart onto A</description>`)
+
+	// Written documents can be parsed back.
+	requests, err := ParseKML(&buf, ProfileFoot)
+	assert.NoError(t, err)
+	assert.Len(t, requests, 1)
+	assert.Equal(t, ProfileFoot, requests[0].Profile)
+	assert.Equal(t, coordinates, requests[0].Coordinates)
+}
+
+func TestParseKML(t *testing.T) {
+	doc := `<kml xmlns="http://www.opengis.net/kml/2.2"><Document>
+		<Folder><Folder><Placemark><LineString><coordinates>
+			1,2,0
+			3,4,0
+		</coordinates></LineString></Placemark></Folder></Folder>
+	</Document></kml>`
+
+	requests, err := ParseKML(strings.NewReader(doc), ProfileCar)
+	assert.NoError(t, err)
+	assert.Len(t, requests, 1)
+	assert.Equal(t, []Coordinate{{1, 2}, {3, 4}}, requests[0].Coordinates)
+
+	_, err = ParseKML(strings.NewReader(strings.Replace(doc, "3,4,0", "3", 1)), ProfileCar)
+	assert.Error(t, err)
+
+	_, err = ParseKML(strings.NewReader(strings.Replace(doc, "3,4,0", "3,x", 1)), ProfileCar)
+	assert.Error(t, err)
+
+	_, err = ParseKML(strings.NewReader("<kml"), ProfileCar)
+	assert.Error(t, err)
+}
@@ -0,0 +1,310 @@
+package gosrm
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"slices"
+)
+
+// ErrInvalidKNearest is returned when a k-nearest request is not valid.
+var ErrInvalidKNearest = errors.New("gosrm: invalid k-nearest request")
+
+// DefaultKNearestPrefilter is the default number of candidates that are evaluated by table service.
+const DefaultKNearestPrefilter int = 25
+
+// KNearestDirection is the direction of the travels of a k-nearest request.
+type KNearestDirection string
+
+const (
+	// KNearestToTarget ranks candidates by travels from them to the target, e.g. couriers to a customer.
+	KNearestToTarget KNearestDirection = "to"
+
+	// KNearestFromTarget ranks candidates by travels from the target to them, e.g. a customer to stores.
+	KNearestFromTarget KNearestDirection = "from"
+)
+
+type (
+	// KNearestRequest is the request of the k nearest candidates by travel time or distance.
+	KNearestRequest struct {
+		// Profile is used to compute travel durations and distances.
+		Profile Profile
+
+		// Target is the coordinate candidates are ranked by.
+		Target Coordinate
+
+		// Candidates are the coordinates that are ranked.
+		Candidates []Coordinate
+
+		// K is the number of candidates returned, candidates tied with the K-th one are also returned.
+		K int
+
+		// Direction of the travels, defaults to KNearestToTarget.
+		Direction KNearestDirection
+
+		// Metric candidates are ranked by, defaults to TableDurations.
+		Metric TableMatrix
+
+		// Prefilter is the number of candidates nearest to the target by straight-line distance
+		// that are evaluated by table service. Defaults to DefaultKNearestPrefilter, it's at least K and at most 65534.
+		Prefilter int
+
+		// ChunkSize is the max number of sources and destinations per table request, see TableChunked.
+		// Prefilters bigger than the max table size of OSRM are evaluated by several table requests.
+		ChunkSize int
+
+		// MaxDistance is the max straight-line distance of candidates to the target in meters, 0 is unlimited.
+		MaxDistance float64
+	}
+
+	// KNearestCandidate is a candidate ranked by travel time or distance.
+	KNearestCandidate struct {
+		// Index is the index of the candidate in the request.
+		Index int
+
+		// Duration of the travel in seconds.
+		Duration float32
+
+		// Distance of the travel in meters.
+		Distance float32
+
+		// StraightLineDistance is the straight-line distance of the candidate to the target in meters.
+		StraightLineDistance float64
+
+		// Estimated reports whether duration and distance are estimated by fallback speed, see WithFallbackSpeed.
+		Estimated bool
+	}
+
+	// KNearestResult is the result of a k-nearest request.
+	KNearestResult struct {
+		// Candidates are the nearest candidates, sorted by the metric of the request.
+		// Ties are broken by straight-line distance.
+		Candidates []KNearestCandidate
+
+		// Unreachable are the indices of the evaluated candidates which can't be reached, in straight-line order.
+		Unreachable []int
+	}
+)
+
+// KNearest returns the k candidates nearest to the target by travel time or distance.
+// Candidates are prefiltered by straight-line distance using a spatial index, then the remaining ones
+// are evaluated by table requests in the direction of the request, in chunks of at most ChunkSize candidates.
+// Options are passed to table service, e.g. WithFallbackSpeed to estimate travels that can't be routed.
+// A ResponseError is returned if OSRM can't process the request.
+func KNearest(ctx context.Context, osrm OSRMClient, req KNearestRequest, opts ...Option) (*KNearestResult, error) {
+	if req.Direction == "" {
+		req.Direction = KNearestToTarget
+	}
+	if req.Metric == "" {
+		req.Metric = TableDurations
+	}
+	if req.Prefilter <= 0 {
+		req.Prefilter = DefaultKNearestPrefilter
+	}
+	req.Prefilter = max(req.Prefilter, req.K)
+
+	if req.K <= 0 || req.MaxDistance < 0 ||
+		(req.Direction != KNearestToTarget && req.Direction != KNearestFromTarget) ||
+		(req.Metric != TableDurations && req.Metric != TableDistances) {
+		return nil, ErrInvalidKNearest
+	}
+
+	candidates := newKDTree(req.Candidates).nearest(req.Target, min(req.Prefilter, math.MaxUint16-1), req.MaxDistance)
+	if len(candidates) == 0 {
+		return &KNearestResult{}, nil
+	}
+
+	// The target is the first coordinate of the table request and candidates follow it.
+	table := Request{Profile: req.Profile, Coordinates: []Coordinate{req.Target}}
+	indices := make([]int, len(candidates))
+	for i, c := range candidates {
+		table.Coordinates = append(table.Coordinates, req.Candidates[c])
+		indices[i] = i + 1
+	}
+
+	sources, destinations := indices, []int{0}
+	if req.Direction == KNearestFromTarget {
+		sources, destinations = destinations, sources
+	}
+
+	tableOpts := append(slices.Clone(opts), WithAnnotations(AnnotationsDurationDistance))
+	res, err := TableChunked(ctx, osrm, table, sources, destinations, req.ChunkSize, tableOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// cell returns the row and column of the i-th candidate.
+	cell := func(i int) (int, int) {
+		if req.Direction == KNearestToTarget {
+			return i, 0
+		}
+		return 0, i
+	}
+
+	estimated := make(map[[2]int]bool, len(res.FallbackSpeedCells))
+	for _, c := range res.FallbackSpeedCells {
+		if len(c) == 2 {
+			estimated[[2]int{int(c[0]), int(c[1])}] = true
+		}
+	}
+
+	var result KNearestResult
+	for i, c := range candidates {
+		row, col := cell(i)
+		candidate := KNearestCandidate{
+			Index:                c,
+			Duration:             tableValue(res.Durations, row, col),
+			Distance:             tableValue(res.Distances, row, col),
+			StraightLineDistance: HaversineDistance(req.Target, req.Candidates[c]),
+			Estimated:            estimated[[2]int{row, col}],
+		}
+
+		if math.IsInf(float64(candidate.metric(req.Metric)), 1) {
+			result.Unreachable = append(result.Unreachable, c)
+			continue
+		}
+		result.Candidates = append(result.Candidates, candidate)
+	}
+
+	slices.SortStableFunc(result.Candidates, func(a, b KNearestCandidate) int {
+		return cmp.Or(
+			cmp.Compare(a.metric(req.Metric), b.metric(req.Metric)),
+			cmp.Compare(a.StraightLineDistance, b.StraightLineDistance),
+		)
+	})
+
+	// Candidates tied with the K-th one are kept.
+	k := min(req.K, len(result.Candidates))
+	for k > 0 && k < len(result.Candidates) && result.Candidates[k].metric(req.Metric) == result.Candidates[k-1].metric(req.Metric) {
+		k++
+	}
+	result.Candidates = result.Candidates[:k]
+
+	return &result, nil
+}
+
+// metric returns the value of the metric of the candidate.
+func (c KNearestCandidate) metric(metric TableMatrix) float32 {
+	if metric == TableDistances {
+		return c.Distance
+	}
+	return c.Duration
+}
+
+// tableValue returns a value of a matrix, it's positive infinity if the matrix doesn't have it.
+func tableValue(m [][]float32, row, col int) float32 {
+	if row >= len(m) || col >= len(m[row]) {
+		return float32(math.Inf(1))
+	}
+	return m[row][col]
+}
+
+// kdTree is a static k-d tree of coordinates as points on the unit sphere.
+// Chord distances of the points have the same order as great-circle distances of the coordinates,
+// so nearest neighbors are exact on the whole globe.
+type kdTree struct {
+	points [][3]float64
+
+	// nodes are the indices of the points, each subtree is a range of nodes with its root in the middle.
+	nodes []int
+}
+
+// newKDTree returns a k-d tree of the coordinates.
+func newKDTree(coordinates []Coordinate) *kdTree {
+	t := kdTree{points: make([][3]float64, len(coordinates)), nodes: allIndices(len(coordinates))}
+	for i, c := range coordinates {
+		t.points[i] = unitVector(c)
+	}
+
+	t.build(t.nodes, 0)
+	return &t
+}
+
+// build orders the nodes of a subtree by the axis of its depth and builds its children.
+func (t *kdTree) build(nodes []int, depth int) {
+	if len(nodes) <= 1 {
+		return
+	}
+
+	axis := depth % 3
+	slices.SortFunc(nodes, func(a, b int) int {
+		return cmp.Compare(t.points[a][axis], t.points[b][axis])
+	})
+
+	mid := len(nodes) / 2
+	t.build(nodes[:mid], depth+1)
+	t.build(nodes[mid+1:], depth+1)
+}
+
+// nearest returns the indices of the k coordinates nearest to c, sorted by distance.
+// Coordinates farther than maxDistance meters are excluded if it's positive.
+func (t *kdTree) nearest(c Coordinate, k int, maxDistance float64) []int {
+	type neighbor struct {
+		index    int
+		distance float64
+	}
+
+	// Great-circle distances are converted to chord distances of the unit sphere.
+	bound := math.Inf(1)
+	if maxDistance > 0 && maxDistance < math.Pi*earthRadius {
+		bound = 2 * math.Sin(maxDistance/(2*earthRadius))
+	}
+
+	p := unitVector(c)
+	var neighbors []neighbor
+
+	var search func(nodes []int, depth int)
+	search = func(nodes []int, depth int) {
+		if len(nodes) == 0 {
+			return
+		}
+
+		mid := len(nodes) / 2
+		node := nodes[mid]
+
+		if d := chordDistance(p, t.points[node]); d <= bound {
+			i, _ := slices.BinarySearchFunc(neighbors, d, func(n neighbor, d float64) int {
+				return cmp.Compare(n.distance, d)
+			})
+			neighbors = slices.Insert(neighbors, i, neighbor{index: node, distance: d})
+			if len(neighbors) > k {
+				neighbors = neighbors[:k]
+			}
+			if len(neighbors) == k {
+				bound = neighbors[k-1].distance
+			}
+		}
+
+		axis := depth % 3
+		diff := p[axis] - t.points[node][axis]
+		near, far := nodes[:mid], nodes[mid+1:]
+		if diff > 0 {
+			near, far = far, near
+		}
+
+		search(near, depth+1)
+		if math.Abs(diff) <= bound {
+			search(far, depth+1)
+		}
+	}
+	search(t.nodes, 0)
+
+	indices := make([]int, len(neighbors))
+	for i, n := range neighbors {
+		indices[i] = n.index
+	}
+	return indices
+}
+
+// unitVector returns the point of the coordinate on the unit sphere.
+func unitVector(c Coordinate) [3]float64 {
+	lng, lat := c[0]*math.Pi/180, c[1]*math.Pi/180
+	return [3]float64{math.Cos(lat) * math.Cos(lng), math.Cos(lat) * math.Sin(lng), math.Sin(lat)}
+}
+
+// chordDistance returns the straight distance of points.
+func chordDistance(a, b [3]float64) float64 {
+	dx, dy, dz := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
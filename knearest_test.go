@@ -0,0 +1,170 @@
+package gosrm
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newKNearestTestServer returns a fake OSRM table service, distances are testTableDistance and durations are
+// distances divided by 10. Coordinates with a negative longitude can't be routed unless fallback speed is set,
+// then their distances are straight-line distances.
+func newKNearestTestServer(t *testing.T, queries *[]url.Values) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		*queries = append(*queries, q)
+
+		coordinates := parseTestCoordinates(r.URL.Path)
+		sources := parseTestIndices(q.Get("sources"), len(coordinates))
+		destinations := parseTestIndices(q.Get("destinations"), len(coordinates))
+
+		res := TableResponse{Response: Response{Code: CodeOK}}
+		for i, src := range sources {
+			var durations, distances []float32
+			for j, dst := range destinations {
+				a, b := coordinates[src], coordinates[dst]
+
+				d := testTableDistance(a, b)
+				if a[0] < 0 || b[0] < 0 {
+					d = float32(math.Inf(1))
+					if q.Has("fallback_speed") {
+						d = float32(HaversineDistance(a, b))
+						res.FallbackSpeedCells = append(res.FallbackSpeedCells, []uint16{uint16(i), uint16(j)})
+					}
+				}
+
+				distances = append(distances, d)
+				durations = append(durations, d/10)
+			}
+			res.Durations = append(res.Durations, durations)
+			res.Distances = append(res.Distances, distances)
+		}
+
+		assert.NoError(t, json.NewEncoder(w).Encode(testTableJSON(res)))
+	}))
+}
+
+func TestKNearest(t *testing.T) {
+	var queries []url.Values
+	srv := newKNearestTestServer(t, &queries)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	req := KNearestRequest{
+		Profile: ProfileCar,
+		Target:  Coordinate{0.5, 0.5},
+		Candidates: []Coordinate{
+			{0.5, 0.6},   // 0: 10000m by the grid
+			{0.56, 0.56}, // 1: 12000m by the grid but nearer in a straight line
+			{0.4, 0.5},   // 2: 10000m by the grid, tied with 0
+			{-0.5, 0.5},  // 3: unreachable
+			{-0.45, 0.5}, // 4: unreachable
+			{10, 10},     // 5: too far for the prefilter
+		},
+		K:         1,
+		Prefilter: 5,
+	}
+
+	res, err := KNearest(context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.Len(t, queries, 1)
+	assert.Equal(t, "0;1;2;3;4", queries[0].Get("sources"))
+	assert.Equal(t, "5", queries[0].Get("destinations"))
+	assert.Equal(t, "duration,distance", queries[0].Get("annotations"))
+
+	// Candidates tied with the K-th one are returned too, ordered by straight-line distance.
+	assert.Len(t, res.Candidates, 2)
+	assert.ElementsMatch(t, []int{0, 2}, []int{res.Candidates[0].Index, res.Candidates[1].Index})
+	assert.InDelta(t, 1000, res.Candidates[0].Duration, 0.01)
+	assert.InDelta(t, 10000, res.Candidates[0].Distance, 0.01)
+	assert.InDelta(t, 11119, res.Candidates[0].StraightLineDistance, 10)
+	assert.LessOrEqual(t, res.Candidates[0].StraightLineDistance, res.Candidates[1].StraightLineDistance)
+	assert.Equal(t, []int{4, 3}, res.Unreachable)
+
+	// Unreachable candidates can be estimated by fallback speed.
+	req.Direction = KNearestFromTarget
+	req.Metric = TableDistances
+	req.K = 5
+	req.MaxDistance = 200000
+	res, err = KNearest(context.Background(), osrm, req, WithFallbackSpeed(10))
+	assert.NoError(t, err)
+	assert.Equal(t, "0", queries[1].Get("sources"))
+	assert.Equal(t, "1;2;3;4;5", queries[1].Get("destinations"))
+	assert.Equal(t, "10.000000", queries[1].Get("fallback_speed"))
+
+	var indices []int
+	for _, c := range res.Candidates {
+		indices = append(indices, c.Index)
+		assert.Equal(t, c.Index == 3 || c.Index == 4, c.Estimated)
+	}
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 4}, indices)
+	assert.Equal(t, 1, indices[2])
+	assert.Empty(t, res.Unreachable)
+
+	// Prefilters bigger than the chunk size are evaluated by several table requests.
+	req.ChunkSize = 2
+	chunked, err := KNearest(context.Background(), osrm, req, WithFallbackSpeed(10))
+	assert.NoError(t, err)
+	assert.Len(t, queries, 5)
+	assert.Equal(t, res, chunked)
+	req.ChunkSize = 0
+
+	// Candidates which are too far away aren't evaluated.
+	req.MaxDistance = 100
+	res, err = KNearest(context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.Empty(t, res.Candidates)
+	assert.Len(t, queries, 5)
+
+	for _, invalid := range []KNearestRequest{
+		{K: 0},
+		{K: 1, Direction: "invalid"},
+		{K: 1, Metric: "invalid"},
+		{K: 1, MaxDistance: -1},
+	} {
+		_, err := KNearest(context.Background(), osrm, invalid)
+		assert.ErrorIs(t, err, ErrInvalidKNearest)
+	}
+}
+
+func TestKDTree_Nearest(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	coordinates := make([]Coordinate, 500)
+	for i := range coordinates {
+		coordinates[i] = Coordinate{rng.Float64()*360 - 180, rng.Float64()*180 - 90}
+	}
+	tree := newKDTree(coordinates)
+
+	for range 20 {
+		c := Coordinate{rng.Float64()*360 - 180, rng.Float64()*180 - 90}
+
+		expected := allIndices(len(coordinates))
+		slices.SortFunc(expected, func(a, b int) int {
+			return cmp.Compare(HaversineDistance(c, coordinates[a]), HaversineDistance(c, coordinates[b]))
+		})
+
+		assert.Equal(t, expected[:10], tree.nearest(c, 10, 0))
+
+		var within []int
+		for _, i := range expected {
+			if HaversineDistance(c, coordinates[i]) <= 2000000 {
+				within = append(within, i)
+			}
+		}
+		assert.Equal(t, within, tree.nearest(c, len(coordinates), 2000000))
+	}
+
+	assert.Empty(t, newKDTree(nil).nearest(Coordinate{}, 3, 0))
+}
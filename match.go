@@ -13,6 +13,7 @@ type MatchResponse[T GeometryType] struct {
 
 	// Tracepoints is an array of waypoint objects representing all points of the trace in order.
 	// If the trace point was ommited by map matching because it is an outlier, the entry will be null.
+	// Null entries are decoded as zero values, see Tracepoint.Omitted.
 	Tracepoints []Tracepoint `json:"tracepoints"`
 
 	// Matchings is an array of route objects that assemble the trace.
@@ -25,10 +26,35 @@ func Match[T GeometryType](ctx context.Context, osrm OSRMClient, req Request, op
 
 	osrm.applyOpts(u, opts)
 
+	format := requestedGeometries(u)
+	if err := checkGeometryType[T](format); err != nil {
+		return nil, err
+	}
+
 	var res MatchResponse[T]
 	if err := osrm.get(ctx, u.String(), &res); err != nil {
 		return nil, err
 	}
 
+	for i := range res.Matchings {
+		if err := decodeGeometries(&res.Matchings[i].RouteType, format); err != nil {
+			return nil, err
+		}
+	}
+
+	if keys := osrm.hintKeys(req); keys != nil {
+		waypoints := make([]Waypoint, len(res.Tracepoints))
+		for i := range res.Tracepoints {
+			waypoints[i] = res.Tracepoints[i].Waypoint
+		}
+		osrm.updateHints(keys, waypoints, res.Response)
+	}
+
 	return &res, nil
 }
+
+// MatchAny is Match with AnyGeometry geometries, which can hold all of the geometry formats,
+// so it doesn't need a type parameter that matches the geometries option.
+func MatchAny(ctx context.Context, osrm OSRMClient, req Request, opts ...Option) (*MatchResponse[AnyGeometry], error) {
+	return Match[AnyGeometry](ctx, osrm, req, opts...)
+}
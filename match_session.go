@@ -0,0 +1,293 @@
+package gosrm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ErrInvalidMatchPoint is returned when the timestamp of a match point isn't after the timestamp of the previous one.
+var ErrInvalidMatchPoint = errors.New("gosrm: match point timestamps must be increasing")
+
+const (
+	// DefaultMatchWindowSize is the default max number of uncommitted points of a match session.
+	DefaultMatchWindowSize int = 20
+
+	// DefaultMatchRadius is the default standard deviation of GPS precision of match points, in meters.
+	DefaultMatchRadius float32 = 10
+)
+
+type (
+	// MatchSessionConfig is the config of a match session.
+	MatchSessionConfig struct {
+		// WindowSize is the max number of uncommitted points that are matched together.
+		// When the window is full, its oldest leg is committed even if it's ambiguous.
+		// Defaults to DefaultMatchWindowSize.
+		WindowSize int
+
+		// Radius is the standard deviation of GPS precision of points without a radius, in meters.
+		// Defaults to DefaultMatchRadius.
+		Radius float32
+
+		// Options are passed to match service, e.g. WithGaps or WithTidy.
+		// Timestamps, radiuses, annotations, overview and geometries are set by the session.
+		Options []Option
+	}
+
+	// MatchPoint is a GPS point of a match session.
+	MatchPoint struct {
+		// Location of the point.
+		Location Coordinate
+
+		// Timestamp of the point in UNIX seconds.
+		Timestamp int64
+
+		// Radius is the standard deviation of GPS precision of the point, in meters.
+		// If it's 0 then the radius of the session config is used.
+		Radius float32
+	}
+
+	// MatchedSegment is a confirmed part of the road network traveled between two points of a match session.
+	MatchedSegment struct {
+		// FromIndex is the index of the first point of the segment, in the order points were added to the session.
+		FromIndex int
+
+		// ToIndex is the index of the last point of the segment, in the order points were added to the session.
+		ToIndex int
+
+		// Nodes are the OSM node IDs of the segment.
+		// Nodes shared with the previous segment of the session are omitted, so segments can be concatenated.
+		Nodes []uint64
+
+		// Distance is the distance traveled by the segment, in meters.
+		Distance float32
+
+		// Duration is the estimated travel time of the segment, in seconds.
+		Duration float32
+
+		// Confidence is the confidence of the matching the segment was part of.
+		Confidence float32
+	}
+
+	// MatchSession matches a live stream of GPS points incrementally.
+	// Each point re-matches a sliding window of the uncommitted points, and the legs up to the last
+	// unambiguous point are committed and returned as matched segments. The last committed point stays in
+	// the window, so the next match continues from it.
+	//
+	// A session is safe for concurrent use, but updates of a vehicle are usually sent from one goroutine
+	// to keep them in order. Sessions of different vehicles can share one OSRMClient.
+	MatchSession struct {
+		osrm    OSRMClient
+		profile Profile
+		config  MatchSessionConfig
+
+		mu     sync.Mutex
+		points []MatchPoint
+
+		// offset is the index of the first point of the window in the session.
+		offset int
+
+		// nodes are the nodes of the last matched segment.
+		nodes []uint64
+	}
+)
+
+// NewMatchSession returns a new match session of the profile.
+func NewMatchSession(osrm OSRMClient, profile Profile, config MatchSessionConfig) *MatchSession {
+	if config.WindowSize < 2 {
+		config.WindowSize = DefaultMatchWindowSize
+	}
+	if config.Radius <= 0 {
+		config.Radius = DefaultMatchRadius
+	}
+
+	return &MatchSession{osrm: osrm, profile: profile, config: config}
+}
+
+// Add adds a point to the session and returns the segments that were confirmed by it.
+// Points that can't be matched are dropped once they leave the window.
+// If the request fails the point is kept, so it's matched again with the next one.
+func (s *MatchSession) Add(ctx context.Context, point MatchPoint) ([]MatchedSegment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n := len(s.points); n > 0 && point.Timestamp <= s.points[n-1].Timestamp {
+		return nil, ErrInvalidMatchPoint
+	}
+	s.points = append(s.points, point)
+
+	if len(s.points) < 2 {
+		return nil, nil
+	}
+
+	res, err := s.match(ctx)
+	if err != nil {
+		s.drop()
+		return nil, err
+	}
+
+	// The last point is always ambiguous because the trace may continue on any road from it.
+	end := 0
+	if res != nil {
+		for i := len(s.points) - 2; i > 0; i-- {
+			if tp := res.Tracepoints[i]; !tp.Omitted() && tp.AlternativesCount == 0 {
+				end = i
+				break
+			}
+		}
+	}
+
+	if end == 0 && len(s.points) > s.config.WindowSize {
+		end = 1
+	}
+
+	if end == 0 {
+		return nil, nil
+	}
+
+	return s.commit(res, end), nil
+}
+
+// Flush matches the uncommitted points and commits them all, e.g. at the end of a trip.
+// The last point stays in the session, so more points can be added after it.
+func (s *MatchSession) Flush(ctx context.Context) ([]MatchedSegment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.points) < 2 {
+		return nil, nil
+	}
+
+	res, err := s.match(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.commit(res, len(s.points)-1), nil
+}
+
+// Pending returns the number of points of the session that aren't committed.
+func (s *MatchSession) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.points)
+}
+
+// match matches the points of the window, the response is nil if there is no match.
+// The caller must hold the lock.
+func (s *MatchSession) match(ctx context.Context) (*MatchResponse[string], error) {
+	req := Request{Profile: s.profile, Coordinates: make([]Coordinate, len(s.points))}
+	timestamps := make([]int64, len(s.points))
+	radiuses := make([]float32, len(s.points))
+
+	for i, p := range s.points {
+		req.Coordinates[i], timestamps[i], radiuses[i] = p.Location, p.Timestamp, p.Radius
+		if radiuses[i] <= 0 {
+			radiuses[i] = s.config.Radius
+		}
+	}
+
+	opts := append(slices.Clone(s.config.Options),
+		WithTimestamps(timestamps),
+		WithRadiuses(radiuses),
+		WithAnnotations(AnnotationsNodes),
+		WithOverview(OverviewFalse),
+		WithGeometries(GeometryPolyline),
+	)
+
+	res, err := Match[string](ctx, s.osrm, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Code == CodeNoMatch {
+		return nil, nil
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	if len(res.Tracepoints) != len(s.points) {
+		return nil, fmt.Errorf("gosrm: match response has %d tracepoints for %d points", len(res.Tracepoints), len(s.points))
+	}
+
+	return res, nil
+}
+
+// commit returns the segments between consecutive matched points up to end and removes the points before end.
+// The caller must hold the lock.
+func (s *MatchSession) commit(res *MatchResponse[string], end int) []MatchedSegment {
+	var segments []MatchedSegment
+
+	from := -1
+	for i := 0; res != nil && i <= end; i++ {
+		tp := res.Tracepoints[i]
+		if tp.Omitted() {
+			continue
+		}
+
+		if from >= 0 {
+			if segment, ok := s.segment(res, from, i); ok {
+				segments = append(segments, segment)
+			}
+		}
+		from = i
+	}
+
+	s.points = s.points[end:]
+	s.offset += end
+
+	return segments
+}
+
+// segment returns the segment between the matched points at indices from and to of the window.
+// The caller must hold the lock.
+func (s *MatchSession) segment(res *MatchResponse[string], from, to int) (MatchedSegment, bool) {
+	a, b := res.Tracepoints[from], res.Tracepoints[to]
+
+	// Points of different matchings aren't connected, e.g. when the trace is split at a gap.
+	if a.MatchingIndex != b.MatchingIndex || int(a.MatchingIndex) >= len(res.Matchings) {
+		return MatchedSegment{}, false
+	}
+
+	matching := res.Matchings[a.MatchingIndex]
+	if b.WaypointIndex != a.WaypointIndex+1 || int(a.WaypointIndex) >= len(matching.Legs) {
+		return MatchedSegment{}, false
+	}
+
+	leg := matching.Legs[a.WaypointIndex]
+	nodes := leg.Annotation.Nodes[trimmedNodes(s.nodes, leg.Annotation.Nodes):]
+	if len(leg.Annotation.Nodes) > 0 {
+		s.nodes = leg.Annotation.Nodes
+	}
+
+	return MatchedSegment{
+		FromIndex:  s.offset + from,
+		ToIndex:    s.offset + to,
+		Nodes:      slices.Clone(nodes),
+		Distance:   leg.Distance,
+		Duration:   leg.Duration,
+		Confidence: matching.Confidence,
+	}, true
+}
+
+// drop drops the oldest point if the window is over its size.
+// The caller must hold the lock.
+func (s *MatchSession) drop() {
+	if len(s.points) > s.config.WindowSize {
+		s.points = s.points[1:]
+		s.offset++
+	}
+}
+
+// trimmedNodes returns the number of leading nodes of next that overlap with the trailing nodes of prev.
+func trimmedNodes(prev, next []uint64) int {
+	for n := min(len(prev), len(next)); n > 0; n-- {
+		if slices.Equal(prev[len(prev)-n:], next[:n]) {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,222 @@
+package gosrm
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testMatchNode returns the fake OSM node ID of a coordinate.
+func testMatchNode(c Coordinate) uint64 {
+	return uint64(math.Round(c[0] * 1000))
+}
+
+// newMatchTestServer returns a fake OSRM match service.
+// Points with a negative latitude are outliers, points with a positive latitude are ambiguous,
+// and the trace can't be matched if it has a point with a negative longitude.
+// Legs go through the nodes of their points and the requests' queries are sent to queries.
+func newMatchTestServer(t *testing.T, queries chan<- url.Values) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if queries != nil {
+			queries <- r.URL.Query()
+		}
+
+		res := MatchResponse[string]{Response: Response{Code: CodeOK}, Matchings: []Matching[string]{{Confidence: 0.9}}}
+
+		var prev Coordinate
+		matched := false
+		for _, c := range parseTestCoordinates(r.URL.Path) {
+			if c[0] < 0 {
+				res = MatchResponse[string]{Response: Response{Code: CodeNoMatch}}
+				break
+			}
+			if c[1] < 0 {
+				res.Tracepoints = append(res.Tracepoints, Tracepoint{})
+				continue
+			}
+
+			tp := Tracepoint{Waypoint: Waypoint{Hint: "h"}, WaypointIndex: uint16(len(res.Matchings[0].Legs))}
+			if c[1] > 0 {
+				tp.AlternativesCount = 1
+			}
+			if matched {
+				res.Matchings[0].Legs = append(res.Matchings[0].Legs, RouteLeg[string]{
+					Distance:   float32(HaversineDistance(prev, c)),
+					Annotation: Annotation{Nodes: []uint64{testMatchNode(prev), testMatchNode(c)}},
+				})
+				tp.WaypointIndex++
+			}
+			res.Tracepoints = append(res.Tracepoints, tp)
+			prev, matched = c, true
+		}
+
+		assert.NoError(t, json.NewEncoder(w).Encode(res))
+	}))
+}
+
+// testMatchNodes returns the nodes of segments.
+func testMatchNodes(segments []MatchedSegment) []uint64 {
+	var nodes []uint64
+	for _, s := range segments {
+		nodes = append(nodes, s.Nodes...)
+	}
+	return nodes
+}
+
+func TestMatchSession_Add(t *testing.T) {
+	queries := make(chan url.Values, 10)
+	srv := newMatchTestServer(t, queries)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	s := NewMatchSession(osrm, ProfileCar, MatchSessionConfig{})
+	ctx := context.Background()
+
+	segments, err := s.Add(ctx, MatchPoint{Location: Coordinate{0.001, 0}, Timestamp: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, segments)
+	assert.Empty(t, queries)
+
+	segments, err = s.Add(ctx, MatchPoint{Location: Coordinate{0.002, 0}, Timestamp: 20, Radius: 5})
+	assert.NoError(t, err)
+	assert.Empty(t, segments)
+
+	q := <-queries
+	assert.Equal(t, "10;20", q.Get("timestamps"))
+	assert.Equal(t, "10.000000;5.000000", q.Get("radiuses"))
+	assert.Equal(t, "nodes", q.Get("annotations"))
+	assert.Equal(t, "false", q.Get("overview"))
+
+	segments, err = s.Add(ctx, MatchPoint{Location: Coordinate{0.003, 0}, Timestamp: 30})
+	assert.NoError(t, err)
+	assert.Len(t, segments, 1)
+	assert.Equal(t, 0, segments[0].FromIndex)
+	assert.Equal(t, 1, segments[0].ToIndex)
+	assert.Equal(t, []uint64{1, 2}, segments[0].Nodes)
+	assert.InDelta(t, 111, segments[0].Distance, 1)
+	assert.Equal(t, float32(0.9), segments[0].Confidence)
+	assert.Equal(t, 2, s.Pending())
+
+	// Ambiguous points are committed once a later point is unambiguous.
+	segments, err = s.Add(ctx, MatchPoint{Location: Coordinate{0.004, 0.0001}, Timestamp: 40})
+	assert.NoError(t, err)
+	assert.Len(t, segments, 1)
+
+	segments, err = s.Add(ctx, MatchPoint{Location: Coordinate{0.005, 0.0001}, Timestamp: 50})
+	assert.NoError(t, err)
+	assert.Empty(t, segments)
+	assert.Equal(t, 3, s.Pending())
+
+	segments, err = s.Add(ctx, MatchPoint{Location: Coordinate{0.006, 0}, Timestamp: 60})
+	assert.NoError(t, err)
+	assert.Empty(t, segments)
+
+	q = <-queries
+	for len(queries) > 0 {
+		q = <-queries
+	}
+	assert.Equal(t, "30;40;50;60", q.Get("timestamps"))
+
+	segments, err = s.Add(ctx, MatchPoint{Location: Coordinate{0.007, 0}, Timestamp: 70})
+	assert.NoError(t, err)
+	assert.Len(t, segments, 3)
+	assert.Equal(t, 2, segments[0].FromIndex)
+	assert.Equal(t, 5, segments[2].ToIndex)
+	assert.Equal(t, []uint64{4, 5, 6}, testMatchNodes(segments))
+
+	// Outliers are skipped and the remaining points are flushed.
+	segments, err = s.Add(ctx, MatchPoint{Location: Coordinate{0.008, -0.01}, Timestamp: 80})
+	assert.NoError(t, err)
+	assert.Len(t, segments, 1)
+
+	_, err = s.Add(ctx, MatchPoint{Location: Coordinate{0.009, 0}, Timestamp: 80})
+	assert.ErrorIs(t, err, ErrInvalidMatchPoint)
+
+	_, err = s.Add(ctx, MatchPoint{Location: Coordinate{0.009, 0.0001}, Timestamp: 90})
+	assert.NoError(t, err)
+
+	segments, err = s.Flush(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, segments, 1)
+	assert.Equal(t, 6, segments[0].FromIndex)
+	assert.Equal(t, 8, segments[0].ToIndex)
+	assert.Equal(t, []uint64{9}, segments[0].Nodes)
+	assert.Equal(t, 1, s.Pending())
+}
+
+func TestMatchSession_Window(t *testing.T) {
+	srv := newMatchTestServer(t, nil)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	s := NewMatchSession(osrm, ProfileCar, MatchSessionConfig{WindowSize: 3})
+	ctx := context.Background()
+
+	// The oldest leg of a full window is committed even if it's ambiguous.
+	var segments []MatchedSegment
+	for i := 1; i <= 5; i++ {
+		added, err := s.Add(ctx, MatchPoint{Location: Coordinate{float64(i) / 1000, 0.0001}, Timestamp: int64(i)})
+		assert.NoError(t, err)
+		segments = append(segments, added...)
+		assert.LessOrEqual(t, s.Pending(), 3)
+	}
+	assert.Equal(t, []uint64{1, 2, 3}, testMatchNodes(segments))
+
+	// Points that can't be matched are dropped once they leave the window.
+	for i := 6; i <= 8; i++ {
+		added, err := s.Add(ctx, MatchPoint{Location: Coordinate{-float64(i) / 1000, 0}, Timestamp: int64(i)})
+		assert.NoError(t, err)
+		assert.Empty(t, added)
+	}
+	assert.Equal(t, 3, s.Pending())
+}
+
+func TestMatchSession_Concurrent(t *testing.T) {
+	srv := newMatchTestServer(t, nil)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for v := 0; v < 20; v++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s := NewMatchSession(osrm, ProfileCar, MatchSessionConfig{})
+
+			var segments []MatchedSegment
+			for i := 1; i <= 5; i++ {
+				added, err := s.Add(context.Background(), MatchPoint{Location: Coordinate{float64(i) / 1000, 0}, Timestamp: int64(i)})
+				assert.NoError(t, err)
+				segments = append(segments, added...)
+			}
+
+			flushed, err := s.Flush(context.Background())
+			assert.NoError(t, err)
+			segments = append(segments, flushed...)
+
+			assert.Equal(t, []uint64{1, 2, 3, 4, 5}, testMatchNodes(segments), "vehicle %d", v)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTrimmedNodes(t *testing.T) {
+	assert.Equal(t, 0, trimmedNodes(nil, []uint64{1, 2}))
+	assert.Equal(t, 1, trimmedNodes([]uint64{1, 2}, []uint64{2, 3}))
+	assert.Equal(t, 2, trimmedNodes([]uint64{1, 2, 3}, []uint64{2, 3, 4}))
+	assert.Equal(t, 0, trimmedNodes([]uint64{1, 2}, []uint64{3, 4}))
+}
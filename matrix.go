@@ -0,0 +1,435 @@
+package gosrm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidMatrix is returned when a matrix or its encoding is not valid.
+var ErrInvalidMatrix = errors.New("gosrm: invalid matrix")
+
+// matrixMagic is the magic number of the binary matrix format.
+const matrixMagic string = "GOSRMMAT"
+
+// matrixVersion is the version of the binary matrix format.
+const matrixVersion uint16 = 1
+
+// MaxMatrixValues is the max number of values of the matrices read by ReadMatrixBinary and ReadMatrixSparse,
+// so untrusted inputs can't allocate more than 1 GiB of values. It's a matrix of 16384 by 16384 locations.
+const MaxMatrixValues int = 1 << 28
+
+// matrixChunkSize is the number of values binary matrices are read in at once.
+const matrixChunkSize int = 1 << 14
+
+// Matrix is a durations or distances matrix of table service with its metadata.
+// It's used to export matrices to other tools and import them back.
+type Matrix struct {
+	// Kind is the kind of the matrix, durations or distances.
+	Kind TableMatrix
+
+	// Profile is the profile the matrix is computed with.
+	Profile Profile
+
+	// DataVersion is the data version of OSRM the matrix is computed with.
+	DataVersion time.Time
+
+	// RowIDs are optional IDs of the rows, RowIDs[i] belongs to Values[i].
+	RowIDs []string
+
+	// ColumnIDs are optional IDs of the columns, ColumnIDs[j] belongs to Values[i][j].
+	ColumnIDs []string
+
+	// Values of the matrix in row-major order, values of the pairs that can't be routed are positive infinity.
+	Values [][]float32
+}
+
+// Matrix returns the durations or distances matrix of the response with its data version.
+func (res TableResponse) Matrix(kind TableMatrix) Matrix {
+	m := Matrix{Kind: kind, DataVersion: res.DataVersion, Values: res.Durations}
+	if kind == TableDistances {
+		m.Values = res.Distances
+	}
+	return m
+}
+
+// Size returns the number of rows and columns of the matrix.
+func (m Matrix) Size() (rows, cols int) {
+	rows = len(m.Values)
+	if rows > 0 {
+		cols = len(m.Values[0])
+	} else {
+		cols = len(m.ColumnIDs)
+	}
+	return rows, cols
+}
+
+// validate returns ErrInvalidMatrix if rows of the matrix don't have the same length or IDs don't match the values.
+func (m Matrix) validate() error {
+	rows, cols := m.Size()
+	if rows > 0 && cols == 0 {
+		return fmt.Errorf("%w: %d rows without columns", ErrInvalidMatrix, rows)
+	}
+	for _, row := range m.Values {
+		if len(row) != cols {
+			return fmt.Errorf("%w: rows have different lengths", ErrInvalidMatrix)
+		}
+	}
+	if m.RowIDs != nil && len(m.RowIDs) != rows {
+		return fmt.Errorf("%w: %d row IDs for %d rows", ErrInvalidMatrix, len(m.RowIDs), rows)
+	}
+	if m.ColumnIDs != nil && len(m.ColumnIDs) != cols {
+		return fmt.Errorf("%w: %d column IDs for %d columns", ErrInvalidMatrix, len(m.ColumnIDs), cols)
+	}
+	return nil
+}
+
+// WriteMatrixCSV writes the values of the matrix as CSV, a record per row.
+// If the matrix has column IDs the first record is a header of the column IDs.
+// If it has row IDs the first cell of each record is the row ID, the first cell of the header is empty in this case.
+// Values of the pairs that can't be routed are written as empty cells.
+func WriteMatrixCSV(w io.Writer, m Matrix) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+
+	if m.ColumnIDs != nil {
+		header := m.ColumnIDs
+		if m.RowIDs != nil {
+			header = append([]string{""}, m.ColumnIDs...)
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+
+	for i, row := range m.Values {
+		record := make([]string, 0, len(row)+1)
+		if m.RowIDs != nil {
+			record = append(record, m.RowIDs[i])
+		}
+		for _, v := range row {
+			record = append(record, formatMatrixValue(v))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadMatrixCSV reads the values of a matrix written by WriteMatrixCSV.
+// rowIDs and columnIDs report whether the CSV has row IDs and a header of column IDs.
+// Empty cells are read as positive infinity.
+func ReadMatrixCSV(r io.Reader, rowIDs, columnIDs bool) (Matrix, error) {
+	cr := csv.NewReader(r)
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return Matrix{}, err
+	}
+
+	var m Matrix
+	if columnIDs {
+		if len(records) == 0 {
+			return Matrix{}, fmt.Errorf("%w: missing header", ErrInvalidMatrix)
+		}
+		m.ColumnIDs = records[0]
+		if rowIDs {
+			m.ColumnIDs = m.ColumnIDs[1:]
+		}
+		records = records[1:]
+	}
+
+	for _, record := range records {
+		if rowIDs {
+			m.RowIDs = append(m.RowIDs, record[0])
+			record = record[1:]
+		}
+
+		row := make([]float32, len(record))
+		for j, s := range record {
+			if row[j], err = parseMatrixValue(s); err != nil {
+				return Matrix{}, err
+			}
+		}
+		m.Values = append(m.Values, row)
+	}
+
+	return m, m.validate()
+}
+
+// WriteMatrixBinary writes the matrix in a compact little-endian binary format. It consists of:
+//
+//   - The magic number "GOSRMMAT" and the version of the format as uint16, currently 1.
+//   - Kind and profile of the matrix, each as a uint16 length followed by the bytes of the string.
+//   - DataVersion as int64 Unix seconds, 0 if it's unknown.
+//   - Number of rows and columns as uint32.
+//   - Values in row-major order as float32, pairs that can't be routed are positive infinity.
+//
+// IDs of the matrix aren't written.
+func WriteMatrixBinary(w io.Writer, m Matrix) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+
+	var dataVersion int64
+	if !m.DataVersion.IsZero() {
+		dataVersion = m.DataVersion.Unix()
+	}
+	rows, cols := m.Size()
+
+	header := []byte(matrixMagic)
+	header = binary.LittleEndian.AppendUint16(header, matrixVersion)
+	header = appendMatrixString(header, string(m.Kind))
+	header = appendMatrixString(header, string(m.Profile))
+	header = binary.LittleEndian.AppendUint64(header, uint64(dataVersion))
+	header = binary.LittleEndian.AppendUint32(header, uint32(rows))
+	header = binary.LittleEndian.AppendUint32(header, uint32(cols))
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 0, 4*cols)
+	for _, row := range m.Values {
+		buf = buf[:0]
+		for _, v := range row {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+		}
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// ReadMatrixBinary reads a matrix written by WriteMatrixBinary.
+func ReadMatrixBinary(r io.Reader) (Matrix, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(matrixMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return Matrix{}, err
+	}
+	if string(magic) != matrixMagic {
+		return Matrix{}, fmt.Errorf("%w: unknown format", ErrInvalidMatrix)
+	}
+
+	var version uint16
+	if err := binary.Read(br, binary.LittleEndian, &version); err != nil {
+		return Matrix{}, err
+	}
+	if version != matrixVersion {
+		return Matrix{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidMatrix, version)
+	}
+
+	kind, err := readMatrixString(br)
+	if err != nil {
+		return Matrix{}, err
+	}
+	profile, err := readMatrixString(br)
+	if err != nil {
+		return Matrix{}, err
+	}
+
+	var header struct {
+		DataVersion int64
+		Rows, Cols  uint32
+	}
+	if err := binary.Read(br, binary.LittleEndian, &header); err != nil {
+		return Matrix{}, err
+	}
+
+	if err := checkMatrixSize(int(header.Rows), int(header.Cols)); err != nil {
+		return Matrix{}, err
+	}
+
+	m := Matrix{Kind: TableMatrix(kind), Profile: Profile(profile)}
+	if header.DataVersion != 0 {
+		m.DataVersion = time.Unix(header.DataVersion, 0).UTC()
+	}
+
+	// Values are read in chunks and allocated as they're read, so truncated inputs don't allocate the whole matrix.
+	for range header.Rows {
+		row, err := readMatrixRow(br, int(header.Cols))
+		if err != nil {
+			return Matrix{}, err
+		}
+		m.Values = append(m.Values, row)
+	}
+
+	return m, nil
+}
+
+// checkMatrixSize returns ErrInvalidMatrix if a matrix of rows by cols can't be read,
+// i.e. it has more than MaxMatrixValues values or rows without values.
+func checkMatrixSize(rows, cols int) error {
+	if rows > 0 && cols == 0 {
+		return fmt.Errorf("%w: %d rows without columns", ErrInvalidMatrix, rows)
+	}
+	if rows > MaxMatrixValues || (rows > 0 && cols > MaxMatrixValues/rows) {
+		return fmt.Errorf("%w: %dx%d matrix has more than %d values", ErrInvalidMatrix, rows, cols, MaxMatrixValues)
+	}
+	return nil
+}
+
+// readMatrixRow reads a row of cols values of the binary matrix format in chunks of matrixChunkSize values.
+func readMatrixRow(r io.Reader, cols int) ([]float32, error) {
+	row := make([]float32, 0, min(cols, matrixChunkSize))
+	for len(row) < cols {
+		n := len(row)
+		row = append(row, make([]float32, min(cols-n, matrixChunkSize))...)
+		if err := binary.Read(r, binary.LittleEndian, row[n:]); err != nil {
+			return nil, err
+		}
+	}
+	return row, nil
+}
+
+// appendMatrixString appends a string of the binary matrix format.
+func appendMatrixString(b []byte, s string) []byte {
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// readMatrixString reads a string of the binary matrix format.
+func readMatrixString(r io.Reader) (string, error) {
+	var n uint16
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", err
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// WriteMatrixSparse writes the values of the matrix as CSV triplets of row index, column index and value,
+// with a row,column,value header. Values of the pairs that can't be routed are omitted,
+// so it's suited for partial matrices.
+func WriteMatrixSparse(w io.Writer, m Matrix) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"row", "column", "value"}); err != nil {
+		return err
+	}
+
+	for i, row := range m.Values {
+		for j, v := range row {
+			if math.IsInf(float64(v), 1) {
+				continue
+			}
+			if err := cw.Write([]string{strconv.Itoa(i), strconv.Itoa(j), formatMatrixValue(v)}); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadMatrixSparse reads a matrix of rows by cols written by WriteMatrixSparse, missing values are positive infinity.
+// If rows or cols is not positive, it's the max index of the triplets plus one.
+// Matrices with more than MaxMatrixValues values are not valid.
+func ReadMatrixSparse(r io.Reader, rows, cols int) (Matrix, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return Matrix{}, err
+	}
+	if len(records) == 0 {
+		return Matrix{}, fmt.Errorf("%w: missing header", ErrInvalidMatrix)
+	}
+
+	type triplet struct {
+		i, j int
+		v    float32
+	}
+
+	triplets := make([]triplet, len(records)-1)
+	inferRows, inferCols := rows <= 0, cols <= 0
+	for k, record := range records[1:] {
+		t := &triplets[k]
+		if t.i, err = strconv.Atoi(record[0]); err == nil {
+			t.j, err = strconv.Atoi(record[1])
+		}
+		if err == nil {
+			t.v, err = parseMatrixValue(record[2])
+		}
+		if err != nil || t.i < 0 || t.j < 0 {
+			return Matrix{}, fmt.Errorf("%w: invalid triplet %v", ErrInvalidMatrix, record)
+		}
+
+		if inferRows {
+			rows = max(rows, t.i+1)
+		}
+		if inferCols {
+			cols = max(cols, t.j+1)
+		}
+		if t.i >= rows || t.j >= cols {
+			return Matrix{}, fmt.Errorf("%w: triplet %v out of range", ErrInvalidMatrix, record)
+		}
+	}
+
+	if err := checkMatrixSize(rows, cols); err != nil {
+		return Matrix{}, err
+	}
+
+	inf := float32(math.Inf(1))
+	m := Matrix{Values: make([][]float32, rows)}
+	for i := range m.Values {
+		m.Values[i] = make([]float32, cols)
+		for j := range m.Values[i] {
+			m.Values[i][j] = inf
+		}
+	}
+	for _, t := range triplets {
+		m.Values[t.i][t.j] = t.v
+	}
+
+	return m, nil
+}
+
+// formatMatrixValue formats a value of a matrix, infinity is formatted as an empty string.
+func formatMatrixValue(v float32) string {
+	if math.IsInf(float64(v), 1) {
+		return ""
+	}
+	return strconv.FormatFloat(float64(v), 'f', -1, 32)
+}
+
+// parseMatrixValue parses a value formatted by formatMatrixValue.
+func parseMatrixValue(s string) (float32, error) {
+	if s == "" {
+		return float32(math.Inf(1)), nil
+	}
+
+	v, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid value %q", ErrInvalidMatrix, s)
+	}
+
+	return float32(v), nil
+}
@@ -0,0 +1,161 @@
+package gosrm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testMatrix returns a matrix with an unroutable pair.
+func testMatrix() Matrix {
+	return Matrix{
+		Kind:        TableDurations,
+		Profile:     ProfileCar,
+		DataVersion: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		RowIDs:      []string{"a", "b"},
+		ColumnIDs:   []string{"x", "y", "z"},
+		Values:      [][]float32{{0, 1.5, float32(math.Inf(1))}, {10, 0, 250.25}},
+	}
+}
+
+func TestTableResponse_Matrix(t *testing.T) {
+	res := TableResponse{Durations: [][]float32{{1}}, Distances: [][]float32{{2}}}
+	res.DataVersion = time.Unix(100, 0)
+
+	m := res.Matrix(TableDistances)
+	assert.Equal(t, TableDistances, m.Kind)
+	assert.Equal(t, res.DataVersion, m.DataVersion)
+	assert.Equal(t, res.Distances, m.Values)
+	assert.Equal(t, res.Durations, res.Matrix(TableDurations).Values)
+
+	rows, cols := m.Size()
+	assert.Equal(t, 1, rows)
+	assert.Equal(t, 1, cols)
+}
+
+func TestMatrixCSV(t *testing.T) {
+	m := testMatrix()
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteMatrixCSV(&buf, m))
+	assert.Equal(t, ",x,y,z\na,0,1.5,\nb,10,0,250.25\n", buf.String())
+
+	read, err := ReadMatrixCSV(&buf, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, m.RowIDs, read.RowIDs)
+	assert.Equal(t, m.ColumnIDs, read.ColumnIDs)
+	assert.Equal(t, m.Values, read.Values)
+
+	m.RowIDs = nil
+	buf.Reset()
+	assert.NoError(t, WriteMatrixCSV(&buf, m))
+	assert.Equal(t, "x,y,z\n0,1.5,\n10,0,250.25\n", buf.String())
+
+	m.ColumnIDs = nil
+	buf.Reset()
+	assert.NoError(t, WriteMatrixCSV(&buf, m))
+	read, err = ReadMatrixCSV(&buf, false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, m.Values, read.Values)
+
+	m.RowIDs = []string{"a"}
+	assert.ErrorIs(t, WriteMatrixCSV(&buf, m), ErrInvalidMatrix)
+
+	_, err = ReadMatrixCSV(strings.NewReader("0,a\n"), false, false)
+	assert.ErrorIs(t, err, ErrInvalidMatrix)
+	_, err = ReadMatrixCSV(strings.NewReader(""), false, true)
+	assert.ErrorIs(t, err, ErrInvalidMatrix)
+}
+
+func TestMatrixBinary(t *testing.T) {
+	m := testMatrix()
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteMatrixBinary(&buf, m))
+	assert.Equal(t, 8+2+2+9+2+3+8+4+4+6*4, buf.Len())
+	assert.Equal(t, "GOSRMMAT", buf.String()[:8])
+
+	data := buf.Bytes()
+	read, err := ReadMatrixBinary(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, m.Kind, read.Kind)
+	assert.Equal(t, m.Profile, read.Profile)
+	assert.Equal(t, m.DataVersion, read.DataVersion)
+	assert.Equal(t, m.Values, read.Values)
+	assert.Nil(t, read.RowIDs)
+
+	_, err = ReadMatrixBinary(bytes.NewReader(data[:len(data)-1]))
+	assert.Error(t, err)
+	_, err = ReadMatrixBinary(strings.NewReader("NOTAMATRIX"))
+	assert.ErrorIs(t, err, ErrInvalidMatrix)
+
+	// Unknown data versions and empty matrices are supported.
+	buf.Reset()
+	assert.NoError(t, WriteMatrixBinary(&buf, Matrix{Kind: TableDistances}))
+	read, err = ReadMatrixBinary(&buf)
+	assert.NoError(t, err)
+	assert.True(t, read.DataVersion.IsZero())
+	assert.Empty(t, read.Values)
+
+	// Headers of untrusted inputs don't allocate more than they contain.
+	header := func(rows, cols uint32) []byte {
+		b := []byte(matrixMagic)
+		b = binary.LittleEndian.AppendUint16(b, matrixVersion)
+		b = appendMatrixString(b, string(TableDurations))
+		b = appendMatrixString(b, string(ProfileCar))
+		b = binary.LittleEndian.AppendUint64(b, 0)
+		b = binary.LittleEndian.AppendUint32(b, rows)
+		return binary.LittleEndian.AppendUint32(b, cols)
+	}
+
+	_, err = ReadMatrixBinary(bytes.NewReader(header(math.MaxUint32, math.MaxUint32)))
+	assert.ErrorIs(t, err, ErrInvalidMatrix)
+	_, err = ReadMatrixBinary(bytes.NewReader(header(math.MaxUint32, 0)))
+	assert.ErrorIs(t, err, ErrInvalidMatrix)
+	_, err = ReadMatrixBinary(bytes.NewReader(header(uint32(MaxMatrixValues)+1, 1)))
+	assert.ErrorIs(t, err, ErrInvalidMatrix)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err = ReadMatrixBinary(bytes.NewReader(header(1, uint32(MaxMatrixValues))))
+	runtime.ReadMemStats(&after)
+	assert.Error(t, err)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+
+	read, err = ReadMatrixBinary(bytes.NewReader(append(header(1, uint32(matrixChunkSize+1)), make([]byte, 4*(matrixChunkSize+1))...)))
+	assert.NoError(t, err)
+	assert.Len(t, read.Values[0], matrixChunkSize+1)
+}
+
+func TestMatrixSparse(t *testing.T) {
+	m := testMatrix()
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteMatrixSparse(&buf, m))
+	assert.Equal(t, "row,column,value\n0,0,0\n0,1,1.5\n1,0,10\n1,1,0\n1,2,250.25\n", buf.String())
+
+	read, err := ReadMatrixSparse(strings.NewReader(buf.String()), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, m.Values, read.Values)
+
+	read, err = ReadMatrixSparse(strings.NewReader("row,column,value\n1,0,5\n"), 3, 2)
+	assert.NoError(t, err)
+	inf := float32(math.Inf(1))
+	assert.Equal(t, [][]float32{{inf, inf}, {5, inf}, {inf, inf}}, read.Values)
+
+	_, err = ReadMatrixSparse(strings.NewReader("row,column,value\n100000,100000,5\n"), 0, 0)
+	assert.ErrorIs(t, err, ErrInvalidMatrix)
+	_, err = ReadMatrixSparse(strings.NewReader("row,column,value\n"), math.MaxInt32, 0)
+	assert.ErrorIs(t, err, ErrInvalidMatrix)
+
+	for _, data := range []string{"", "row,column,value\n3,0,5\n", "row,column,value\n-1,0,5\n", "row,column,value\n0,0,a\n"} {
+		_, err = ReadMatrixSparse(strings.NewReader(data), 3, 2)
+		assert.ErrorIs(t, err, ErrInvalidMatrix, data)
+	}
+}
@@ -0,0 +1,352 @@
+package gosrm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NavigationEventType is the type of a navigation event.
+type NavigationEventType string
+
+const (
+	// EventStepAdvanced is emitted when the current step changes.
+	EventStepAdvanced NavigationEventType = "step_advanced"
+
+	// EventApproachingManeuver is emitted once per step when the maneuver at its end is within the approach distance.
+	EventApproachingManeuver NavigationEventType = "approaching_maneuver"
+
+	// EventWaypointArrived is emitted once per waypoint when it's within the arrival distance.
+	EventWaypointArrived NavigationEventType = "waypoint_arrived"
+
+	// EventOffRoute is emitted when the position goes off-route.
+	EventOffRoute NavigationEventType = "off_route"
+
+	// EventRerouted is emitted when a new route from an off-route position is active.
+	EventRerouted NavigationEventType = "rerouted"
+
+	// EventRerouteFailed is emitted when requesting a new route fails.
+	EventRerouteFailed NavigationEventType = "reroute_failed"
+)
+
+const (
+	// DefaultApproachDistance is the default distance to a maneuver when it's approaching, in meters.
+	DefaultApproachDistance float64 = 200
+
+	// DefaultArrivalDistance is the default distance to a waypoint when it's arrived at, in meters.
+	DefaultArrivalDistance float64 = 20
+
+	// DefaultRerouteInterval is the default min interval between reroutes.
+	DefaultRerouteInterval time.Duration = 5 * time.Second
+
+	// DefaultRerouteBearingRange is the default range of the bearing of reroute requests, in degrees.
+	DefaultRerouteBearingRange uint16 = 45
+)
+
+type (
+	// NavigationConfig is the config of a navigation session.
+	NavigationConfig struct {
+		// Tracker is the config of the route tracker.
+		Tracker TrackerConfig
+
+		// ApproachDistance is the distance to a maneuver when it's approaching, in meters.
+		// Defaults to DefaultApproachDistance.
+		ApproachDistance float64
+
+		// ArrivalDistance is the distance to a waypoint when it's arrived at, in meters.
+		// Defaults to DefaultArrivalDistance.
+		ArrivalDistance float64
+
+		// RerouteInterval is the min interval between reroutes. Defaults to DefaultRerouteInterval.
+		RerouteInterval time.Duration
+
+		// RerouteBearingRange is the range of the bearing of reroute requests, in degrees.
+		// Defaults to DefaultRerouteBearingRange.
+		RerouteBearingRange uint16
+
+		// RouteOptions are passed to route service when rerouting.
+		// They should request the same geometries, overview and steps as the initial route so it can be tracked.
+		RouteOptions []Option
+	}
+
+	// NavigationEvent is an event of a navigation session.
+	NavigationEvent struct {
+		// Type of the event.
+		Type NavigationEventType
+
+		// Progress is the progress of the update that emitted the event.
+		Progress RouteProgress
+
+		// LegIndex is the index of the leg of the event in the active route.
+		// It's the new leg for step advanced and the leg of the maneuver for approaching maneuver.
+		LegIndex int
+
+		// StepIndex is the index of the step of the event in its leg.
+		// It's the new step for step advanced and the step of the maneuver for approaching maneuver.
+		StepIndex int
+
+		// WaypointIndex is the index of the arrived waypoint in the coordinates of the session request.
+		WaypointIndex int
+
+		// Err is the error of reroute failed events.
+		Err error
+	}
+
+	// NavigationSession navigates a route with position updates.
+	// It emits events of the progress and requests a new route from the current position when it goes off-route.
+	// Rerouting is rate-limited and runs in the background, new routes are activated by the next update.
+	// Reroutes which are stale because the position is back on the route or a newer reroute is started are canceled.
+	// It's safe for concurrent use.
+	NavigationSession[T GeometryType] struct {
+		osrm   OSRMClient
+		req    Request
+		config NavigationConfig
+
+		ctx    context.Context
+		cancel context.CancelFunc
+
+		mu sync.Mutex
+
+		// res is the active route response, its coordinates start at the waypoint at index offset of the request.
+		res     *RouteResponse[T]
+		offset  int
+		tracker *RouteTracker
+
+		leg, step    int
+		approached   bool
+		arrived      map[int]bool
+		offRoute     bool
+		lastReroute  time.Time
+		generation   int
+		cancelRoute  context.CancelFunc
+		pending      *RouteResponse[T]
+		pendingErr   error
+		pendingReady bool
+		pendingAt    int
+	}
+)
+
+// NewNavigationSession returns a new navigation session of the first route of a response of the request.
+// The route needs a geometry that can be tracked, see NewRouteTracker.
+// The session is bound to ctx, reroutes are canceled when it's done or the session is closed.
+func NewNavigationSession[T GeometryType](ctx context.Context, osrm OSRMClient, req Request, res *RouteResponse[T], config NavigationConfig) (*NavigationSession[T], error) {
+	if config.ApproachDistance <= 0 {
+		config.ApproachDistance = DefaultApproachDistance
+	}
+	if config.ArrivalDistance <= 0 {
+		config.ArrivalDistance = DefaultArrivalDistance
+	}
+	if config.RerouteInterval <= 0 {
+		config.RerouteInterval = DefaultRerouteInterval
+	}
+	if config.RerouteBearingRange == 0 {
+		config.RerouteBearingRange = DefaultRerouteBearingRange
+	}
+
+	s := NavigationSession[T]{osrm: osrm, req: req, config: config, arrived: make(map[int]bool)}
+	if err := s.activate(res, 0); err != nil {
+		return nil, err
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	return &s, nil
+}
+
+// Close cancels the running reroute, the session shouldn't be used after it's closed.
+func (s *NavigationSession[T]) Close() {
+	s.cancel()
+}
+
+// Route returns the active route response.
+func (s *NavigationSession[T]) Route() *RouteResponse[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.res
+}
+
+// Rerouting reports whether a reroute is running.
+func (s *NavigationSession[T]) Rerouting() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cancelRoute != nil
+}
+
+// Update updates the position and returns the emitted events.
+// heading is the direction of travel in degrees clockwise from north, a negative heading is unknown.
+// A new route that is ready is activated before the position is tracked.
+func (s *NavigationSession[T]) Update(location Coordinate, heading float64) []NavigationEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []NavigationEvent
+
+	if s.pendingReady {
+		events = append(events, s.activatePending())
+	}
+
+	p := s.tracker.Update(location, heading)
+	event := func(t NavigationEventType) NavigationEvent {
+		return NavigationEvent{Type: t, Progress: p, LegIndex: p.LegIndex, StepIndex: p.StepIndex}
+	}
+
+	if p.OffRoute {
+		if !s.offRoute {
+			events = append(events, event(EventOffRoute))
+		}
+		s.offRoute = true
+
+		if time.Since(s.lastReroute) >= s.config.RerouteInterval {
+			s.reroute(location, heading, p.LegIndex)
+		}
+
+		return events
+	}
+
+	s.offRoute = false
+	s.stopReroute()
+
+	if p.LegIndex != s.leg || p.StepIndex != s.step {
+		s.leg, s.step, s.approached = p.LegIndex, p.StepIndex, false
+		events = append(events, event(EventStepAdvanced))
+	}
+
+	var steps int
+	if legs := s.res.Routes[0].Legs; p.LegIndex < len(legs) {
+		steps = len(legs[p.LegIndex].Steps)
+	}
+
+	if !s.approached && p.StepIndex+1 < steps && p.StepDistanceRemaining <= s.config.ApproachDistance {
+		s.approached = true
+		e := event(EventApproachingManeuver)
+		e.StepIndex++
+		events = append(events, e)
+	}
+
+	// Waypoints of passed legs are arrived at even if no update was close enough to them.
+	for waypoint := s.offset + 1; waypoint <= s.offset+p.LegIndex+1; waypoint++ {
+		passed := waypoint <= s.offset+p.LegIndex
+		if s.arrived[waypoint] || (!passed && p.LegDistanceRemaining > s.config.ArrivalDistance) {
+			continue
+		}
+
+		s.arrived[waypoint] = true
+		e := event(EventWaypointArrived)
+		e.WaypointIndex = waypoint
+		events = append(events, e)
+	}
+
+	return events
+}
+
+// activate activates a route response whose coordinates start at the waypoint at index offset of the request.
+// The caller must hold the lock, except in the constructor.
+func (s *NavigationSession[T]) activate(res *RouteResponse[T], offset int) error {
+	if len(res.Routes) == 0 {
+		return fmt.Errorf("%w: no routes", ErrEmptyGeometry)
+	}
+
+	tracker, err := NewRouteTracker(res.Routes[0], s.config.Tracker)
+	if err != nil {
+		return err
+	}
+
+	s.res, s.offset, s.tracker = res, offset, tracker
+	s.leg, s.step, s.approached, s.offRoute = 0, 0, false, false
+
+	return nil
+}
+
+// activatePending activates the result of the finished reroute and returns its event.
+// The caller must hold the lock.
+func (s *NavigationSession[T]) activatePending() NavigationEvent {
+	res, err, offset := s.pending, s.pendingErr, s.pendingAt
+	s.pending, s.pendingErr, s.pendingReady = nil, nil, false
+
+	if err == nil {
+		err = s.activate(res, offset)
+	}
+	if err != nil {
+		return NavigationEvent{Type: EventRerouteFailed, Err: err}
+	}
+
+	return NavigationEvent{Type: EventRerouted}
+}
+
+// reroute starts requesting a new route from the location to the remaining waypoints of the leg.
+// The running reroute is canceled. The caller must hold the lock.
+func (s *NavigationSession[T]) reroute(location Coordinate, heading float64, leg int) {
+	s.stopReroute()
+
+	next := s.offset + leg + 1
+	if next >= len(s.req.Coordinates) {
+		next = len(s.req.Coordinates) - 1
+	}
+
+	req := Request{Profile: s.req.Profile, Coordinates: append([]Coordinate{location}, s.req.Coordinates[next:]...)}
+	if len(s.req.IDs) == len(s.req.Coordinates) {
+		req.IDs = append([]string{""}, s.req.IDs[next:]...)
+	}
+
+	opts := append([]Option{}, s.config.RouteOptions...)
+
+	// Bearings and hints of the current location are empty, so only the heading and the remaining waypoints are set.
+	// The remaining waypoints have a range of 180 degrees, i.e. any direction.
+	if heading >= 0 {
+		bearings := make([]Bearing, len(req.Coordinates))
+		for i := range bearings {
+			bearings[i] = Bearing{Value: 0, Range: 180}
+		}
+		bearings[0] = Bearing{Value: uint16(heading) % 360, Range: s.config.RerouteBearingRange}
+		opts = append(opts, WithBearings(bearings))
+	}
+
+	hints := make([]string, len(req.Coordinates))
+	for i := 1; i < len(hints); i++ {
+		if wp := next + i - 1 - s.offset; wp < len(s.res.Waypoints) {
+			hints[i] = s.res.Waypoints[wp].Hint
+		}
+	}
+	if strings.Join(hints, "") != "" {
+		opts = append(opts, WithHints(hints))
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.cancelRoute = cancel
+	s.lastReroute = time.Now()
+	s.generation++
+	generation := s.generation
+
+	go func() {
+		defer cancel()
+
+		res, err := Route[T](ctx, s.osrm, req, opts...)
+		if err == nil {
+			err = res.Err()
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		// A newer reroute was started or the position is back on the route.
+		if generation != s.generation {
+			return
+		}
+
+		s.cancelRoute = nil
+		s.pending, s.pendingErr, s.pendingAt, s.pendingReady = res, err, next-1, true
+	}()
+}
+
+// stopReroute cancels the running reroute. The caller must hold the lock.
+func (s *NavigationSession[T]) stopReroute() {
+	if s.cancelRoute != nil {
+		s.cancelRoute()
+		s.cancelRoute = nil
+		s.generation++
+	}
+}
@@ -0,0 +1,186 @@
+package gosrm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testNavigationSession returns a navigation session of testTrackedRoute.
+func testNavigationSession(t *testing.T, srv *httptest.Server, config NavigationConfig) *NavigationSession[LineString] {
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	req := Request{Profile: ProfileCar, Coordinates: []Coordinate{{0, 0}, {0.02, 0}, {0.02, 0.01}}}
+	res := &RouteResponse[LineString]{
+		Response:  Response{Code: CodeOK},
+		Routes:    []RouteType[LineString]{testTrackedRoute()},
+		Waypoints: []Waypoint{{Hint: "h0"}, {Hint: "h1"}, {Hint: "h2"}},
+	}
+
+	config.RouteOptions = []Option{WithGeometries(GeometryGeoJSON), WithOverview(OverviewFull)}
+
+	s, err := NewNavigationSession(context.Background(), osrm, req, res, config)
+	assert.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// eventTypes returns the types of events.
+func eventTypes(events []NavigationEvent) []NavigationEventType {
+	var types []NavigationEventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestNavigationSession_Update(t *testing.T) {
+	var mu sync.Mutex
+	var queries []url.Values
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query())
+		mu.Unlock()
+
+		// The new route goes through the requested coordinates, with a leg between each pair.
+		coordinates := parseTestCoordinates(r.URL.Path)
+		route := RouteType[LineString]{Geometry: LineString{Type: "LineString", Coordinates: coordinates}}
+		for i := 1; i < len(coordinates); i++ {
+			route.Legs = append(route.Legs, RouteLeg[LineString]{Distance: float32(HaversineDistance(coordinates[i-1], coordinates[i]))})
+		}
+
+		assert.NoError(t, json.NewEncoder(w).Encode(RouteResponse[LineString]{Response: Response{Code: CodeOK}, Routes: []RouteType[LineString]{route}}))
+	}))
+	defer srv.Close()
+
+	s := testNavigationSession(t, srv, NavigationConfig{})
+
+	assert.Empty(t, s.Update(Coordinate{0.005, 0}, 90))
+
+	events := s.Update(Coordinate{0.0095, 0}, 90)
+	assert.Equal(t, []NavigationEventType{EventApproachingManeuver}, eventTypes(events))
+	assert.Equal(t, 0, events[0].LegIndex)
+	assert.Equal(t, 1, events[0].StepIndex)
+	assert.Empty(t, s.Update(Coordinate{0.0096, 0}, 90))
+
+	events = s.Update(Coordinate{0.0105, 0}, 90)
+	assert.Equal(t, []NavigationEventType{EventStepAdvanced}, eventTypes(events))
+	assert.Equal(t, 1, events[0].StepIndex)
+
+	events = s.Update(Coordinate{0.02, 0.0001}, 0)
+	assert.Equal(t, []NavigationEventType{EventStepAdvanced, EventWaypointArrived}, eventTypes(events))
+	assert.Equal(t, 1, events[0].LegIndex)
+	assert.Equal(t, 1, events[1].WaypointIndex)
+
+	// Going off-route starts a reroute from the current location to the remaining waypoints.
+	events = s.Update(Coordinate{0.01, 0.005}, 45)
+	assert.Equal(t, []NavigationEventType{EventOffRoute}, eventTypes(events))
+	assert.Eventually(t, func() bool { return !s.Rerouting() }, time.Second, time.Millisecond)
+
+	mu.Lock()
+	assert.Len(t, queries, 1)
+	assert.Equal(t, "45,45;0,180;0,180", queries[0].Get("bearings"))
+	assert.Equal(t, ";h1;h2", queries[0].Get("hints"))
+	assert.Equal(t, "geojson", queries[0].Get("geometries"))
+	mu.Unlock()
+
+	events = s.Update(Coordinate{0.0101, 0.005}, 90)
+	assert.Equal(t, []NavigationEventType{EventRerouted}, eventTypes(events))
+	assert.Equal(t, Coordinate{0.01, 0.005}, s.Route().Routes[0].Geometry.Coordinates[0])
+
+	// Waypoint indices are indices of the session request.
+	events = s.Update(Coordinate{0.02, 0.0099}, 0)
+	assert.Equal(t, []NavigationEventType{EventStepAdvanced, EventWaypointArrived}, eventTypes(events))
+	assert.Equal(t, 2, events[1].WaypointIndex)
+}
+
+func TestNavigationSession_Reroute(t *testing.T) {
+	received, canceled := make(chan struct{}), make(chan struct{})
+	var mu sync.Mutex
+	requests := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+
+		if n == 1 {
+			close(received)
+			<-r.Context().Done()
+			close(canceled)
+			return
+		}
+		w.Write([]byte(`{"code":"NoRoute","message":"Impossible route between points"}`))
+	}))
+	defer srv.Close()
+
+	s := testNavigationSession(t, srv, NavigationConfig{RerouteInterval: time.Millisecond})
+
+	// Stale reroutes are canceled when the position is back on the route.
+	s.Update(Coordinate{0.01, 0.005}, -1)
+	assert.True(t, s.Rerouting())
+	<-received
+	assert.Empty(t, s.Update(Coordinate{0.005, 0}, 90))
+	assert.False(t, s.Rerouting())
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("reroute was not canceled")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	assert.Equal(t, []NavigationEventType{EventOffRoute}, eventTypes(s.Update(Coordinate{0.01, 0.005}, -1)))
+	assert.Eventually(t, func() bool { return !s.Rerouting() }, time.Second, time.Millisecond)
+
+	events := s.Update(Coordinate{0.01, 0.005}, -1)
+	assert.Equal(t, EventRerouteFailed, events[0].Type)
+	assert.Equal(t, ResponseError{Code: CodeNoRoute, Message: "Impossible route between points"}, events[0].Err)
+
+	// The failed route is not activated.
+	assert.Equal(t, "h0", s.Route().Waypoints[0].Hint)
+}
+
+func TestNavigationSession_RerouteInterval(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.Write([]byte(`{"code":"NoRoute"}`))
+	}))
+	defer srv.Close()
+
+	s := testNavigationSession(t, srv, NavigationConfig{RerouteInterval: time.Hour})
+
+	s.Update(Coordinate{0.01, 0.005}, -1)
+	assert.Eventually(t, func() bool { return !s.Rerouting() }, time.Second, time.Millisecond)
+
+	assert.Equal(t, []NavigationEventType{EventRerouteFailed}, eventTypes(s.Update(Coordinate{0.01, 0.005}, -1)))
+	assert.False(t, s.Rerouting())
+
+	mu.Lock()
+	assert.Equal(t, 1, requests)
+	mu.Unlock()
+}
+
+func TestNewNavigationSession(t *testing.T) {
+	osrm, err := New("http://localhost:5000")
+	assert.NoError(t, err)
+
+	_, err = NewNavigationSession(context.Background(), osrm, Request{}, &RouteResponse[LineString]{}, NavigationConfig{})
+	assert.ErrorIs(t, err, ErrEmptyGeometry)
+}
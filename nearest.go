@@ -26,5 +26,10 @@ func Nearest(ctx context.Context, osrm OSRMClient, req Request, opts ...Option)
 		return nil, err
 	}
 
+	// Waypoints are sorted by distance, so the first one is the snapped input coordinate.
+	if len(res.Waypoints) > 0 {
+		osrm.updateHints(osrm.hintKeys(req), []Waypoint{res.Waypoints[0].Waypoint}, res.Response)
+	}
+
 	return &res, nil
 }
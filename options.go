@@ -62,7 +62,7 @@ func WithAnnotations(annotations Annotations) Option {
 
 // WithGeometries sets the returned route geometry format (influences overview and per step).
 // Can be used in route, match and trip services.
-func WithGeometries(geometry Geometry) Option {
+func WithGeometries(geometry GeometryFormat) Option {
 	return optionImpl(func(u *url.URL) {
 		setQueryParam(u, "geometries", string(geometry))
 	})
@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type (
+	// cache is an LRU cache of response bodies with a TTL.
+	// It's safe for concurrent use.
+	cache struct {
+		size int
+		ttl  time.Duration
+		now  func() time.Time
+
+		mu      sync.Mutex
+		entries map[string]*list.Element
+		order   *list.List
+	}
+
+	// cacheEntry is a cached response body.
+	cacheEntry struct {
+		key     string
+		body    []byte
+		expires time.Time
+	}
+)
+
+// newCache returns a new cache of at most size entries which expire after ttl.
+func newCache(size int, ttl time.Duration) *cache {
+	return &cache{size: size, ttl: ttl, now: time.Now, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// get returns the body of a key if it's cached and not expired.
+func (c *cache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*cacheEntry)
+	if c.now().After(entry.expires) {
+		c.order.Remove(e)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(e)
+	return entry.body, true
+}
+
+// set caches the body of a key, the least recently used entry is evicted if the cache is full.
+func (c *cache) set(key string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.ttl)
+	if e, ok := c.entries[key]; ok {
+		entry := e.Value.(*cacheEntry)
+		entry.body, entry.expires = body, expires
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, body: body, expires: expires})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// len returns the number of cached entries, including expired ones that weren't evicted yet.
+func (c *cache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := newCache(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.set("a", []byte("1"))
+	c.set("b", []byte("2"))
+
+	// a is used more recently than b, so b is evicted.
+	body, ok := c.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), body)
+
+	c.set("c", []byte("3"))
+	assert.Equal(t, 2, c.len())
+
+	_, ok = c.get("b")
+	assert.False(t, ok)
+
+	// Setting a key again updates its body and expiry.
+	now = now.Add(30 * time.Second)
+	c.set("a", []byte("4"))
+
+	now = now.Add(45 * time.Second)
+	body, ok = c.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("4"), body)
+
+	_, ok = c.get("c")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.len())
+}
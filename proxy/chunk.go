@@ -0,0 +1,271 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mojixcoder/gosrm"
+)
+
+// errTooBig is returned when a request is too big for the backends and can't be split into chunks.
+var errTooBig = errors.New("request is too big for the backends and can't be split")
+
+// perCoordinate are the parameters with a value per coordinate, they're split with the coordinates.
+var perCoordinate = []string{"bearings", "radiuses", "hints", "approaches", "timestamps"}
+
+// chunks returns the bounds of chunks of n coordinates with at most size coordinates each.
+// Consecutive chunks share a coordinate, so they're connected.
+func chunks(n, size int) [][2]int {
+	if size < 2 || n <= size {
+		return [][2]int{{0, n}}
+	}
+
+	var bounds [][2]int
+	for start := 0; start < n-1; start += size - 1 {
+		bounds = append(bounds, [2]int{start, min(start+size, n)})
+	}
+
+	return bounds
+}
+
+// chunked returns true if the request has more coordinates than the backends accept, so it's split into chunks.
+// Invalid sources or destinations of table requests are returned as response errors.
+func (pr proxyRequest) chunked(size int) (bool, error) {
+	switch pr.service {
+	case ServiceRoute, ServiceMatch:
+		return len(chunks(len(pr.req.Coordinates), size)) > 1, nil
+	case ServiceTable:
+		sources, destinations, err := pr.tableIndices()
+		if err != nil {
+			return false, err
+		}
+		return size > 0 && max(len(sources), len(destinations)) > size, nil
+	}
+
+	return false, nil
+}
+
+// tableIndices returns the sources and destinations of a table request.
+func (pr proxyRequest) tableIndices() ([]int, []int, error) {
+	n := len(pr.req.Coordinates)
+
+	sources, err := parseIndices(pr.query.Get("sources"), n)
+	if err != nil {
+		return nil, nil, gosrm.ResponseError{Code: "InvalidOptions", Message: err.Error()}
+	}
+	destinations, err := parseIndices(pr.query.Get("destinations"), n)
+	if err != nil {
+		return nil, nil, gosrm.ResponseError{Code: "InvalidOptions", Message: err.Error()}
+	}
+
+	return sources, destinations, nil
+}
+
+// chunk returns the request and options of the coordinates from start to end.
+// Per coordinate parameters are split with the coordinates, other parameters are kept except the skipped ones.
+func (pr proxyRequest) chunk(start, end int, skip ...string) (gosrm.Request, []gosrm.Option) {
+	req := gosrm.Request{Profile: pr.req.Profile, Coordinates: pr.req.Coordinates[start:end]}
+	opts := pr.options(append(skip, perCoordinate...)...)
+
+	for _, k := range perCoordinate {
+		if !pr.query.Has(k) {
+			continue
+		}
+
+		// Values that don't match the coordinates are passed as is, so the backend returns the error.
+		v := pr.query.Get(k)
+		if values := strings.Split(v, ";"); len(values) == len(pr.req.Coordinates) {
+			v = strings.Join(values[start:end], ";")
+		}
+		opts = append(opts, gosrm.WithCustomOption(k, v))
+	}
+
+	return req, opts
+}
+
+// route forwards a route request which is split into consecutive routes of at most chunk coordinates,
+// they're merged into one. Alternatives aren't requested for split routes.
+func (s *Server) route(ctx context.Context, osrm gosrm.OSRMClient, pr proxyRequest, chunk int) (*gosrm.RouteResponse[gosrm.AnyGeometry], error) {
+	bounds := chunks(len(pr.req.Coordinates), chunk)
+	if pr.query.Has("waypoints") {
+		return nil, errTooBig
+	}
+	s.metrics.add(&s.metrics.chunkedRequests, 1)
+
+	merged := gosrm.RouteResponse[gosrm.AnyGeometry]{Routes: make([]gosrm.RouteType[gosrm.AnyGeometry], 1)}
+	route := &merged.Routes[0]
+
+	var coordinates []gosrm.Coordinate
+	for i, b := range bounds {
+		req, opts := pr.chunk(b[0], b[1], "alternatives")
+
+		s.metrics.add(&s.metrics.backendRequests, 1)
+		res, err := gosrm.Route[gosrm.AnyGeometry](ctx, osrm, req, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if err := res.Err(); err != nil || len(res.Routes) == 0 {
+			return res, err
+		}
+
+		part := res.Routes[0]
+		route.Distance += part.Distance
+		route.Duration += part.Duration
+		route.Weight += part.Weight
+		route.WeightName = part.WeightName
+		route.Legs = append(route.Legs, part.Legs...)
+		route.Geometry.Format = part.Geometry.Format
+
+		points := part.Geometry.Coordinates()
+		waypoints := res.Waypoints
+		if i > 0 {
+			// The first coordinate is the last one of the previous chunk.
+			if len(points) > 0 && len(coordinates) > 0 && points[0] == coordinates[len(coordinates)-1] {
+				points = points[1:]
+			}
+			if len(waypoints) > 0 {
+				waypoints = waypoints[1:]
+			}
+		}
+		coordinates = append(coordinates, points...)
+		merged.Waypoints = append(merged.Waypoints, waypoints...)
+		merged.Response = res.Response
+	}
+
+	if len(coordinates) > 0 {
+		route.Geometry = gosrm.NewGeometry(route.Geometry.Format, coordinates)
+	}
+
+	return &merged, nil
+}
+
+// tableResponse is a merged table response, values of the pairs that can't be routed are encoded as null like OSRM does.
+type tableResponse struct {
+	*gosrm.TableResponse
+
+	Durations [][]*float32 `json:"durations,omitempty"`
+	Distances [][]*float32 `json:"distances,omitempty"`
+}
+
+// newTableResponse returns the table response to encode of a merged response.
+func newTableResponse(res *gosrm.TableResponse) tableResponse {
+	return tableResponse{TableResponse: res, Durations: nullMatrix(res.Durations), Distances: nullMatrix(res.Distances)}
+}
+
+// nullMatrix returns the matrix with nil for infinite values.
+func nullMatrix(m [][]float32) [][]*float32 {
+	if m == nil {
+		return nil
+	}
+
+	out := make([][]*float32, len(m))
+	for i, row := range m {
+		out[i] = make([]*float32, len(row))
+		for j := range row {
+			if !math.IsInf(float64(row[j]), 0) {
+				out[i][j] = &row[j]
+			}
+		}
+	}
+	return out
+}
+
+// table forwards a table request which is split into blocks of at most chunk sources and destinations.
+func (s *Server) table(ctx context.Context, osrm gosrm.OSRMClient, pr proxyRequest, chunk int) (*gosrm.TableResponse, error) {
+	sources, destinations, err := pr.tableIndices()
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocks have the coordinates of their sources and destinations, so per coordinate parameters can't be kept.
+	for _, k := range perCoordinate {
+		if pr.query.Has(k) {
+			return nil, errTooBig
+		}
+	}
+
+	rows := (len(sources) + chunk - 1) / chunk
+	cols := (len(destinations) + chunk - 1) / chunk
+	s.metrics.add(&s.metrics.chunkedRequests, 1)
+	s.metrics.add(&s.metrics.backendRequests, rows*cols)
+
+	return gosrm.TableChunked(ctx, osrm, pr.req, sources, destinations, chunk, pr.options("sources", "destinations")...)
+}
+
+// match forwards a match request which is split into consecutive traces of at most chunk coordinates.
+// Matchings of the chunks are merged, so traces are also split at the bounds of the chunks.
+func (s *Server) match(ctx context.Context, osrm gosrm.OSRMClient, pr proxyRequest, chunk int) (*gosrm.MatchResponse[gosrm.AnyGeometry], error) {
+	bounds := chunks(len(pr.req.Coordinates), chunk)
+	if pr.query.Has("waypoints") {
+		return nil, errTooBig
+	}
+	s.metrics.add(&s.metrics.chunkedRequests, 1)
+
+	var merged gosrm.MatchResponse[gosrm.AnyGeometry]
+	for i, b := range bounds {
+		req, opts := pr.chunk(b[0], b[1])
+
+		s.metrics.add(&s.metrics.backendRequests, 1)
+		res, err := gosrm.Match[gosrm.AnyGeometry](ctx, osrm, req, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		// Chunks that can't be matched don't have tracepoints.
+		tracepoints := make([]gosrm.Tracepoint, b[1]-b[0])
+		if res.Code == gosrm.CodeNoMatch {
+			merged.Message = res.Message
+		} else if err := res.Err(); err != nil {
+			return res, err
+		} else {
+			merged.DataVersion = res.DataVersion
+			for j, tp := range res.Tracepoints {
+				if !tp.Omitted() && j < len(tracepoints) {
+					tp.MatchingIndex += uint16(len(merged.Matchings))
+					tracepoints[j] = tp
+				}
+			}
+			merged.Matchings = append(merged.Matchings, res.Matchings...)
+		}
+
+		// The first tracepoint is the last one of the previous chunk.
+		if i > 0 {
+			tracepoints = tracepoints[1:]
+		}
+		merged.Tracepoints = append(merged.Tracepoints, tracepoints...)
+	}
+
+	merged.Code = gosrm.CodeOK
+	if len(merged.Matchings) == 0 {
+		merged.Code = gosrm.CodeNoMatch
+	} else {
+		merged.Message = ""
+	}
+
+	return &merged, nil
+}
+
+// parseIndices parses indices of sources or destinations parameters, all and empty mean all of the coordinates.
+func parseIndices(v string, n int) ([]int, error) {
+	if v == "" || v == "all" {
+		indices := make([]int, n)
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices, nil
+	}
+
+	var indices []int
+	for _, s := range strings.Split(v, ";") {
+		i, err := strconv.Atoi(s)
+		if err != nil || i < 0 || i >= n {
+			return nil, errors.New("invalid index " + s)
+		}
+		indices = append(indices, i)
+	}
+
+	return indices, nil
+}
@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+type (
+	// metrics are the counters of a server, written in Prometheus text format.
+	// It's safe for concurrent use.
+	metrics struct {
+		mu sync.Mutex
+
+		// requests are the numbers of requests by service and status code.
+		requests map[requestLabels]int
+
+		// seconds are the total durations of requests by service.
+		seconds map[string]float64
+
+		cacheHits, cacheMisses int
+		backendRequests        int
+		backendErrors          int
+		chunkedRequests        int
+		quotaRejections        int
+	}
+
+	// requestLabels are the labels of a request counter.
+	requestLabels struct {
+		service string
+		status  int
+	}
+)
+
+// newMetrics returns new metrics.
+func newMetrics() *metrics {
+	return &metrics{requests: make(map[requestLabels]int), seconds: make(map[string]float64)}
+}
+
+// request counts a request of a service.
+func (m *metrics) request(service string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestLabels{service: service, status: status}]++
+	m.seconds[service] += duration.Seconds()
+}
+
+// add adds n to a counter.
+func (m *metrics) add(counter *int, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	*counter += n
+}
+
+// write writes the metrics in Prometheus text format.
+func (m *metrics) write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	labels := make([]requestLabels, 0, len(m.requests))
+	for l := range m.requests {
+		labels = append(labels, l)
+	}
+	slices.SortFunc(labels, func(a, b requestLabels) int {
+		return cmp.Or(cmp.Compare(a.service, b.service), cmp.Compare(a.status, b.status))
+	})
+
+	services := make([]string, 0, len(m.seconds))
+	for s := range m.seconds {
+		services = append(services, s)
+	}
+	slices.Sort(services)
+
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	printf("# TYPE gosrm_proxy_requests_total counter\n")
+	for _, l := range labels {
+		printf("gosrm_proxy_requests_total{service=%q,status=\"%d\"} %d\n", l.service, l.status, m.requests[l])
+	}
+
+	printf("# TYPE gosrm_proxy_request_seconds_total counter\n")
+	for _, s := range services {
+		printf("gosrm_proxy_request_seconds_total{service=%q} %g\n", s, m.seconds[s])
+	}
+
+	counters := []struct {
+		name  string
+		value int
+	}{
+		{"gosrm_proxy_cache_hits_total", m.cacheHits},
+		{"gosrm_proxy_cache_misses_total", m.cacheMisses},
+		{"gosrm_proxy_backend_requests_total", m.backendRequests},
+		{"gosrm_proxy_backend_errors_total", m.backendErrors},
+		{"gosrm_proxy_chunked_requests_total", m.chunkedRequests},
+		{"gosrm_proxy_quota_rejections_total", m.quotaRejections},
+	}
+	for _, c := range counters {
+		printf("# TYPE %s counter\n%s %d\n", c.name, c.name, c.value)
+	}
+
+	return err
+}
@@ -0,0 +1,423 @@
+// Package proxy is an HTTP gateway with OSRM's URL grammar that forwards requests to OSRM backends through
+// gosrm.OSRMClient, so OSRM clients can use it unchanged. Responses of the backends are forwarded as they sent them,
+// except for the merged responses of requests split into chunks. It caches responses, enforces per API key quotas and
+// max coordinates per service, splits requests that are bigger than the backends accept into chunks and
+// exposes metrics in Prometheus text format at /metrics.
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mojixcoder/gosrm"
+)
+
+// ErrNoBackends is returned when a server is created without backends.
+var ErrNoBackends = errors.New("proxy: no backends")
+
+const (
+	// DefaultCacheSize is the default max number of cached responses.
+	DefaultCacheSize int = 1000
+
+	// DefaultCacheTTL is the default time responses are cached for.
+	DefaultCacheTTL time.Duration = 5 * time.Minute
+
+	// DefaultQuotaWindow is the default window of API key quotas.
+	DefaultQuotaWindow time.Duration = time.Minute
+
+	// APIKeyHeader is the header of API keys, they can also be passed as api_key query parameter.
+	APIKeyHeader string = "X-API-Key"
+)
+
+const (
+	// ServiceRoute is the route service.
+	ServiceRoute string = "route"
+
+	// ServiceTable is the table service.
+	ServiceTable string = "table"
+
+	// ServiceMatch is the match service.
+	ServiceMatch string = "match"
+
+	// ServiceTrip is the trip service.
+	ServiceTrip string = "trip"
+
+	// ServiceNearest is the nearest service.
+	ServiceNearest string = "nearest"
+)
+
+// Codes of the errors returned by the proxy, in addition to the codes returned by OSRM.
+const (
+	// CodeTooBig is returned when a request has more coordinates than allowed.
+	CodeTooBig gosrm.Code = "TooBig"
+
+	// CodeInvalidKey is returned when an API key is missing or unknown.
+	CodeInvalidKey gosrm.Code = "InvalidKey"
+
+	// CodeTooManyRequests is returned when the quota of an API key is exceeded.
+	CodeTooManyRequests gosrm.Code = "TooManyRequests"
+
+	// CodeBackendError is returned when a backend can't be reached.
+	CodeBackendError gosrm.Code = "BackendError"
+)
+
+// services are the supported services.
+var services = []string{ServiceRoute, ServiceTable, ServiceMatch, ServiceTrip, ServiceNearest}
+
+// DefaultMaxCoordinates are the default max numbers of coordinates of the services.
+var DefaultMaxCoordinates = map[string]int{
+	ServiceRoute:   5000,
+	ServiceTable:   1000,
+	ServiceMatch:   5000,
+	ServiceTrip:    100,
+	ServiceNearest: 1,
+}
+
+// DefaultChunkSizes are the default max numbers of coordinates the backends accept, they're OSRM's defaults.
+// Route and match requests are split into chunks of this size, table requests into blocks of this many
+// sources and destinations.
+var DefaultChunkSizes = map[string]int{
+	ServiceRoute: 500,
+	ServiceTable: 100,
+	ServiceMatch: 100,
+}
+
+type (
+	// Config is the config of a server.
+	Config struct {
+		// Backends are the OSRM clients requests are forwarded to, in round-robin order.
+		Backends []gosrm.OSRMClient
+
+		// MaxCoordinates are the max numbers of coordinates of the services, bigger requests are rejected.
+		// Services that aren't set use DefaultMaxCoordinates, a max of 0 is unlimited.
+		MaxCoordinates map[string]int
+
+		// ChunkSizes are the max numbers of coordinates the backends accept for route, table and match services.
+		// Services that aren't set use DefaultChunkSizes.
+		ChunkSizes map[string]int
+
+		// CacheSize is the max number of cached responses. If it's negative responses aren't cached.
+		// Defaults to DefaultCacheSize.
+		CacheSize int
+
+		// CacheTTL is the time responses are cached for. Defaults to DefaultCacheTTL.
+		CacheTTL time.Duration
+
+		// APIKeys are the API keys and their quotas per window, a quota of 0 is unlimited.
+		// If it's empty then API keys aren't required.
+		APIKeys map[string]int
+
+		// QuotaWindow is the window of API key quotas. Defaults to DefaultQuotaWindow.
+		QuotaWindow time.Duration
+	}
+
+	// Server is the proxy HTTP handler.
+	// It's safe for concurrent use.
+	Server struct {
+		config  Config
+		next    atomic.Uint64
+		cache   *cache
+		quotas  *quotas
+		metrics *metrics
+	}
+
+	// response is a response of OSRM.
+	response interface {
+		Err() error
+	}
+
+	// proxyRequest is a parsed OSRM request.
+	proxyRequest struct {
+		service string
+		req     gosrm.Request
+		query   url.Values
+	}
+)
+
+// New returns a new proxy server.
+func New(config Config) (*Server, error) {
+	if len(config.Backends) == 0 {
+		return nil, ErrNoBackends
+	}
+
+	config.MaxCoordinates = withDefaults(config.MaxCoordinates, DefaultMaxCoordinates)
+	config.ChunkSizes = withDefaults(config.ChunkSizes, DefaultChunkSizes)
+
+	if config.CacheSize == 0 {
+		config.CacheSize = DefaultCacheSize
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultCacheTTL
+	}
+	if config.QuotaWindow <= 0 {
+		config.QuotaWindow = DefaultQuotaWindow
+	}
+
+	s := Server{config: config, quotas: newQuotas(config.APIKeys, config.QuotaWindow), metrics: newMetrics()}
+	if config.CacheSize > 0 {
+		s.cache = newCache(config.CacheSize, config.CacheTTL)
+	}
+
+	return &s, nil
+}
+
+// withDefaults returns the values with the defaults of the keys that aren't set.
+func withDefaults(values, defaults map[string]int) map[string]int {
+	merged := make(map[string]int, len(defaults))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range values {
+		merged[k] = v
+	}
+	return merged
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/metrics" {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		s.metrics.write(w)
+		return
+	}
+
+	start := time.Now()
+	status := s.serve(w, r)
+
+	service := "unknown"
+	if name, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/"); slices.Contains(services, name) {
+		service = name
+	}
+	s.metrics.request(service, status, time.Since(start))
+}
+
+// serve serves an OSRM request and returns the status code of the response.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) int {
+	if r.Method != http.MethodGet {
+		return writeError(w, http.StatusMethodNotAllowed, "InvalidMethod", "only GET requests are supported")
+	}
+
+	query := r.URL.Query()
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		key = query.Get("api_key")
+	}
+	query.Del("api_key")
+
+	if len(s.config.APIKeys) > 0 && !s.quotas.known(key) {
+		return writeError(w, http.StatusUnauthorized, CodeInvalidKey, "API key is missing or unknown")
+	}
+
+	pr, err := parseRequest(r.URL.Path, query)
+	if err != nil {
+		return writeError(w, http.StatusBadRequest, "InvalidUrl", err.Error())
+	}
+
+	if !slices.Contains(services, pr.service) {
+		return writeError(w, http.StatusBadRequest, "InvalidService", fmt.Sprintf("service %s is not supported", pr.service))
+	}
+	if limit := s.config.MaxCoordinates[pr.service]; limit > 0 && len(pr.req.Coordinates) > limit {
+		return writeError(w, http.StatusBadRequest, CodeTooBig, fmt.Sprintf("%s requests can have at most %d coordinates", pr.service, limit))
+	}
+
+	// Invalid requests don't count against the quota.
+	if len(s.config.APIKeys) > 0 && !s.quotas.allow(key) {
+		s.metrics.add(&s.metrics.quotaRejections, 1)
+		return writeError(w, http.StatusTooManyRequests, CodeTooManyRequests, "quota of API key is exceeded")
+	}
+
+	cacheKey := pr.cacheKey()
+	if s.cache != nil {
+		if body, ok := s.cache.get(cacheKey); ok {
+			s.metrics.add(&s.metrics.cacheHits, 1)
+			return writeBody(w, http.StatusOK, body)
+		}
+		s.metrics.add(&s.metrics.cacheMisses, 1)
+	}
+
+	status, body, err := s.forward(r.Context(), pr)
+	if err != nil {
+		var resErr gosrm.ResponseError
+		if errors.As(err, &resErr) {
+			return writeError(w, http.StatusBadRequest, resErr.Code, resErr.Message)
+		}
+		if errors.Is(err, errTooBig) {
+			return writeError(w, http.StatusBadRequest, CodeTooBig, err.Error())
+		}
+
+		s.metrics.add(&s.metrics.backendErrors, 1)
+		return writeError(w, http.StatusBadGateway, CodeBackendError, err.Error())
+	}
+
+	if s.cache != nil && status == http.StatusOK {
+		s.cache.set(cacheKey, body)
+	}
+
+	return writeBody(w, status, body)
+}
+
+// backend returns the next backend.
+func (s *Server) backend() gosrm.OSRMClient {
+	return s.config.Backends[(s.next.Add(1)-1)%uint64(len(s.config.Backends))]
+}
+
+// forward forwards a request to a backend and returns the status code and the body of its response.
+// Responses are returned as the backend sent them, except for requests which are split into chunks,
+// their merged responses are encoded and their response errors are returned as errors.
+func (s *Server) forward(ctx context.Context, pr proxyRequest) (int, []byte, error) {
+	osrm := s.backend()
+	chunk := s.config.ChunkSizes[pr.service]
+
+	chunked, err := pr.chunked(chunk)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !chunked {
+		s.metrics.add(&s.metrics.backendRequests, 1)
+		return osrm.Raw(ctx, pr.service, pr.req, pr.options()...)
+	}
+
+	var res response
+
+	switch pr.service {
+	case ServiceRoute:
+		res, err = s.route(ctx, osrm, pr, chunk)
+	case ServiceTable:
+		var table *gosrm.TableResponse
+		if table, err = s.table(ctx, osrm, pr, chunk); err == nil {
+			res = newTableResponse(table)
+		}
+	case ServiceMatch:
+		res, err = s.match(ctx, osrm, pr, chunk)
+	}
+
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := res.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	body, err := json.Marshal(res)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, body, nil
+}
+
+// parseRequest parses the path and query of an OSRM request:
+// /{service}/{version}/{profile}/{coordinates}[.{format}]?{options}.
+// Coordinates are {longitude},{latitude} pairs separated by ; or polyline({polyline}) or polyline6({polyline}).
+func parseRequest(path string, query url.Values) (proxyRequest, error) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) != 4 {
+		return proxyRequest{}, errors.New("URL must be /{service}/{version}/{profile}/{coordinates}")
+	}
+	if parts[1] != "v1" {
+		return proxyRequest{}, fmt.Errorf("version %s is not supported", parts[1])
+	}
+
+	coordinates, err := parseCoordinates(strings.TrimSuffix(parts[3], ".json"))
+	if err != nil {
+		return proxyRequest{}, err
+	}
+
+	return proxyRequest{
+		service: parts[0],
+		req:     gosrm.Request{Profile: gosrm.Profile(parts[2]), Coordinates: coordinates},
+		query:   query,
+	}, nil
+}
+
+// parseCoordinates parses the coordinates of an OSRM request.
+func parseCoordinates(s string) ([]gosrm.Coordinate, error) {
+	for prefix, precision := range map[string]uint8{"polyline(": 5, "polyline6(": 6} {
+		if encoded, ok := strings.CutPrefix(s, prefix); ok {
+			coordinates, err := gosrm.DecodePolyline(strings.TrimSuffix(encoded, ")"), precision)
+			if err != nil {
+				return nil, err
+			}
+			if len(coordinates) == 0 {
+				return nil, errors.New("no coordinates")
+			}
+			return coordinates, nil
+		}
+	}
+
+	var coordinates []gosrm.Coordinate
+	for _, pair := range strings.Split(s, ";") {
+		lng, lat, ok := strings.Cut(pair, ",")
+		if !ok {
+			return nil, fmt.Errorf("invalid coordinate %q", pair)
+		}
+
+		x, err := strconv.ParseFloat(lng, 64)
+		if err != nil || x < -180 || x > 180 {
+			return nil, fmt.Errorf("invalid longitude %q", lng)
+		}
+		y, err := strconv.ParseFloat(lat, 64)
+		if err != nil || y < -90 || y > 90 {
+			return nil, fmt.Errorf("invalid latitude %q", lat)
+		}
+
+		coordinates = append(coordinates, gosrm.Coordinate{x, y})
+	}
+
+	return coordinates, nil
+}
+
+// cacheKey returns the key of the request in the cache.
+func (pr proxyRequest) cacheKey() string {
+	var b strings.Builder
+	b.WriteString(pr.service + "/" + string(pr.req.Profile) + "/")
+	for _, c := range pr.req.Coordinates {
+		b.WriteString(strconv.FormatFloat(c[0], 'f', -1, 64) + "," + strconv.FormatFloat(c[1], 'f', -1, 64) + ";")
+	}
+
+	// Encode sorts the parameters by key.
+	b.WriteString("?" + pr.query.Encode())
+
+	return b.String()
+}
+
+// options returns the options of the query, except the skipped ones.
+func (pr proxyRequest) options(skip ...string) []gosrm.Option {
+	keys := make([]string, 0, len(pr.query))
+	for k := range pr.query {
+		if !slices.Contains(skip, k) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	opts := make([]gosrm.Option, len(keys))
+	for i, k := range keys {
+		opts[i] = gosrm.WithCustomOption(k, pr.query.Get(k))
+	}
+
+	return opts
+}
+
+// writeError writes an error response in the format of OSRM and returns its status code.
+func writeError(w http.ResponseWriter, status int, code gosrm.Code, message string) int {
+	body, _ := json.Marshal(map[string]string{"code": string(code), "message": message})
+	return writeBody(w, status, body)
+}
+
+// writeBody writes a JSON response and returns its status code.
+func writeBody(w http.ResponseWriter, status int, body []byte) int {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	w.Write(body)
+	return status
+}
@@ -0,0 +1,497 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mojixcoder/gosrm"
+	"github.com/stretchr/testify/assert"
+)
+
+// backend is a fake OSRM backend which records the queries of its requests.
+type backend struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	queries []url.Values
+}
+
+// newBackend returns a fake OSRM backend.
+// Routes go straight through their coordinates with a leg between each pair, tables are Manhattan distances in
+// degrees times 100000 and durations are distances divided by 10. Traces are matched to their coordinates and
+// coordinates with a negative longitude can't be routed or matched.
+func newBackend(t *testing.T) *backend {
+	b := backend{}
+	b.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.mu.Lock()
+		b.queries = append(b.queries, r.URL.Query())
+		b.mu.Unlock()
+
+		parts := strings.Split(r.URL.Path, "/")
+		coordinates := testCoordinates(parts[len(parts)-1])
+		format := gosrm.GeometryFormat(r.URL.Query().Get("geometries"))
+		if format == "" {
+			format = gosrm.GeometryPolyline
+		}
+
+		for _, c := range coordinates {
+			if c[0] < 0 {
+				code := gosrm.CodeNoRoute
+				if parts[1] == "match" {
+					code = gosrm.CodeNoMatch
+				}
+				w.WriteHeader(http.StatusBadRequest)
+				assert.NoError(t, json.NewEncoder(w).Encode(gosrm.Response{Code: code, Message: "no route"}))
+				return
+			}
+		}
+
+		var res any
+		switch parts[1] {
+		case "route":
+			res = gosrm.RouteResponse[gosrm.AnyGeometry]{
+				Response:  gosrm.Response{Code: gosrm.CodeOK},
+				Routes:    []gosrm.RouteType[gosrm.AnyGeometry]{testRoute(coordinates, format)},
+				Waypoints: testWaypoints(coordinates),
+			}
+		case "table":
+			res = testTable(coordinates, r.URL.Query())
+		case "match":
+			route := testRoute(coordinates, format)
+			match := gosrm.MatchResponse[gosrm.AnyGeometry]{
+				Response:  gosrm.Response{Code: gosrm.CodeOK},
+				Matchings: []gosrm.Matching[gosrm.AnyGeometry]{{RouteType: route, Confidence: 1}},
+			}
+			for i, wp := range testWaypoints(coordinates) {
+				match.Tracepoints = append(match.Tracepoints, gosrm.Tracepoint{Waypoint: wp, WaypointIndex: uint16(i)})
+			}
+			res = match
+		case "nearest":
+			res = gosrm.NearestResponse{
+				Response:  gosrm.Response{Code: gosrm.CodeOK},
+				Waypoints: []gosrm.NearestWaypoint{{Waypoint: testWaypoints(coordinates)[0]}},
+			}
+		case "trip":
+			res = gosrm.TripResponse[gosrm.AnyGeometry]{
+				Response: gosrm.Response{Code: gosrm.CodeOK},
+				Trips:    []gosrm.RouteType[gosrm.AnyGeometry]{testRoute(coordinates, format)},
+			}
+		}
+
+		assert.NoError(t, json.NewEncoder(w).Encode(res))
+	}))
+
+	return &b
+}
+
+// requests returns the queries of the requests of the backend.
+func (b *backend) requests() []url.Values {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]url.Values(nil), b.queries...)
+}
+
+// testCoordinates parses the coordinates of a path.
+func testCoordinates(s string) []gosrm.Coordinate {
+	var coordinates []gosrm.Coordinate
+	for _, pair := range strings.Split(strings.TrimSuffix(s, ".json"), ";") {
+		lng, lat, _ := strings.Cut(pair, ",")
+		x, _ := strconv.ParseFloat(lng, 64)
+		y, _ := strconv.ParseFloat(lat, 64)
+		coordinates = append(coordinates, gosrm.Coordinate{x, y})
+	}
+	return coordinates
+}
+
+// testRoute returns a route straight through the coordinates.
+func testRoute(coordinates []gosrm.Coordinate, format gosrm.GeometryFormat) gosrm.RouteType[gosrm.AnyGeometry] {
+	route := gosrm.RouteType[gosrm.AnyGeometry]{Geometry: gosrm.NewGeometry(format, coordinates), WeightName: "routability"}
+	for i := 1; i < len(coordinates); i++ {
+		d := float32(gosrm.HaversineDistance(coordinates[i-1], coordinates[i]))
+		route.Legs = append(route.Legs, gosrm.RouteLeg[gosrm.AnyGeometry]{Distance: d, Duration: d / 10, Weight: d / 10})
+		route.Distance += d
+		route.Duration += d / 10
+		route.Weight += d / 10
+	}
+	return route
+}
+
+// testWaypoints returns waypoints at the coordinates.
+func testWaypoints(coordinates []gosrm.Coordinate) []gosrm.Waypoint {
+	waypoints := make([]gosrm.Waypoint, len(coordinates))
+	for i, c := range coordinates {
+		waypoints[i] = gosrm.Waypoint{Location: c, Hint: "h" + strconv.Itoa(i)}
+	}
+	return waypoints
+}
+
+// testTable returns the table of the coordinates.
+func testTable(coordinates []gosrm.Coordinate, query url.Values) gosrm.TableResponse {
+	indices := func(v string) []int {
+		if v == "" || v == "all" {
+			return testIndices(len(coordinates))
+		}
+		var indices []int
+		for _, s := range strings.Split(v, ";") {
+			i, _ := strconv.Atoi(s)
+			indices = append(indices, i)
+		}
+		return indices
+	}
+
+	res := gosrm.TableResponse{Response: gosrm.Response{Code: gosrm.CodeOK}}
+	for _, i := range indices(query.Get("sources")) {
+		res.Sources = append(res.Sources, gosrm.Waypoint{Location: coordinates[i]})
+
+		var row []float32
+		for _, j := range indices(query.Get("destinations")) {
+			row = append(row, testDistance(coordinates[i], coordinates[j]))
+		}
+		res.Distances = append(res.Distances, row)
+	}
+	for _, j := range indices(query.Get("destinations")) {
+		res.Destinations = append(res.Destinations, gosrm.Waypoint{Location: coordinates[j]})
+	}
+
+	return res
+}
+
+// testDistance returns the Manhattan distance of coordinates in degrees times 100000.
+func testDistance(a, b gosrm.Coordinate) float32 {
+	return float32(math.Round((math.Abs(a[0]-b[0]) + math.Abs(a[1]-b[1])) * 100000))
+}
+
+// testIndices returns the indices from 0 to n-1.
+func testIndices(n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
+
+// newTestServer returns a proxy server of the backends.
+func newTestServer(t *testing.T, config Config, backends ...*backend) *httptest.Server {
+	for _, b := range backends {
+		osrm, err := gosrm.New(b.URL)
+		assert.NoError(t, err)
+		config.Backends = append(config.Backends, osrm)
+	}
+
+	s, err := New(config)
+	assert.NoError(t, err)
+
+	return httptest.NewServer(s)
+}
+
+// get calls the proxy and decodes the response.
+func get(t *testing.T, srv *httptest.Server, path string, header http.Header, out any) int {
+	req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	assert.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(out))
+	return res.StatusCode
+}
+
+func TestServer_Client(t *testing.T) {
+	b := newBackend(t)
+	defer b.Close()
+
+	srv := newTestServer(t, Config{}, b)
+	defer srv.Close()
+
+	// This library can use the proxy unchanged.
+	osrm, err := gosrm.New(srv.URL)
+	assert.NoError(t, err)
+
+	req := gosrm.Request{Profile: gosrm.ProfileCar, Coordinates: []gosrm.Coordinate{{0, 0}, {0.01, 0}, {0.01, 0.01}}}
+	route, err := gosrm.Route[gosrm.LineString](context.Background(), osrm, req, gosrm.WithGeometries(gosrm.GeometryGeoJSON))
+	assert.NoError(t, err)
+	assert.Equal(t, gosrm.CodeOK, route.Code)
+	assert.Equal(t, req.Coordinates, route.Routes[0].Geometry.Coordinates)
+	assert.Len(t, route.Routes[0].Legs, 2)
+	assert.Equal(t, "h1", route.Waypoints[1].Hint)
+
+	table, err := gosrm.Table(context.Background(), osrm, req, gosrm.WithSources([]uint16{0}), gosrm.WithAnnotations(gosrm.AnnotationsDistance))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]float32{{0, 1000, 2000}}, table.Distances)
+
+	match, err := gosrm.Match[string](context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.Len(t, match.Tracepoints, 3)
+
+	nearest, err := gosrm.Nearest(context.Background(), osrm, gosrm.Request{Profile: gosrm.ProfileCar, Coordinates: req.Coordinates[:1]})
+	assert.NoError(t, err)
+	assert.Len(t, nearest.Waypoints, 1)
+
+	trip, err := gosrm.Trip[string](context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.Len(t, trip.Trips, 1)
+
+	// Errors of the backends are returned as is.
+	req.Coordinates[1][0] = -1
+	route, err = gosrm.Route[gosrm.LineString](context.Background(), osrm, req, gosrm.WithGeometries(gosrm.GeometryGeoJSON))
+	assert.NoError(t, err)
+	assert.Equal(t, gosrm.ResponseError{Code: gosrm.CodeNoRoute, Message: "no route"}, route.Err())
+}
+
+func TestServer_Cache(t *testing.T) {
+	b := newBackend(t)
+	defer b.Close()
+
+	srv := newTestServer(t, Config{}, b)
+	defer srv.Close()
+
+	var res map[string]any
+	assert.Equal(t, http.StatusOK, get(t, srv, "/route/v1/car/0,0;0.01,0.json?steps=false&overview=full", nil, &res))
+	assert.Equal(t, http.StatusOK, get(t, srv, "/route/v1/car/0,0;0.01,0?overview=full&steps=false", nil, &res))
+	assert.Len(t, b.requests(), 1)
+	assert.Equal(t, "full", b.requests()[0].Get("overview"))
+
+	assert.Equal(t, http.StatusOK, get(t, srv, "/route/v1/car/0,0;0.01,0?overview=false", nil, &res))
+	assert.Len(t, b.requests(), 2)
+
+	// Errors aren't cached.
+	get(t, srv, "/route/v1/car/0,0;-1,0", nil, &res)
+	get(t, srv, "/route/v1/car/0,0;-1,0", nil, &res)
+	assert.Len(t, b.requests(), 4)
+
+	// Coordinates can be encoded as polylines.
+	polyline := gosrm.EncodePolyline([]gosrm.Coordinate{{0, 0}, {0.01, 0}}, 6)
+	assert.Equal(t, http.StatusOK, get(t, srv, "/route/v1/car/polyline6("+url.PathEscape(polyline)+")?overview=full&steps=false", nil, &res))
+	assert.Len(t, b.requests(), 4)
+}
+
+func TestServer_Passthrough(t *testing.T) {
+	const body = `{"code":"Ok","routes":[{"distance":1,"legs":[{"steps":[]}]}],"waypoints":[],"extra":true}`
+
+	var requests int
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if strings.Contains(r.URL.Path, ";-1") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"NoRoute","message":"no route","extra":true}`))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer stub.Close()
+
+	osrm, err := gosrm.New(stub.URL)
+	assert.NoError(t, err)
+	s, err := New(Config{Backends: []gosrm.OSRMClient{osrm}})
+	assert.NoError(t, err)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	raw := func(path string) (int, string) {
+		res, err := http.Get(srv.URL + path)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		var b bytes.Buffer
+		_, err = b.ReadFrom(res.Body)
+		assert.NoError(t, err)
+		return res.StatusCode, b.String()
+	}
+
+	// Responses are sent as the backend sent them, including cached ones.
+	for range 2 {
+		status, got := raw("/route/v1/car/0,0;1,1?steps=true")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, body, got)
+	}
+	assert.Equal(t, 1, requests)
+
+	status, got := raw("/route/v1/car/0,0;-1,0")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, `{"code":"NoRoute","message":"no route","extra":true}`, got)
+}
+
+func TestServer_Chunks(t *testing.T) {
+	b := newBackend(t)
+	defer b.Close()
+
+	srv := newTestServer(t, Config{ChunkSizes: map[string]int{ServiceRoute: 3, ServiceTable: 2, ServiceMatch: 3}}, b)
+	defer srv.Close()
+
+	coordinates := "0,0;0.01,0;0.02,0;0.03,0;0.04,0"
+
+	var route gosrm.RouteResponse[gosrm.LineString]
+	status := get(t, srv, "/route/v1/car/"+coordinates+"?geometries=geojson&alternatives=true&radiuses=1%3B2%3B3%3B4%3B5", nil, &route)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, route.Routes, 1)
+	assert.Len(t, route.Routes[0].Legs, 4)
+	assert.Len(t, route.Routes[0].Geometry.Coordinates, 5)
+	assert.Len(t, route.Waypoints, 5)
+	assert.Equal(t, gosrm.Coordinate{0.04, 0}, route.Waypoints[4].Location)
+	assert.InDelta(t, 4448, route.Routes[0].Distance, 1)
+
+	requests := b.requests()
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "1;2;3", requests[0].Get("radiuses"))
+	assert.Equal(t, "3;4;5", requests[1].Get("radiuses"))
+	assert.False(t, requests[0].Has("alternatives"))
+
+	var table gosrm.TableResponse
+	status = get(t, srv, "/table/v1/car/0,0;0.01,0;0.02,0?annotations=distance&sources=0%3B2", nil, &table)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, [][]float32{{0, 1000, 2000}, {2000, 1000, 0}}, table.Distances)
+	assert.Len(t, b.requests(), 4)
+
+	var match gosrm.MatchResponse[string]
+	status = get(t, srv, "/match/v1/car/"+coordinates, nil, &match)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, match.Matchings, 2)
+	assert.Len(t, match.Tracepoints, 5)
+	assert.Equal(t, uint16(1), match.Tracepoints[4].MatchingIndex)
+	assert.Equal(t, uint16(2), match.Tracepoints[4].WaypointIndex)
+
+	// Chunks that can't be matched don't have tracepoints.
+	match = gosrm.MatchResponse[string]{}
+	status = get(t, srv, "/match/v1/car/0,0;0.01,0;0.02,0;-0.03,0;0.04,0", nil, &match)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, match.Matchings, 1)
+	assert.Equal(t, []bool{true, true, true, false, false}, []bool{
+		!match.Tracepoints[0].Omitted(), !match.Tracepoints[1].Omitted(), !match.Tracepoints[2].Omitted(),
+		!match.Tracepoints[3].Omitted(), !match.Tracepoints[4].Omitted(),
+	})
+
+	var res map[string]any
+	status = get(t, srv, "/route/v1/car/"+coordinates+"?waypoints=0%3B4", nil, &res)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, string(CodeTooBig), res["code"])
+}
+
+func TestServer_Errors(t *testing.T) {
+	b := newBackend(t)
+	defer b.Close()
+
+	srv := newTestServer(t, Config{MaxCoordinates: map[string]int{ServiceRoute: 2}}, b)
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		status int
+		code   gosrm.Code
+	}{
+		{"/route/v1/car/0,0;1,1;2,2", http.StatusBadRequest, CodeTooBig},
+		{"/route/v1/car/0,0;1", http.StatusBadRequest, "InvalidUrl"},
+		{"/route/v1/car/0,0;1,100", http.StatusBadRequest, "InvalidUrl"},
+		{"/route/v2/car/0,0;1,1", http.StatusBadRequest, "InvalidUrl"},
+		{"/route/car/0,0;1,1", http.StatusBadRequest, "InvalidUrl"},
+		{"/tile/v1/car/0,0", http.StatusBadRequest, "InvalidService"},
+		{"/table/v1/car/0,0;1,1?sources=5", http.StatusBadRequest, "InvalidOptions"},
+		{"/route/v1/car/0,0;-1,1", http.StatusBadRequest, gosrm.CodeNoRoute},
+	}
+
+	for _, test := range tests {
+		var res map[string]any
+		assert.Equal(t, test.status, get(t, srv, test.path, nil, &res), test.path)
+		assert.Equal(t, string(test.code), res["code"], test.path)
+	}
+
+	res, err := http.Post(srv.URL+"/route/v1/car/0,0;1,1", "application/json", nil)
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	// Backends that can't be reached are bad gateways.
+	b.Close()
+	var body map[string]any
+	assert.Equal(t, http.StatusBadGateway, get(t, srv, "/route/v1/car/0,0;1,1", nil, &body))
+	assert.Equal(t, string(CodeBackendError), body["code"])
+
+	_, err = New(Config{})
+	assert.ErrorIs(t, err, ErrNoBackends)
+}
+
+func TestServer_APIKeys(t *testing.T) {
+	b := newBackend(t)
+	defer b.Close()
+
+	srv := newTestServer(t, Config{APIKeys: map[string]int{"limited": 2, "unlimited": 0}, CacheSize: -1}, b)
+	defer srv.Close()
+
+	var res map[string]any
+	assert.Equal(t, http.StatusUnauthorized, get(t, srv, "/nearest/v1/car/0,0", nil, &res))
+	assert.Equal(t, http.StatusUnauthorized, get(t, srv, "/nearest/v1/car/0,0?api_key=unknown", nil, &res))
+
+	header := http.Header{APIKeyHeader: []string{"limited"}}
+
+	// Invalid requests don't count against the quota.
+	assert.Equal(t, http.StatusBadRequest, get(t, srv, "/nearest/v1/car/invalid", header, &res))
+	assert.Equal(t, http.StatusBadRequest, get(t, srv, "/unknown/v1/car/0,0", header, &res))
+
+	assert.Equal(t, http.StatusOK, get(t, srv, "/nearest/v1/car/0,0", header, &res))
+	assert.Equal(t, http.StatusOK, get(t, srv, "/nearest/v1/car/0,0?api_key=limited", nil, &res))
+	assert.Equal(t, http.StatusTooManyRequests, get(t, srv, "/nearest/v1/car/0,0", header, &res))
+	assert.Equal(t, string(CodeTooManyRequests), res["code"])
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, get(t, srv, "/nearest/v1/car/0,0?api_key=unlimited", nil, &res))
+	}
+
+	// API keys aren't forwarded.
+	for _, q := range b.requests() {
+		assert.False(t, q.Has("api_key"))
+	}
+	assert.Len(t, b.requests(), 5)
+}
+
+func TestServer_Metrics(t *testing.T) {
+	b1, b2 := newBackend(t), newBackend(t)
+	defer b1.Close()
+	defer b2.Close()
+
+	srv := newTestServer(t, Config{ChunkSizes: map[string]int{ServiceRoute: 2}}, b1, b2)
+	defer srv.Close()
+
+	var res map[string]any
+	get(t, srv, "/route/v1/car/0,0;1,1", nil, &res)
+	get(t, srv, "/route/v1/car/0,0;1,1", nil, &res)
+	get(t, srv, "/route/v1/car/0,0;1,0;1,1", nil, &res)
+	get(t, srv, "/nearest/v1/car/0,0", nil, &res)
+	get(t, srv, "/foo", nil, &res)
+
+	// Requests are forwarded to the backends in round-robin order.
+	assert.Len(t, b1.requests(), 2)
+	assert.Len(t, b2.requests(), 2)
+
+	r, err := http.Get(srv.URL + "/metrics")
+	assert.NoError(t, err)
+	defer r.Body.Close()
+
+	var body bytes.Buffer
+	_, err = body.ReadFrom(r.Body)
+	assert.NoError(t, err)
+
+	for _, line := range []string{
+		`gosrm_proxy_requests_total{service="route",status="200"} 3`,
+		`gosrm_proxy_requests_total{service="nearest",status="200"} 1`,
+		`gosrm_proxy_requests_total{service="unknown",status="400"} 1`,
+		`gosrm_proxy_cache_hits_total 1`,
+		`gosrm_proxy_cache_misses_total 3`,
+		`gosrm_proxy_backend_requests_total 4`,
+		`gosrm_proxy_chunked_requests_total 1`,
+	} {
+		assert.Contains(t, body.String(), line)
+	}
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// quotas limits the number of requests of API keys in fixed time windows.
+	// It's safe for concurrent use.
+	quotas struct {
+		limits map[string]int
+		window time.Duration
+		now    func() time.Time
+
+		mu     sync.Mutex
+		usages map[string]*usage
+	}
+
+	// usage is the number of requests of an API key in its current window.
+	usage struct {
+		start time.Time
+		count int
+	}
+)
+
+// newQuotas returns new quotas of API keys, a limit of 0 is unlimited.
+func newQuotas(limits map[string]int, window time.Duration) *quotas {
+	return &quotas{limits: limits, window: window, now: time.Now, usages: make(map[string]*usage)}
+}
+
+// known reports whether the API key is known.
+func (q *quotas) known(key string) bool {
+	_, ok := q.limits[key]
+	return ok
+}
+
+// allow counts a request of an API key and reports whether it's within the quota.
+// Rejected requests aren't counted.
+func (q *quotas) allow(key string) bool {
+	limit := q.limits[key]
+	if limit <= 0 {
+		return true
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	u, ok := q.usages[key]
+	if !ok || now.Sub(u.start) >= q.window {
+		u = &usage{start: now}
+		q.usages[key] = u
+	}
+
+	if u.count >= limit {
+		return false
+	}
+	u.count++
+
+	return true
+}
@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotas(t *testing.T) {
+	now := time.Unix(0, 0)
+	q := newQuotas(map[string]int{"a": 2, "b": 0}, time.Minute)
+	q.now = func() time.Time { return now }
+
+	assert.True(t, q.known("a"))
+	assert.True(t, q.known("b"))
+	assert.False(t, q.known("c"))
+
+	assert.True(t, q.allow("a"))
+	now = now.Add(30 * time.Second)
+	assert.True(t, q.allow("a"))
+	assert.False(t, q.allow("a"))
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, q.allow("b"))
+	}
+
+	// The window starts at the first request, so it's reset a minute after it.
+	now = now.Add(30 * time.Second)
+	assert.True(t, q.allow("a"))
+	assert.True(t, q.allow("a"))
+	assert.False(t, q.allow("a"))
+}
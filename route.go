@@ -19,12 +19,32 @@ type RouteResponse[T GeometryType] struct {
 func Route[T GeometryType](ctx context.Context, osrm OSRMClient, req Request, opts ...Option) (*RouteResponse[T], error) {
 	u := req.buildURLPath(*osrm.baseURL, routeServiceURL)
 
+	osrm.injectHints(u, req)
 	osrm.applyOpts(u, opts)
 
+	format := requestedGeometries(u)
+	if err := checkGeometryType[T](format); err != nil {
+		return nil, err
+	}
+
 	var res RouteResponse[T]
 	if err := osrm.get(ctx, u.String(), &res); err != nil {
 		return nil, err
 	}
 
+	for i := range res.Routes {
+		if err := decodeGeometries(&res.Routes[i], format); err != nil {
+			return nil, err
+		}
+	}
+
+	osrm.updateHints(osrm.hintKeys(req), res.Waypoints, res.Response)
+
 	return &res, nil
 }
+
+// RouteAny is Route with AnyGeometry geometries, which can hold all of the geometry formats,
+// so it doesn't need a type parameter that matches the geometries option.
+func RouteAny(ctx context.Context, osrm OSRMClient, req Request, opts ...Option) (*RouteResponse[AnyGeometry], error) {
+	return Route[AnyGeometry](ctx, osrm, req, opts...)
+}
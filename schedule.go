@@ -0,0 +1,439 @@
+package gosrm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// ErrInvalidSchedule is returned when a schedule request is not valid.
+var ErrInvalidSchedule = errors.New("gosrm: invalid schedule request")
+
+// DefaultMaxRestAreaDetour is the default max detour to rest areas.
+const DefaultMaxRestAreaDetour time.Duration = 15 * time.Minute
+
+// EUDrivingRules are the driving time limits of the EU regulation (EC) No 561/2006.
+var EUDrivingRules = DrivingRules{
+	MaxContinuousDriving: 4*time.Hour + 30*time.Minute,
+	BreakDuration:        45 * time.Minute,
+	MaxDailyDriving:      9 * time.Hour,
+	DailyRestDuration:    11 * time.Hour,
+	MaxDutyPeriod:        13 * time.Hour,
+}
+
+// ScheduleEventKind is the kind of a schedule event.
+type ScheduleEventKind string
+
+const (
+	// ScheduleDriving is driving along a leg.
+	ScheduleDriving ScheduleEventKind = "driving"
+
+	// ScheduleService is the service at a waypoint.
+	ScheduleService ScheduleEventKind = "service"
+
+	// ScheduleBreak is a break after the max continuous driving.
+	ScheduleBreak ScheduleEventKind = "break"
+
+	// ScheduleRest is a daily rest after the max daily driving or duty period.
+	ScheduleRest ScheduleEventKind = "rest"
+)
+
+type (
+	// DrivingRules are the limits of driving time.
+	DrivingRules struct {
+		// MaxContinuousDriving is the max driving before a break.
+		MaxContinuousDriving time.Duration
+
+		// BreakDuration is the duration of breaks.
+		BreakDuration time.Duration
+
+		// MaxDailyDriving is the max driving between daily rests.
+		MaxDailyDriving time.Duration
+
+		// DailyRestDuration is the duration of daily rests.
+		DailyRestDuration time.Duration
+
+		// MaxDutyPeriod is the max duration from the end of a daily rest to the start of the next one,
+		// including driving, services and breaks.
+		MaxDutyPeriod time.Duration
+	}
+
+	// ScheduleRequest is the request of scheduling a route.
+	ScheduleRequest struct {
+		// Start is the time at the first waypoint, before its service.
+		Start time.Time
+
+		// ServiceTimes are the service times at the waypoints, it's either empty or has a duration per waypoint.
+		ServiceTimes []time.Duration
+
+		// Rules are the limits of driving time, zero fields default to the fields of EUDrivingRules.
+		Rules DrivingRules
+
+		// Profile is used to compute the durations to rest areas.
+		Profile Profile
+
+		// Waypoints are the coordinates of the waypoints of the route, they're required with rest areas.
+		Waypoints []Coordinate
+
+		// RestAreas are the locations where breaks and rests can be taken.
+		// If a leg needs a break or a rest, it's taken at the rest area which makes the most progress
+		// within the driving time left and the max detour. Otherwise it's taken on the road.
+		RestAreas []Coordinate
+
+		// MaxRestAreaDetour is the max added duration of driving to a rest area, defaults to DefaultMaxRestAreaDetour.
+		MaxRestAreaDetour time.Duration
+
+		// ChunkSize is the max number of sources and destinations per table request, see TableChunked.
+		ChunkSize int
+	}
+
+	// ScheduleStop is the schedule of a waypoint.
+	ScheduleStop struct {
+		// Waypoint is the index of the waypoint.
+		Waypoint int
+
+		// Arrival is the arrival time at the waypoint.
+		Arrival time.Time
+
+		// Departure is the departure time from the waypoint after its service, breaks and rests.
+		Departure time.Time
+	}
+
+	// ScheduleEvent is an event of the timeline of a schedule.
+	ScheduleEvent struct {
+		// Kind of the event.
+		Kind ScheduleEventKind
+
+		// Start time of the event.
+		Start time.Time
+
+		// End time of the event.
+		End time.Time
+
+		// Leg is the index of the leg the event happens on, it's -1 for events at waypoints.
+		Leg int
+
+		// Waypoint is the index of the waypoint the event happens at, it's -1 for events on legs.
+		Waypoint int
+
+		// RestArea is the index of the rest area of breaks and rests, it's -1 if it's not at a rest area.
+		RestArea int
+
+		// Location of breaks and rests. It's the zero coordinate if it's unknown, e.g. for routes without geometry.
+		Location Coordinate
+	}
+
+	// Schedule is the timeline of a route with breaks and rests.
+	Schedule struct {
+		// Stops are the schedules of the waypoints.
+		Stops []ScheduleStop
+
+		// Events are the events in order.
+		Events []ScheduleEvent
+
+		// End is the departure time from the last waypoint.
+		End time.Time
+
+		// DrivingTime is the total duration of driving.
+		DrivingTime time.Duration
+
+		// ServiceTime is the total duration of services.
+		ServiceTime time.Duration
+
+		// BreakTime is the total duration of breaks.
+		BreakTime time.Duration
+
+		// RestTime is the total duration of daily rests.
+		RestTime time.Duration
+	}
+)
+
+// ScheduleRoute returns the timeline of a route from the start time, with breaks and daily rests inserted
+// when the driving rules require them. Leg durations of the route are used for driving, and services don't count
+// as breaks. If rest areas are given, table service computes the durations between the waypoints and the rest areas,
+// and the durations of legs with rest areas are the durations of table service.
+// Locations of breaks and rests on the road need a full overview geometry or steps, see NewRouteTracker.
+// Options are passed to table service.
+func ScheduleRoute[T GeometryType](ctx context.Context, osrm OSRMClient, route RouteType[T], req ScheduleRequest, opts ...Option) (*Schedule, error) {
+	req.Rules = req.Rules.withDefaults()
+	if req.MaxRestAreaDetour == 0 {
+		req.MaxRestAreaDetour = DefaultMaxRestAreaDetour
+	}
+
+	waypoints := len(route.Legs) + 1
+	if len(route.Legs) == 0 {
+		return nil, fmt.Errorf("%w: route has no legs", ErrInvalidSchedule)
+	}
+	if len(req.ServiceTimes) > 0 && len(req.ServiceTimes) != waypoints {
+		return nil, fmt.Errorf("%w: service times must have a duration per waypoint", ErrInvalidSchedule)
+	}
+	if len(req.Waypoints) > 0 && len(req.Waypoints) != waypoints {
+		return nil, fmt.Errorf("%w: waypoints don't match the legs", ErrInvalidSchedule)
+	}
+	if len(req.RestAreas) > 0 && len(req.Waypoints) == 0 {
+		return nil, fmt.Errorf("%w: waypoints are required with rest areas", ErrInvalidSchedule)
+	}
+	if err := req.Rules.validate(); err != nil {
+		return nil, err
+	}
+
+	s := scheduler{req: req, waypoints: waypoints, now: req.Start, dutyStart: req.Start}
+
+	// Nodes of the table are the waypoints and then the rest areas.
+	if len(req.RestAreas) > 0 {
+		coordinates := append(append([]Coordinate{}, req.Waypoints...), req.RestAreas...)
+		indices := allIndices(len(coordinates))
+
+		table, err := TableChunked(ctx, osrm, Request{Profile: req.Profile, Coordinates: coordinates},
+			indices, indices, req.ChunkSize, append(slices.Clone(opts), WithAnnotations(AnnotationsDuration))...)
+		if err != nil {
+			return nil, err
+		}
+		s.durations = table.Durations
+	}
+
+	// The tracker locates breaks and rests on the road.
+	if tracker, err := NewRouteTracker(route, TrackerConfig{}); err == nil {
+		s.tracker = tracker
+	}
+
+	var elapsed float64
+	for k := range waypoints {
+		s.arrive(k)
+
+		if k == waypoints-1 {
+			break
+		}
+
+		leg := route.Legs[k]
+		s.driveLeg(k, seconds(float64(leg.Duration)), elapsed)
+		elapsed += float64(leg.Duration)
+	}
+
+	s.schedule.End = s.now
+
+	return &s.schedule, nil
+}
+
+// withDefaults returns the rules with the fields of EUDrivingRules for zero fields.
+func (r DrivingRules) withDefaults() DrivingRules {
+	if r.MaxContinuousDriving == 0 {
+		r.MaxContinuousDriving = EUDrivingRules.MaxContinuousDriving
+	}
+	if r.BreakDuration == 0 {
+		r.BreakDuration = EUDrivingRules.BreakDuration
+	}
+	if r.MaxDailyDriving == 0 {
+		r.MaxDailyDriving = EUDrivingRules.MaxDailyDriving
+	}
+	if r.DailyRestDuration == 0 {
+		r.DailyRestDuration = EUDrivingRules.DailyRestDuration
+	}
+	if r.MaxDutyPeriod == 0 {
+		r.MaxDutyPeriod = EUDrivingRules.MaxDutyPeriod
+	}
+	return r
+}
+
+// validate returns ErrInvalidSchedule if the rules are not valid.
+func (r DrivingRules) validate() error {
+	if r.MaxContinuousDriving < 0 || r.BreakDuration < 0 || r.MaxDailyDriving < 0 || r.DailyRestDuration < 0 || r.MaxDutyPeriod < 0 {
+		return fmt.Errorf("%w: negative driving rules", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// seconds returns the duration of s seconds.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// scheduler builds the timeline of a route.
+type scheduler struct {
+	req ScheduleRequest
+
+	// durations are the durations of table service between the waypoints and the rest areas, if there are any.
+	durations [][]float32
+	tracker   *RouteTracker
+	waypoints int
+
+	now time.Time
+
+	// driving and daily are the driving since the last break and the last daily rest.
+	driving, daily time.Duration
+
+	// dutyStart is the end of the last daily rest.
+	dutyStart time.Time
+
+	schedule Schedule
+}
+
+// allowance returns the driving time left before a stop and the kind of that stop.
+func (s *scheduler) allowance() (time.Duration, ScheduleEventKind) {
+	allowed, kind := s.req.Rules.MaxContinuousDriving-s.driving, ScheduleBreak
+	if d := s.req.Rules.MaxDailyDriving - s.daily; d <= allowed {
+		allowed, kind = d, ScheduleRest
+	}
+	if d := s.dutyStart.Add(s.req.Rules.MaxDutyPeriod).Sub(s.now); d <= allowed {
+		allowed, kind = d, ScheduleRest
+	}
+	return max(allowed, 0), kind
+}
+
+// event appends an event which starts now and moves the time to its end.
+func (s *scheduler) event(e ScheduleEvent, d time.Duration) {
+	e.Start, e.End = s.now, s.now.Add(d)
+	s.schedule.Events = append(s.schedule.Events, e)
+	s.now = e.End
+}
+
+// stop takes a break or a daily rest.
+func (s *scheduler) stop(kind ScheduleEventKind, e ScheduleEvent) {
+	e.Kind = kind
+
+	if kind == ScheduleBreak {
+		s.event(e, s.req.Rules.BreakDuration)
+		s.schedule.BreakTime += s.req.Rules.BreakDuration
+		s.driving = 0
+		return
+	}
+
+	s.event(e, s.req.Rules.DailyRestDuration)
+	s.schedule.RestTime += s.req.Rules.DailyRestDuration
+	s.driving, s.daily, s.dutyStart = 0, 0, s.now
+}
+
+// arrive schedules the service of a waypoint, with a daily rest before it if it doesn't fit in the duty period.
+// A break or rest is taken before leaving the waypoint if no driving time is left.
+func (s *scheduler) arrive(waypoint int) {
+	stop := ScheduleStop{Waypoint: waypoint, Arrival: s.now}
+	at := ScheduleEvent{Leg: -1, Waypoint: waypoint, RestArea: -1}
+	if len(s.req.Waypoints) > 0 {
+		at.Location = s.req.Waypoints[waypoint]
+	}
+
+	var service time.Duration
+	if len(s.req.ServiceTimes) > 0 {
+		service = s.req.ServiceTimes[waypoint]
+	}
+
+	if service > 0 {
+		if s.now.Add(service).After(s.dutyStart.Add(s.req.Rules.MaxDutyPeriod)) && s.now.After(s.dutyStart) {
+			s.stop(ScheduleRest, at)
+		}
+
+		e := at
+		e.Kind = ScheduleService
+		s.event(e, service)
+		s.schedule.ServiceTime += service
+	}
+
+	// Breaks and rests needed before leaving are taken at the waypoint.
+	if waypoint < s.waypoints-1 {
+		if allowed, kind := s.allowance(); allowed <= 0 {
+			s.stop(kind, at)
+		}
+	}
+
+	stop.Departure = s.now
+	s.schedule.Stops = append(s.schedule.Stops, stop)
+}
+
+// driveLeg drives the k-th leg which starts at elapsed seconds along the route.
+// Breaks and rests are taken at rest areas if there are any that fit, otherwise on the road.
+func (s *scheduler) driveLeg(k int, duration time.Duration, elapsed float64) {
+	from, to := k, k+1
+
+	for len(s.durations) > 0 {
+		allowed, kind := s.allowance()
+		if duration <= allowed {
+			break
+		}
+
+		area, ok := s.restArea(from, to, duration, allowed)
+		if !ok {
+			break
+		}
+
+		s.drive(k, s.tableDuration(from, s.waypoints+area), nil)
+		s.stop(kind, ScheduleEvent{Leg: k, Waypoint: -1, RestArea: area, Location: s.req.RestAreas[area]})
+
+		from = s.waypoints + area
+		duration = s.tableDuration(from, to)
+	}
+
+	// Only drives from the waypoint follow the geometry of the route.
+	var locate func(time.Duration) Coordinate
+	if s.tracker != nil && from == k {
+		locate = func(d time.Duration) Coordinate {
+			return s.tracker.locateElapsed(elapsed + d.Seconds())
+		}
+	}
+
+	s.drive(k, duration, locate)
+}
+
+// drive drives on the k-th leg for a duration, with breaks and rests on the road when no driving time is left.
+func (s *scheduler) drive(k int, duration time.Duration, locate func(time.Duration) Coordinate) {
+	var done time.Duration
+	for done < duration {
+		allowed, kind := s.allowance()
+		if allowed <= 0 {
+			e := ScheduleEvent{Leg: k, Waypoint: -1, RestArea: -1}
+			if locate != nil {
+				e.Location = locate(done)
+			}
+			s.stop(kind, e)
+			continue
+		}
+
+		d := min(duration-done, allowed)
+		s.event(ScheduleEvent{Kind: ScheduleDriving, Leg: k, Waypoint: -1, RestArea: -1}, d)
+		s.schedule.DrivingTime += d
+		s.driving += d
+		s.daily += d
+		done += d
+	}
+}
+
+// restArea returns the rest area which makes the most progress from node from to node to,
+// within the allowed driving time and the max detour.
+func (s *scheduler) restArea(from, to int, duration, allowed time.Duration) (int, bool) {
+	best, progress := -1, time.Duration(0)
+	var bestDetour time.Duration
+
+	for area := range s.req.RestAreas {
+		node := s.waypoints + area
+		if node == from {
+			continue
+		}
+
+		there, rest := s.tableDuration(from, node), s.tableDuration(node, to)
+		if there > allowed || rest >= duration {
+			continue
+		}
+
+		detour := there + rest - duration
+		if detour > s.req.MaxRestAreaDetour {
+			continue
+		}
+
+		if p := duration - rest; best < 0 || p > progress || (p == progress && detour < bestDetour) {
+			best, progress, bestDetour = area, p, detour
+		}
+	}
+
+	return best, best >= 0
+}
+
+// tableDuration returns the duration of table service between two nodes, it's math.MaxInt64 if there's no route.
+func (s *scheduler) tableDuration(from, to int) time.Duration {
+	d := float64(tableValue(s.durations, from, to))
+	if math.IsInf(d, 1) {
+		return math.MaxInt64
+	}
+	return seconds(d)
+}
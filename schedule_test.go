@@ -0,0 +1,129 @@
+package gosrm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleRoute(t *testing.T) {
+	route := RouteType[LineString]{
+		Geometry: LineString{Coordinates: []Coordinate{{0, 0}, {0.3, 0}, {0.6, 0}, {1, 0}}},
+		Legs: []RouteLeg[LineString]{
+			{Distance: 3, Duration: 3 * 3600},
+			{Distance: 3, Duration: 3 * 3600},
+			{Distance: 4, Duration: 4 * 3600},
+		},
+	}
+
+	start := time.Date(2026, 1, 5, 6, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time {
+		return start.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+	}
+
+	s, err := ScheduleRoute(context.Background(), OSRMClient{}, route, ScheduleRequest{
+		Start:        start,
+		ServiceTimes: []time.Duration{0, 30 * time.Minute, 30 * time.Minute, 10 * time.Minute},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []ScheduleStop{
+		{Waypoint: 0, Arrival: at(0, 0), Departure: at(0, 0)},
+		{Waypoint: 1, Arrival: at(3, 0), Departure: at(3, 30)},
+		{Waypoint: 2, Arrival: at(7, 15), Departure: at(7, 45)},
+		{Waypoint: 3, Arrival: at(22, 45), Departure: at(22, 55)},
+	}, s.Stops)
+	assert.Equal(t, at(22, 55), s.End)
+	assert.Equal(t, 10*time.Hour, s.DrivingTime)
+	assert.Equal(t, 70*time.Minute, s.ServiceTime)
+	assert.Equal(t, 45*time.Minute, s.BreakTime)
+	assert.Equal(t, 11*time.Hour, s.RestTime)
+
+	var kinds []ScheduleEventKind
+	for _, e := range s.Events {
+		kinds = append(kinds, e.Kind)
+	}
+	assert.Equal(t, []ScheduleEventKind{
+		ScheduleDriving, ScheduleService, ScheduleDriving, ScheduleBreak, ScheduleDriving,
+		ScheduleService, ScheduleDriving, ScheduleRest, ScheduleDriving, ScheduleService,
+	}, kinds)
+
+	// The break is halfway through the second leg and the rest is after 3 hours of the third leg.
+	brk := s.Events[3]
+	assert.Equal(t, 1, brk.Leg)
+	assert.Equal(t, -1, brk.Waypoint)
+	assert.Equal(t, -1, brk.RestArea)
+	assert.Equal(t, at(5, 0), brk.Start)
+	assert.Equal(t, at(5, 45), brk.End)
+	assert.InDelta(t, 0.45, brk.Location[0], 1e-6)
+
+	rest := s.Events[7]
+	assert.Equal(t, 2, rest.Leg)
+	assert.Equal(t, at(10, 45), rest.Start)
+	assert.InDelta(t, 0.9, rest.Location[0], 1e-6)
+
+	// Breaks at waypoints are taken before leaving them.
+	s, err = ScheduleRoute(context.Background(), OSRMClient{}, route, ScheduleRequest{
+		Start: start,
+		Rules: DrivingRules{MaxContinuousDriving: 3 * time.Hour, BreakDuration: 30 * time.Minute},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ScheduleBreak, s.Events[1].Kind)
+	assert.Equal(t, 1, s.Events[1].Waypoint)
+	assert.Equal(t, at(3, 30), s.Stops[1].Departure)
+
+	for _, invalid := range []ScheduleRequest{
+		{ServiceTimes: []time.Duration{0}},
+		{Waypoints: []Coordinate{{0, 0}}},
+		{RestAreas: []Coordinate{{0, 0}}},
+		{Rules: DrivingRules{BreakDuration: -1}},
+	} {
+		_, err := ScheduleRoute(context.Background(), OSRMClient{}, route, invalid)
+		assert.ErrorIs(t, err, ErrInvalidSchedule)
+	}
+
+	_, err = ScheduleRoute(context.Background(), OSRMClient{}, RouteType[LineString]{}, ScheduleRequest{})
+	assert.ErrorIs(t, err, ErrInvalidSchedule)
+}
+
+func TestScheduleRoute_RestAreas(t *testing.T) {
+	var requests int32
+	srv := newTableTestServer(t, &requests)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	start := time.Date(2026, 1, 5, 6, 0, 0, 0, time.UTC)
+	route := RouteType[string]{Legs: []RouteLeg[string]{{Duration: 7000}}}
+
+	s, err := ScheduleRoute(context.Background(), osrm, route, ScheduleRequest{
+		Start:     start,
+		Rules:     DrivingRules{MaxContinuousDriving: time.Hour, BreakDuration: 10 * time.Minute},
+		Profile:   ProfileCar,
+		Waypoints: []Coordinate{{0, 0}, {0.7, 0}},
+		RestAreas: []Coordinate{
+			{0.3, 0},      // 0: less progress
+			{-0.1, 0},     // 1: unreachable
+			{0.34, 0.001}, // 2: first break
+			{0.65, 0},     // 3: second break
+		},
+		ChunkSize: 3,
+	})
+	assert.NoError(t, err)
+	assert.Greater(t, requests, int32(1))
+
+	assert.Len(t, s.Events, 5)
+	assert.Equal(t, 3410*time.Second, s.Events[0].End.Sub(s.Events[0].Start))
+	assert.Equal(t, 2, s.Events[1].RestArea)
+	assert.Equal(t, Coordinate{0.34, 0.001}, s.Events[1].Location)
+	assert.Equal(t, 3110*time.Second, s.Events[2].End.Sub(s.Events[2].Start))
+	assert.Equal(t, 3, s.Events[3].RestArea)
+	assert.Equal(t, 500*time.Second, s.Events[4].End.Sub(s.Events[4].Start))
+
+	assert.Equal(t, 7020*time.Second, s.DrivingTime)
+	assert.Equal(t, 20*time.Minute, s.BreakTime)
+	assert.Equal(t, start.Add(7020*time.Second+20*time.Minute), s.Stops[1].Arrival)
+}
@@ -0,0 +1,249 @@
+// Package speedfile builds OSRM segment speed files from matched GPS traces.
+// Observed speeds of node pairs are aggregated from match responses, optionally by time of day, and written as
+// CSV files (from_osm_id,to_osm_id,speed) for the --segment-speed-file flag of osrm-customize and osrm-contract.
+package speedfile
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mojixcoder/gosrm"
+)
+
+// ErrInvalidMatch is returned when a match response can't be aggregated with its timestamps.
+var ErrInvalidMatch = errors.New("speedfile: invalid match response")
+
+const (
+	// DefaultMinSamples is the default min number of traversals of a node pair before its speed is written.
+	DefaultMinSamples int = 3
+
+	// DefaultMaxSpeed is the default max observed speed of a node pair, faster samples are dropped, in km/h.
+	DefaultMaxSpeed float64 = 200
+)
+
+type (
+	// Config is the config of a builder.
+	Config struct {
+		// MinSamples is the min number of traversals of a node pair before its speed is written.
+		// Defaults to DefaultMinSamples.
+		MinSamples int
+
+		// MinConfidence is the min confidence of matchings that are aggregated, between 0 and 1.
+		MinConfidence float32
+
+		// MaxSpeed is the max observed speed of a node pair, faster samples are dropped, in km/h.
+		// Defaults to DefaultMaxSpeed.
+		MaxSpeed float64
+
+		// Buckets is the number of equal time of day buckets speeds are aggregated in, e.g. 24 for hourly speeds.
+		// If it's 0 then all speeds are aggregated in one bucket.
+		Buckets int
+
+		// Location is the time zone of time of day buckets. Defaults to UTC.
+		Location *time.Location
+	}
+
+	// Segment is the observed speed of a node pair.
+	Segment struct {
+		// From is the OSM node ID the segment starts at.
+		From uint64
+
+		// To is the OSM node ID the segment ends at.
+		To uint64
+
+		// Speed is the observed speed, in km/h.
+		Speed float64
+
+		// Samples is the number of traversals of the segment.
+		Samples int
+	}
+
+	// Builder aggregates observed speeds of node pairs from match responses.
+	// It's safe for concurrent use.
+	Builder struct {
+		config Config
+
+		mu      sync.Mutex
+		samples map[key]*sample
+	}
+
+	// key is a node pair in a time of day bucket.
+	key struct {
+		from, to uint64
+		bucket   int
+	}
+
+	// sample is the aggregated traversals of a node pair.
+	sample struct {
+		distance, duration float64
+		count              int
+	}
+)
+
+// NewBuilder returns a new speed file builder.
+func NewBuilder(config Config) *Builder {
+	if config.MinSamples <= 0 {
+		config.MinSamples = DefaultMinSamples
+	}
+	if config.MaxSpeed <= 0 {
+		config.MaxSpeed = DefaultMaxSpeed
+	}
+	if config.Buckets <= 0 {
+		config.Buckets = 1
+	}
+	if config.Location == nil {
+		config.Location = time.UTC
+	}
+
+	return &Builder{config: config, samples: make(map[key]*sample)}
+}
+
+// Add aggregates the matchings of a match response in the builder.
+// The response needs nodes, duration and distance annotations, see gosrm.AnnotationsNodes.
+// timestamps are the timestamps of the matched points in UNIX seconds, aligned to the tracepoints.
+// The observed travel time of each leg is spread over its node pairs in proportion to their annotated durations.
+func Add[T gosrm.GeometryType](b *Builder, res *gosrm.MatchResponse[T], timestamps []int64) error {
+	if len(timestamps) != len(res.Tracepoints) {
+		return fmt.Errorf("%w: %d timestamps for %d tracepoints", ErrInvalidMatch, len(timestamps), len(res.Tracepoints))
+	}
+
+	// starts[m][w] is the timestamp of the w-th waypoint of the m-th matching.
+	starts := make([]map[int]int64, len(res.Matchings))
+	for i, tp := range res.Tracepoints {
+		if tp.Omitted() {
+			continue
+		}
+		if int(tp.MatchingIndex) >= len(res.Matchings) {
+			return fmt.Errorf("%w: invalid matching index %d", ErrInvalidMatch, tp.MatchingIndex)
+		}
+		if starts[tp.MatchingIndex] == nil {
+			starts[tp.MatchingIndex] = make(map[int]int64)
+		}
+		starts[tp.MatchingIndex][int(tp.WaypointIndex)] = timestamps[i]
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for m, matching := range res.Matchings {
+		if matching.Confidence < b.config.MinConfidence {
+			continue
+		}
+
+		for w, leg := range matching.Legs {
+			start, ok := starts[m][w]
+			end, ok2 := starts[m][w+1]
+			if !ok || !ok2 || end <= start {
+				continue
+			}
+
+			b.addLeg(leg.Annotation, float64(end-start), start)
+		}
+	}
+
+	return nil
+}
+
+// addLeg aggregates the node pairs of a leg traveled in observed seconds starting at a timestamp.
+// The caller must hold the lock.
+func (b *Builder) addLeg(a gosrm.Annotation, observed float64, timestamp int64) {
+	n := len(a.Distance)
+	if len(a.Duration) != n || len(a.Nodes) != n+1 {
+		return
+	}
+
+	var expected float64
+	for _, d := range a.Duration {
+		expected += float64(d)
+	}
+	if expected <= 0 {
+		return
+	}
+
+	bucket := b.bucket(timestamp)
+	for i := range n {
+		duration := observed * float64(a.Duration[i]) / expected
+		distance := float64(a.Distance[i])
+		if a.Nodes[i] == a.Nodes[i+1] || duration <= 0 || distance <= 0 || kmh(distance, duration) > b.config.MaxSpeed {
+			continue
+		}
+
+		k := key{from: a.Nodes[i], to: a.Nodes[i+1], bucket: bucket}
+		s, ok := b.samples[k]
+		if !ok {
+			s = &sample{}
+			b.samples[k] = s
+		}
+		s.distance += distance
+		s.duration += duration
+		s.count++
+	}
+}
+
+// bucket returns the time of day bucket of a timestamp.
+func (b *Builder) bucket(timestamp int64) int {
+	t := time.Unix(timestamp, 0).In(b.config.Location)
+	seconds := t.Hour()*3600 + t.Minute()*60 + t.Second()
+
+	return seconds * b.config.Buckets / 86400
+}
+
+// Buckets returns the number of time of day buckets.
+func (b *Builder) Buckets() int {
+	return b.config.Buckets
+}
+
+// Segments returns the node pairs of a time of day bucket with enough samples, sorted by their nodes.
+// Speeds are the aggregated distance divided by the aggregated duration.
+func (b *Builder) Segments(bucket int) []Segment {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var segments []Segment
+	for k, s := range b.samples {
+		if k.bucket != bucket || s.count < b.config.MinSamples {
+			continue
+		}
+		segments = append(segments, Segment{From: k.from, To: k.to, Speed: kmh(s.distance, s.duration), Samples: s.count})
+	}
+
+	slices.SortFunc(segments, func(a, b Segment) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To))
+	})
+
+	return segments
+}
+
+// Write writes the speed file of a time of day bucket as CSV.
+// Speeds are rounded to whole km/h and are at least 1, since a speed of 0 closes the segment in OSRM.
+func (b *Builder) Write(w io.Writer, bucket int) error {
+	cw := csv.NewWriter(w)
+
+	for _, s := range b.Segments(bucket) {
+		speed := max(math.Round(s.Speed), 1)
+		record := []string{
+			strconv.FormatUint(s.From, 10),
+			strconv.FormatUint(s.To, 10),
+			strconv.FormatFloat(speed, 'f', 0, 64),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// kmh returns the speed of a distance in meters traveled in seconds, in km/h.
+func kmh(distance, seconds float64) float64 {
+	return distance / seconds * 3.6
+}
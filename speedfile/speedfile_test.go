@@ -0,0 +1,80 @@
+package speedfile
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/mojixcoder/gosrm"
+	"github.com/stretchr/testify/assert"
+)
+
+// testMatch returns a match response of 3 points with 2 legs.
+// The first leg goes through nodes 1, 2 and 3 and the second one through nodes 3 and 4.
+func testMatch(confidence float32) *gosrm.MatchResponse[string] {
+	return &gosrm.MatchResponse[string]{
+		Tracepoints: []gosrm.Tracepoint{{WaypointIndex: 0, Waypoint: gosrm.Waypoint{Hint: "a"}}, {WaypointIndex: 1}, {WaypointIndex: 2}},
+		Matchings: []gosrm.Matching[string]{{
+			Confidence: confidence,
+			RouteType: gosrm.RouteType[string]{Legs: []gosrm.RouteLeg[string]{
+				{Annotation: gosrm.Annotation{Nodes: []uint64{1, 2, 3}, Distance: []float32{100, 100}, Duration: []float32{10, 30}}},
+				{Annotation: gosrm.Annotation{Nodes: []uint64{3, 4}, Distance: []float32{200}, Duration: []float32{10}}},
+			}},
+		}},
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(Config{})
+	assert.Equal(t, 1, b.Buckets())
+
+	for i := 0; i < 3; i++ {
+		assert.Empty(t, b.Segments(0))
+		assert.NoError(t, Add(b, testMatch(1), []int64{0, 20, 40}))
+	}
+
+	// The observed 20 seconds of the first leg are spread as 5 and 15 seconds.
+	assert.Equal(t, []Segment{
+		{From: 1, To: 2, Speed: 72, Samples: 3},
+		{From: 2, To: 3, Speed: 24, Samples: 3},
+		{From: 3, To: 4, Speed: 36, Samples: 3},
+	}, b.Segments(0))
+
+	var buf bytes.Buffer
+	assert.NoError(t, b.Write(&buf, 0))
+	assert.Equal(t, "1,2,72\n2,3,24\n3,4,36\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, b.Write(&buf, 1))
+	assert.Empty(t, buf.String())
+
+	err := Add(b, testMatch(1), []int64{0, 20})
+	assert.ErrorIs(t, err, ErrInvalidMatch)
+}
+
+func TestBuilder_Filters(t *testing.T) {
+	b := NewBuilder(Config{MinSamples: 1, MinConfidence: 0.8, MaxSpeed: 50})
+
+	assert.NoError(t, Add(b, testMatch(0.5), []int64{0, 20, 40}))
+	assert.Empty(t, b.Segments(0))
+
+	assert.NoError(t, Add(b, testMatch(0.9), []int64{0, 20, 40}))
+	assert.Equal(t, []Segment{{From: 2, To: 3, Speed: 24, Samples: 1}, {From: 3, To: 4, Speed: 36, Samples: 1}}, b.Segments(0))
+
+	// Legs without the timestamps of both their points are skipped.
+	b = NewBuilder(Config{MinSamples: 1})
+	res := testMatch(1)
+	res.Tracepoints[2] = gosrm.Tracepoint{}
+	assert.NoError(t, Add(b, res, []int64{0, 20, 40}))
+	assert.Len(t, b.Segments(0), 2)
+}
+
+func TestBuilder_Buckets(t *testing.T) {
+	b := NewBuilder(Config{MinSamples: 1, Buckets: 24, Location: time.FixedZone("UTC+1", 3600)})
+	assert.Equal(t, 24, b.Buckets())
+
+	start := int64(5*3600 + 1800)
+	assert.NoError(t, Add(b, testMatch(1), []int64{start, start + 20, start + 40}))
+	assert.Empty(t, b.Segments(5))
+	assert.Len(t, b.Segments(6), 3)
+}
@@ -2,6 +2,10 @@ package gosrm
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
 )
 
 // tableServiceURL is the base path of OSRM route service.
@@ -31,10 +35,71 @@ type TableResponse struct {
 	FallbackSpeedCells [][]uint16 `json:"fallback_speed_cells"`
 }
 
+// tableCell is a value of a matrix of table service, null is decoded as positive infinity.
+type tableCell float32
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (v *tableCell) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*v = tableCell(math.Inf(1))
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(string(data), 32)
+	if err != nil {
+		return fmt.Errorf("gosrm: invalid table value %s", data)
+	}
+	*v = tableCell(f)
+	return nil
+}
+
+// tableResponseInf is a TableResponse whose null values of the matrices are decoded as positive infinity.
+type tableResponseInf struct {
+	TableResponse
+
+	Durations [][]tableCell `json:"durations"`
+	Distances [][]tableCell `json:"distances"`
+}
+
+// response returns the TableResponse with the matrices.
+func (res tableResponseInf) response() *TableResponse {
+	r := res.TableResponse
+	r.Durations = tableMatrix(res.Durations)
+	r.Distances = tableMatrix(res.Distances)
+	return &r
+}
+
+// tableMatrix converts the values of a matrix to float32.
+func tableMatrix(m [][]tableCell) [][]float32 {
+	if m == nil {
+		return nil
+	}
+
+	out := make([][]float32, len(m))
+	for i, row := range m {
+		out[i] = tableRow(row)
+	}
+	return out
+}
+
+// tableRow converts the values of a row to float32.
+func tableRow(row []tableCell) []float32 {
+	if row == nil {
+		return nil
+	}
+
+	out := make([]float32, len(row))
+	for j, v := range row {
+		out[j] = float32(v)
+	}
+	return out
+}
+
 // Table computes the duration of the fastest route between all pairs of supplied coordinates.
 func Table(ctx context.Context, osrm OSRMClient, req Request, opts ...Option) (*TableResponse, error) {
 	u := req.buildURLPath(*osrm.baseURL, tableServiceURL)
 
+	osrm.injectHints(u, req)
 	osrm.applyOpts(u, opts)
 
 	var res TableResponse
@@ -42,5 +107,33 @@ func Table(ctx context.Context, osrm OSRMClient, req Request, opts ...Option) (*
 		return nil, err
 	}
 
+	osrm.updateTableHints(u, req, &res)
+
 	return &res, nil
 }
+
+// tableInf is Table with the values of the pairs that can't be routed as positive infinity instead of 0.
+func tableInf(ctx context.Context, osrm OSRMClient, req Request, opts ...Option) (*TableResponse, error) {
+	u := req.buildURLPath(*osrm.baseURL, tableServiceURL)
+
+	osrm.injectHints(u, req)
+	osrm.applyOpts(u, opts)
+
+	var v tableResponseInf
+	if err := osrm.get(ctx, u.String(), &v); err != nil {
+		return nil, err
+	}
+
+	res := v.response()
+	osrm.updateTableHints(u, req, res)
+
+	return res, nil
+}
+
+// updateTableHints stores hints of the sources and destinations of a table response if a hint store is set.
+func (osrm OSRMClient) updateTableHints(u *url.URL, req Request, res *TableResponse) {
+	if keys := osrm.hintKeys(req); keys != nil {
+		osrm.updateHints(selectKeys(u, "sources", keys), res.Sources, res.Response)
+		osrm.updateHints(selectKeys(u, "destinations", keys), res.Destinations, res.Response)
+	}
+}
@@ -0,0 +1,148 @@
+package gosrm
+
+import (
+	"context"
+	"math"
+)
+
+// DefaultTableChunkSize is the default max number of sources and destinations of each table block.
+// It's the default max table size of OSRM.
+const DefaultTableChunkSize int = 100
+
+// TableChunked computes the table between the given sources and destinations in blocks of
+// at most chunkSize sources by chunkSize destinations, so tables bigger than the max table size of OSRM can be computed.
+// sources and destinations are indices of the request coordinates, nil means all of the coordinates.
+// If chunkSize is not positive, DefaultTableChunkSize is used.
+// Blocks are requested one after another, options are applied to all of them, sources and destinations options are set per block.
+// An error is returned if any of the blocks fails, including a ResponseError if OSRM can't process a block.
+// Unlike Table, values of the pairs that can't be routed are positive infinity, as they're also missing blocks.
+func TableChunked(ctx context.Context, osrm OSRMClient, req Request, sources, destinations []int, chunkSize int, opts ...Option) (*TableResponse, error) {
+	if sources == nil {
+		sources = allIndices(len(req.Coordinates))
+	}
+	if destinations == nil {
+		destinations = allIndices(len(req.Coordinates))
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultTableChunkSize
+	}
+
+	// waypoints is false if waypoints are missing in the blocks, e.g. when they're skipped.
+	waypoints := true
+
+	res := TableResponse{
+		Response:     Response{Code: CodeOK},
+		Sources:      make([]Waypoint, len(sources)),
+		Destinations: make([]Waypoint, len(destinations)),
+	}
+
+	for si := 0; si < len(sources); si += chunkSize {
+		srcChunk := sources[si:min(si+chunkSize, len(sources))]
+
+		for di := 0; di < len(destinations); di += chunkSize {
+			dstChunk := destinations[di:min(di+chunkSize, len(destinations))]
+
+			block, err := tableBlock(ctx, osrm, req, srcChunk, dstChunk, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			res.Response = block.Response
+			res.Durations = mergeTableBlock(res.Durations, block.Durations, len(sources), len(destinations), si, di)
+			res.Distances = mergeTableBlock(res.Distances, block.Distances, len(sources), len(destinations), si, di)
+
+			if len(block.Sources) != len(srcChunk) || len(block.Destinations) != len(dstChunk) {
+				waypoints = false
+			} else {
+				copy(res.Sources[si:], block.Sources)
+				copy(res.Destinations[di:], block.Destinations)
+			}
+
+			for _, cell := range block.FallbackSpeedCells {
+				if len(cell) == 2 {
+					res.FallbackSpeedCells = append(res.FallbackSpeedCells, []uint16{cell[0] + uint16(si), cell[1] + uint16(di)})
+				}
+			}
+		}
+	}
+
+	if !waypoints {
+		res.Sources, res.Destinations = nil, nil
+	}
+
+	return &res, nil
+}
+
+// tableBlock computes the table of a block of sources and destinations.
+func tableBlock(ctx context.Context, osrm OSRMClient, req Request, sources, destinations []int, opts []Option) (*TableResponse, error) {
+	block := Request{Profile: req.Profile}
+	withIDs := len(req.IDs) == len(req.Coordinates)
+
+	srcIndices := make([]uint16, len(sources))
+	dstIndices := make([]uint16, len(destinations))
+
+	for i, idx := range sources {
+		srcIndices[i] = uint16(len(block.Coordinates))
+		block.Coordinates = append(block.Coordinates, req.Coordinates[idx])
+		if withIDs {
+			block.IDs = append(block.IDs, req.IDs[idx])
+		}
+	}
+
+	for i, idx := range destinations {
+		dstIndices[i] = uint16(len(block.Coordinates))
+		block.Coordinates = append(block.Coordinates, req.Coordinates[idx])
+		if withIDs {
+			block.IDs = append(block.IDs, req.IDs[idx])
+		}
+	}
+
+	blockOpts := make([]Option, 0, len(opts)+2)
+	blockOpts = append(blockOpts, opts...)
+	blockOpts = append(blockOpts, WithSources(srcIndices), WithDestinations(dstIndices))
+
+	res, err := tableInf(ctx, osrm, block, blockOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// mergeTableBlock copies the values of a block to the matrix at the given offset.
+// The matrix is allocated with infinite values if it's nil.
+func mergeTableBlock(m, block [][]float32, rows, cols, rowOffset, colOffset int) [][]float32 {
+	if block == nil {
+		return m
+	}
+
+	if m == nil {
+		inf := float32(math.Inf(1))
+		m = make([][]float32, rows)
+		for i := range m {
+			m[i] = make([]float32, cols)
+			for j := range m[i] {
+				m[i][j] = inf
+			}
+		}
+	}
+
+	for i, row := range block {
+		copy(m[rowOffset+i][colOffset:], row)
+	}
+
+	return m
+}
+
+// allIndices returns the indices from 0 to n-1.
+func allIndices(n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
@@ -0,0 +1,175 @@
+package gosrm
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTableDistance is the distance used by the table test server, manhattan distance of degrees in 100km.
+func testTableDistance(a, b Coordinate) float32 {
+	return float32((math.Abs(a[0]-b[0]) + math.Abs(a[1]-b[1])) * 100000)
+}
+
+// parseTestCoordinates parses coordinates of an OSRM request path.
+func parseTestCoordinates(path string) []Coordinate {
+	parts := strings.Split(path, "/")
+	s := strings.TrimSuffix(parts[len(parts)-1], ".json")
+
+	var coordinates []Coordinate
+	for _, pair := range strings.Split(s, ";") {
+		lngLat := strings.Split(pair, ",")
+		lng, _ := strconv.ParseFloat(lngLat[0], 64)
+		lat, _ := strconv.ParseFloat(lngLat[1], 64)
+		coordinates = append(coordinates, Coordinate{lng, lat})
+	}
+
+	return coordinates
+}
+
+// parseTestIndices parses indices of sources or destinations params.
+func parseTestIndices(v string, n int) []int {
+	if v == "" || v == "all" {
+		return allIndices(n)
+	}
+
+	var indices []int
+	for _, s := range strings.Split(v, ";") {
+		idx, _ := strconv.Atoi(s)
+		indices = append(indices, idx)
+	}
+
+	return indices
+}
+
+// newTableTestServer returns a fake OSRM table service, see tableTestHandler.
+func newTableTestServer(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(tableTestHandler(t, requests))
+}
+
+// tableTestHandler is the handler of a fake OSRM table service.
+// Durations are distances divided by 10 and destinations with a negative longitude are unreachable.
+func tableTestHandler(t *testing.T, requests *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if requests != nil {
+			atomic.AddInt32(requests, 1)
+		}
+
+		coordinates := parseTestCoordinates(r.URL.Path)
+		q := r.URL.Query()
+		sources := parseTestIndices(q.Get("sources"), len(coordinates))
+		destinations := parseTestIndices(q.Get("destinations"), len(coordinates))
+
+		res := TableResponse{Response: Response{Code: CodeOK}}
+		for _, i := range sources {
+			res.Sources = append(res.Sources, Waypoint{Location: coordinates[i], Hint: "src"})
+
+			var durations, distances []float32
+			for _, j := range destinations {
+				d := testTableDistance(coordinates[i], coordinates[j])
+				if coordinates[j][0] < 0 {
+					d = float32(math.Inf(1))
+				}
+				distances = append(distances, d)
+				durations = append(durations, d/10)
+			}
+
+			res.Durations = append(res.Durations, durations)
+			res.Distances = append(res.Distances, distances)
+		}
+		for _, j := range destinations {
+			res.Destinations = append(res.Destinations, Waypoint{Location: coordinates[j]})
+		}
+
+		assert.NoError(t, json.NewEncoder(w).Encode(testTableJSON(res)))
+	}
+}
+
+// testTableJSON returns the JSON representation of a table response, infinite values are encoded as null like OSRM does.
+func testTableJSON(res TableResponse) any {
+	null := func(m [][]float32) [][]*float32 {
+		var out [][]*float32
+		for _, row := range m {
+			values := make([]*float32, len(row))
+			for j := range row {
+				if !math.IsInf(float64(row[j]), 1) {
+					values[j] = &row[j]
+				}
+			}
+			out = append(out, values)
+		}
+		return out
+	}
+
+	return struct {
+		TableResponse
+		Durations [][]*float32 `json:"durations"`
+		Distances [][]*float32 `json:"distances"`
+	}{res, null(res.Durations), null(res.Distances)}
+}
+
+func TestTableChunked(t *testing.T) {
+	var requests int32
+	srv := newTableTestServer(t, &requests)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	req := Request{
+		Profile:     ProfileCar,
+		Coordinates: []Coordinate{{1, 1}, {1, 2}, {2, 2}, {-3, 3}, {4, 4}},
+	}
+
+	res, err := TableChunked(context.Background(), osrm, req, nil, []int{4, 3, 0}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(6), requests)
+	assert.Equal(t, CodeOK, res.Code)
+	assert.Len(t, res.Durations, 5)
+	assert.Len(t, res.Sources, 5)
+	assert.Len(t, res.Destinations, 3)
+	assert.Equal(t, req.Coordinates[4], res.Destinations[0].Location)
+	assert.Equal(t, req.Coordinates[3], res.Sources[3].Location)
+
+	for i := range req.Coordinates {
+		for j, dst := range []int{4, 3, 0} {
+			expected := testTableDistance(req.Coordinates[i], req.Coordinates[dst])
+			if dst == 3 {
+				assert.True(t, math.IsInf(float64(res.Distances[i][j]), 1))
+				continue
+			}
+			assert.InDelta(t, expected, res.Distances[i][j], 1)
+			assert.InDelta(t, expected/10, res.Durations[i][j], 1)
+		}
+	}
+
+	res, err = TableChunked(context.Background(), osrm, req, []int{1}, nil, 0)
+	assert.NoError(t, err)
+	assert.Len(t, res.Durations, 1)
+	assert.Len(t, res.Durations[0], 5)
+
+	osrm.baseURL.Host = "invalid"
+	_, err = TableChunked(context.Background(), osrm, req, nil, nil, 2)
+	assert.Error(t, err)
+}
+
+func TestTableChunked_ResponseError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":"TooBig","message":"Too many table coordinates"}`))
+	}))
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	_, err = TableChunked(context.Background(), osrm, Request{Coordinates: []Coordinate{{1, 1}}}, nil, nil, 0)
+	assert.Equal(t, ResponseError{Code: CodeTooBig, Message: "Too many table coordinates"}, err)
+}
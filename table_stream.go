@@ -0,0 +1,217 @@
+package gosrm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// TableMatrix is a matrix of table service.
+type TableMatrix string
+
+const (
+	// TableDurations is the durations matrix.
+	TableDurations TableMatrix = "durations"
+
+	// TableDistances is the distances matrix.
+	TableDistances TableMatrix = "distances"
+)
+
+// TableRow is a row of a matrix of table service.
+type TableRow struct {
+	// Matrix is the matrix of the row.
+	Matrix TableMatrix
+
+	// Source is the index of the source of the row.
+	Source int
+
+	// Values are the values of the row by destination, values of the pairs that can't be routed are positive infinity.
+	Values []float32
+}
+
+// DecodeTableRows decodes a table response from r and yields the rows of its matrices as they're parsed,
+// so the matrices don't have to be held in memory. Rows are yielded in the order of the response.
+// Other fields of the response are decoded into res if it's not nil, they're set when the iteration is done.
+// res is reset before it's decoded, so its matrices are nil.
+// An error is yielded and the iteration stops if the response can't be decoded.
+func DecodeTableRows(r io.Reader, res *TableResponse) iter.Seq2[TableRow, error] {
+	return func(yield func(TableRow, error) bool) {
+		dec := json.NewDecoder(r)
+
+		if err := expectDelim(dec, '{'); err != nil {
+			yield(TableRow{}, err)
+			return
+		}
+
+		fields := make(map[string]json.RawMessage)
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				yield(TableRow{}, err)
+				return
+			}
+			key, _ := tok.(string)
+
+			if matrix := TableMatrix(key); matrix == TableDurations || matrix == TableDistances {
+				if ok, err := decodeTableRows(dec, matrix, yield); err != nil {
+					yield(TableRow{}, err)
+					return
+				} else if !ok {
+					return
+				}
+				continue
+			}
+
+			var v json.RawMessage
+			if err := dec.Decode(&v); err != nil {
+				yield(TableRow{}, err)
+				return
+			}
+			fields[key] = v
+		}
+
+		if err := expectDelim(dec, '}'); err != nil {
+			yield(TableRow{}, err)
+			return
+		}
+
+		if res == nil {
+			return
+		}
+
+		*res = TableResponse{}
+		data, err := json.Marshal(fields)
+		if err == nil {
+			err = json.Unmarshal(data, res)
+		}
+		if err != nil {
+			yield(TableRow{}, err)
+		}
+	}
+}
+
+// decodeTableRows decodes the rows of a matrix and yields them.
+// It returns false if the iteration is stopped by yield.
+func decodeTableRows(dec *json.Decoder, matrix TableMatrix, yield func(TableRow, error) bool) (bool, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if tok == nil {
+		return true, nil
+	}
+	if tok != json.Delim('[') {
+		return false, fmt.Errorf("gosrm: invalid %s matrix", matrix)
+	}
+
+	for source := 0; dec.More(); source++ {
+		var row []tableCell
+		if err := dec.Decode(&row); err != nil {
+			return false, err
+		}
+
+		if !yield(TableRow{Matrix: matrix, Source: source, Values: tableRow(row)}, nil) {
+			return false, nil
+		}
+	}
+
+	return true, expectDelim(dec, ']')
+}
+
+// expectDelim reads the next token of the decoder and returns an error if it's not the delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("gosrm: expected %s in table response", delim)
+	}
+	return nil
+}
+
+// TableRows computes the table like Table but yields the rows of the matrices as they're parsed,
+// so the matrices don't have to be held in memory. The request is sent when the iteration starts.
+// Other fields of the response are decoded into res if it's not nil, see DecodeTableRows.
+// A ResponseError is yielded if OSRM can't process the request.
+func TableRows(ctx context.Context, osrm OSRMClient, req Request, res *TableResponse, opts ...Option) iter.Seq2[TableRow, error] {
+	return func(yield func(TableRow, error) bool) {
+		u := req.buildURLPath(*osrm.baseURL, tableServiceURL)
+
+		osrm.injectHints(u, req)
+		osrm.applyOpts(u, opts)
+
+		body, err := osrm.open(ctx, u.String())
+		if err != nil {
+			yield(TableRow{}, err)
+			return
+		}
+		defer body.Close()
+
+		meta := res
+		if meta == nil {
+			meta = new(TableResponse)
+		}
+
+		for row, err := range DecodeTableRows(body, meta) {
+			if !yield(row, err) || err != nil {
+				return
+			}
+		}
+
+		if err := meta.Err(); err != nil {
+			yield(TableRow{}, err)
+			return
+		}
+
+		osrm.updateTableHints(u, req, meta)
+	}
+}
+
+// TableChunkedRows computes the table like TableChunked but yields the rows of the matrices as soon as
+// the blocks of their sources are done, so only chunkSize rows of the matrices are held in memory.
+// Rows are yielded in the order of sources, the durations row of a source is yielded before its distances row.
+// Source of the rows is the index of sources, not of the request coordinates.
+// An error is yielded and the iteration stops if any of the blocks fails.
+func TableChunkedRows(ctx context.Context, osrm OSRMClient, req Request, sources, destinations []int, chunkSize int, opts ...Option) iter.Seq2[TableRow, error] {
+	if sources == nil {
+		sources = allIndices(len(req.Coordinates))
+	}
+	if destinations == nil {
+		destinations = allIndices(len(req.Coordinates))
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultTableChunkSize
+	}
+
+	return func(yield func(TableRow, error) bool) {
+		for si := 0; si < len(sources); si += chunkSize {
+			srcChunk := sources[si:min(si+chunkSize, len(sources))]
+
+			var durations, distances [][]float32
+			for di := 0; di < len(destinations); di += chunkSize {
+				dstChunk := destinations[di:min(di+chunkSize, len(destinations))]
+
+				block, err := tableBlock(ctx, osrm, req, srcChunk, dstChunk, opts)
+				if err != nil {
+					yield(TableRow{}, err)
+					return
+				}
+
+				durations = mergeTableBlock(durations, block.Durations, len(srcChunk), len(destinations), 0, di)
+				distances = mergeTableBlock(distances, block.Distances, len(srcChunk), len(destinations), 0, di)
+			}
+
+			for i := range srcChunk {
+				if durations != nil && !yield(TableRow{Matrix: TableDurations, Source: si + i, Values: durations[i]}, nil) {
+					return
+				}
+				if distances != nil && !yield(TableRow{Matrix: TableDistances, Source: si + i, Values: distances[i]}, nil) {
+					return
+				}
+			}
+		}
+	}
+}
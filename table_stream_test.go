@@ -0,0 +1,153 @@
+package gosrm
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeTableRows(t *testing.T) {
+	data := `{"code":"Ok","durations":[[0,null],[1.5,0]],"sources":[{"name":"a"}],"distances":null,` +
+		`"destinations":[{"name":"b"}],"fallback_speed_cells":[[0,1]]}`
+
+	// Stale fields of a reused response are reset.
+	res := TableResponse{Durations: [][]float32{{1}}, Distances: [][]float32{{1}}, Response: Response{Message: "stale"}}
+	var rows []TableRow
+	for row, err := range DecodeTableRows(strings.NewReader(data), &res) {
+		assert.NoError(t, err)
+		rows = append(rows, row)
+	}
+
+	assert.Len(t, rows, 2)
+	assert.Equal(t, TableRow{Matrix: TableDurations, Source: 1, Values: []float32{1.5, 0}}, rows[1])
+	assert.True(t, math.IsInf(float64(rows[0].Values[1]), 1))
+
+	assert.Equal(t, CodeOK, res.Code)
+	assert.Equal(t, "a", res.Sources[0].Name)
+	assert.Equal(t, "b", res.Destinations[0].Name)
+	assert.Equal(t, [][]uint16{{0, 1}}, res.FallbackSpeedCells)
+	assert.Nil(t, res.Durations)
+	assert.Nil(t, res.Distances)
+	assert.Empty(t, res.Message)
+
+	// Iteration can be stopped early.
+	count := 0
+	for range DecodeTableRows(strings.NewReader(data), nil) {
+		count++
+		break
+	}
+	assert.Equal(t, 1, count)
+
+	for _, data := range []string{`[]`, `{"durations":"invalid"}`, `{"durations":[[0,"a"]]}`, `{"code":"Ok"`, `{"sources":1}`} {
+		var errs []error
+		for _, err := range DecodeTableRows(strings.NewReader(data), &res) {
+			errs = append(errs, err)
+		}
+		assert.Len(t, errs, 1, data)
+		assert.Error(t, errs[0], data)
+	}
+}
+
+func TestTableRows(t *testing.T) {
+	srv := newTableTestServer(t, nil)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+	osrm.SetHintStore(NewHintStore(5))
+
+	req := Request{Profile: ProfileCar, Coordinates: []Coordinate{{1, 1}, {1, 2}, {-3, 3}}}
+	expected, err := tableInf(context.Background(), osrm, req, WithSources([]uint16{0, 1}))
+	assert.NoError(t, err)
+
+	var res TableResponse
+	var durations, distances [][]float32
+	for row, err := range TableRows(context.Background(), osrm, req, &res, WithSources([]uint16{0, 1})) {
+		assert.NoError(t, err)
+		if row.Matrix == TableDurations {
+			durations = append(durations, row.Values)
+		} else {
+			distances = append(distances, row.Values)
+		}
+	}
+
+	assert.Equal(t, expected.Durations, durations)
+	assert.Equal(t, expected.Distances, distances)
+	assert.Equal(t, expected.Sources, res.Sources)
+	assert.Equal(t, CodeOK, res.Code)
+
+	osrm.baseURL.Host = "invalid"
+	for _, err := range TableRows(context.Background(), osrm, req, nil) {
+		assert.Error(t, err)
+	}
+
+	errSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":"InvalidQuery","message":"invalid"}`))
+	}))
+	defer errSrv.Close()
+
+	osrm, err = New(errSrv.URL)
+	assert.NoError(t, err)
+
+	var errs []error
+	for _, err := range TableRows(context.Background(), osrm, req, nil) {
+		errs = append(errs, err)
+	}
+	assert.Equal(t, []error{ResponseError{Code: "InvalidQuery", Message: "invalid"}}, errs)
+}
+
+func TestTableChunkedRows(t *testing.T) {
+	var requests int32
+	srv := newTableTestServer(t, &requests)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	req := Request{
+		Profile:     ProfileCar,
+		Coordinates: []Coordinate{{1, 1}, {1, 2}, {2, 2}, {-3, 3}, {4, 4}},
+	}
+
+	expected, err := TableChunked(context.Background(), osrm, req, nil, []int{4, 3, 0}, 2)
+	assert.NoError(t, err)
+	requests = 0
+
+	var rows []TableRow
+	for row, err := range TableChunkedRows(context.Background(), osrm, req, nil, []int{4, 3, 0}, 2) {
+		assert.NoError(t, err)
+		rows = append(rows, row)
+	}
+
+	assert.Equal(t, int32(6), requests)
+	assert.Len(t, rows, 10)
+	for i, row := range rows {
+		assert.Equal(t, i/2, row.Source)
+		if i%2 == 0 {
+			assert.Equal(t, TableDurations, row.Matrix)
+			assert.Equal(t, expected.Durations[i/2], row.Values)
+		} else {
+			assert.Equal(t, TableDistances, row.Matrix)
+			assert.Equal(t, expected.Distances[i/2], row.Values)
+		}
+	}
+
+	// Blocks of the next sources aren't requested if the iteration is stopped.
+	requests = 0
+	for range TableChunkedRows(context.Background(), osrm, req, nil, nil, 2) {
+		break
+	}
+	assert.Equal(t, int32(3), requests)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, err := range TableChunkedRows(ctx, osrm, req, nil, nil, 2) {
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+}
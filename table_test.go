@@ -2,6 +2,8 @@ package gosrm
 
 import (
 	"context"
+	"encoding/json"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,3 +33,25 @@ func TestTable(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, res)
 }
+
+func TestTableResponse_JSON(t *testing.T) {
+	data := []byte(`{"code":"Ok","durations":[[0,null],[1.5,0]],"distances":[ [ 1e-7 , null ,-2.5E3] ,[],null],"sources":[{"name":"a"}]}`)
+
+	// Table decodes null values as 0.
+	var res TableResponse
+	assert.NoError(t, json.Unmarshal(data, &res))
+	assert.Equal(t, [][]float32{{0, 0}, {1.5, 0}}, res.Durations)
+
+	// Chunked tables decode them as positive infinity.
+	var v tableResponseInf
+	assert.NoError(t, json.Unmarshal(data, &v))
+	inf := float32(math.Inf(1))
+	res = *v.response()
+	assert.Equal(t, CodeOK, res.Code)
+	assert.Equal(t, "a", res.Sources[0].Name)
+	assert.Equal(t, [][]float32{{0, inf}, {1.5, 0}}, res.Durations)
+	assert.Equal(t, [][]float32{{1e-7, inf, -2500}, {}, nil}, res.Distances)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"durations":[["1"]]}`), &v))
+	assert.Error(t, json.Unmarshal([]byte(`{"durations":[[[1]]]}`), &v))
+}
@@ -0,0 +1,410 @@
+package gosrm
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ErrInvalidTerritories is returned when a territory request is not valid.
+var ErrInvalidTerritories = errors.New("gosrm: invalid territory request")
+
+// DefaultTerritoryIterations is the default max number of improvement rounds of capacity balancing.
+const DefaultTerritoryIterations int = 100
+
+type (
+	// TerritoryRequest is the request of assigning customers to depots.
+	TerritoryRequest struct {
+		// Profile is used to compute travel durations and distances.
+		Profile Profile
+
+		// Depots are the coordinates of the depots.
+		Depots []Coordinate
+
+		// Customers are the coordinates of the customers.
+		Customers []Coordinate
+
+		// Metric of the travels from depots to customers that is minimized, defaults to TableDurations.
+		Metric TableMatrix
+
+		// Capacities are optional max loads of the depots, Capacities[i] belongs to Depots[i].
+		// A capacity of 0 or less is unlimited. If it's nil, customers are assigned to their nearest depots.
+		Capacities []float64
+
+		// Demands are optional loads of the customers, Demands[i] belongs to Customers[i]. Defaults to 1 for each customer.
+		Demands []float64
+
+		// Iterations is the max number of improvement rounds of capacity balancing,
+		// defaults to DefaultTerritoryIterations.
+		Iterations int
+
+		// ChunkSize is the max number of sources and destinations per table request, see TableChunked.
+		ChunkSize int
+	}
+
+	// Territory is the customers assigned to a depot.
+	Territory struct {
+		// Depot is the index of the depot.
+		Depot int
+
+		// Customers are the indices of the customers assigned to the depot, in ascending order.
+		Customers []int
+
+		// Load is the total demand of the customers.
+		Load float64
+
+		// Total is the sum of the metric of the travels from the depot to the customers.
+		Total float64
+
+		// Mean is the mean of the metric of the travels from the depot to the customers, 0 if there are no customers.
+		Mean float64
+
+		// Max is the max of the metric of the travels from the depot to the customers.
+		Max float64
+
+		// coordinates are the coordinates of the depot and its customers.
+		coordinates []Coordinate
+	}
+
+	// TerritoryResult is the result of assigning customers to depots.
+	TerritoryResult struct {
+		// Territories are the territories of the depots, Territories[i] belongs to the i-th depot.
+		Territories []Territory
+
+		// Assignments are the depots of the customers, Assignments[i] is the depot index of the i-th customer.
+		// It's -1 if the customer is unassigned.
+		Assignments []int
+
+		// Unassigned are the indices of the customers that can't be reached from any depot
+		// or don't fit in the capacity of any reachable depot.
+		Unassigned []int
+
+		// Total is the sum of the metric of the travels from depots to their customers.
+		Total float64
+	}
+)
+
+// Territories assigns customers to depots so the metric of the travels from depots to customers is minimized.
+// The depot by customer matrix is computed by TableChunked, so there is no limit on the number of customers.
+// Without capacities customers are assigned to their nearest depots. With capacities customers are assigned
+// in order of regret, the difference of their nearest and second nearest depots, and the assignment is
+// improved by moving and swapping customers between depots. Balanced assignments are not guaranteed to be optimal.
+// Options are passed to table service.
+func Territories(ctx context.Context, osrm OSRMClient, req TerritoryRequest, opts ...Option) (*TerritoryResult, error) {
+	if req.Metric == "" {
+		req.Metric = TableDurations
+	}
+	if req.Iterations <= 0 {
+		req.Iterations = DefaultTerritoryIterations
+	}
+
+	if req.Metric != TableDurations && req.Metric != TableDistances {
+		return nil, fmt.Errorf("%w: unknown metric %q", ErrInvalidTerritories, req.Metric)
+	}
+	if len(req.Depots) == 0 {
+		return nil, fmt.Errorf("%w: no depots", ErrInvalidTerritories)
+	}
+	if req.Capacities != nil && len(req.Capacities) != len(req.Depots) {
+		return nil, fmt.Errorf("%w: %d capacities for %d depots", ErrInvalidTerritories, len(req.Capacities), len(req.Depots))
+	}
+	if req.Demands != nil && len(req.Demands) != len(req.Customers) {
+		return nil, fmt.Errorf("%w: %d demands for %d customers", ErrInvalidTerritories, len(req.Demands), len(req.Customers))
+	}
+
+	costs := make([][]float64, len(req.Depots))
+	for i := range costs {
+		costs[i] = make([]float64, len(req.Customers))
+	}
+
+	if len(req.Customers) > 0 {
+		table := Request{Profile: req.Profile, Coordinates: append(slices.Clone(req.Depots), req.Customers...)}
+		tableOpts := append([]Option{WithAnnotations(AnnotationsDurationDistance)}, opts...)
+		indices := allIndices(len(table.Coordinates))
+
+		res, err := TableChunked(ctx, osrm, table, indices[:len(req.Depots)], indices[len(req.Depots):], req.ChunkSize, tableOpts...)
+		if err != nil {
+			return nil, err
+		}
+
+		matrix := res.Matrix(req.Metric).Values
+		if len(matrix) != len(req.Depots) {
+			return nil, fmt.Errorf("%w: table response has no %s", ErrInvalidTerritories, req.Metric)
+		}
+		for i := range costs {
+			for j := range costs[i] {
+				costs[i][j] = float64(tableValue(matrix, i, j))
+			}
+		}
+	}
+
+	a := newTerritoryAssigner(costs, req.Capacities, req.Demands)
+	a.assign()
+	if req.Capacities != nil {
+		a.improve(req.Iterations)
+	}
+
+	return a.result(req), nil
+}
+
+// territoryAssigner assigns customers to depots.
+type territoryAssigner struct {
+	// costs[i][j] is the cost of the travel from the i-th depot to the j-th customer.
+	costs      [][]float64
+	capacities []float64
+	demands    []float64
+
+	// assignments are the depots of the customers, -1 if they're unassigned.
+	assignments []int
+	loads       []float64
+}
+
+// newTerritoryAssigner returns a new assigner with unlimited capacities and demands of 1 by default.
+func newTerritoryAssigner(costs [][]float64, capacities, demands []float64) *territoryAssigner {
+	depots := len(costs)
+	customers := 0
+	if depots > 0 {
+		customers = len(costs[0])
+	}
+
+	a := territoryAssigner{
+		costs:       costs,
+		capacities:  make([]float64, depots),
+		demands:     make([]float64, customers),
+		assignments: make([]int, customers),
+		loads:       make([]float64, depots),
+	}
+
+	for i := range a.capacities {
+		a.capacities[i] = math.Inf(1)
+		if capacities != nil && capacities[i] > 0 {
+			a.capacities[i] = capacities[i]
+		}
+	}
+	for j := range a.demands {
+		a.demands[j] = 1
+		if demands != nil {
+			a.demands[j] = demands[j]
+		}
+		a.assignments[j] = -1
+	}
+
+	return &a
+}
+
+// fits reports whether the customer fits in the remaining capacity of the depot, ignoring its current assignment.
+func (a *territoryAssigner) fits(depot, customer int) bool {
+	load := a.loads[depot]
+	if a.assignments[customer] == depot {
+		load -= a.demands[customer]
+	}
+	return load+a.demands[customer] <= a.capacities[depot]
+}
+
+// move assigns the customer to the depot, -1 unassigns it.
+func (a *territoryAssigner) move(customer, depot int) {
+	if prev := a.assignments[customer]; prev >= 0 {
+		a.loads[prev] -= a.demands[customer]
+	}
+	a.assignments[customer] = depot
+	if depot >= 0 {
+		a.loads[depot] += a.demands[customer]
+	}
+}
+
+// cost returns the cost of the customer at the depot.
+func (a *territoryAssigner) cost(depot, customer int) float64 {
+	return a.costs[depot][customer]
+}
+
+// assign assigns customers in order of regret to their nearest depots which have capacity for them.
+// Customers that can't be reached from any depot have no regret and are left unassigned.
+func (a *territoryAssigner) assign() {
+	regrets := make([]float64, len(a.assignments))
+	for j := range regrets {
+		best, second := math.Inf(1), math.Inf(1)
+		for i := range a.costs {
+			if c := a.cost(i, j); c < best {
+				best, second = c, best
+			} else if c < second {
+				second = c
+			}
+		}
+
+		switch {
+		case math.IsInf(best, 1):
+			regrets[j] = math.Inf(-1)
+		case math.IsInf(second, 1):
+			regrets[j] = math.Inf(1)
+		default:
+			regrets[j] = second - best
+		}
+	}
+
+	order := allIndices(len(a.assignments))
+	slices.SortStableFunc(order, func(x, y int) int {
+		return cmp.Compare(regrets[y], regrets[x])
+	})
+
+	for _, j := range order {
+		best := -1
+		for i := range a.costs {
+			if math.IsInf(a.cost(i, j), 1) || !a.fits(i, j) {
+				continue
+			}
+			if best < 0 || a.cost(i, j) < a.cost(best, j) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			a.move(j, best)
+		}
+	}
+}
+
+// improve moves customers to cheaper depots and swaps customers of depots while it reduces the total cost,
+// for at most the given number of rounds. Unassigned customers are assigned when capacity is freed.
+func (a *territoryAssigner) improve(rounds int) {
+	for range rounds {
+		improved := false
+
+		for j, from := range a.assignments {
+			for i := range a.costs {
+				if i == from || math.IsInf(a.cost(i, j), 1) || !a.fits(i, j) {
+					continue
+				}
+				if from < 0 || a.cost(i, j) < a.cost(from, j) {
+					a.move(j, i)
+					from = i
+					improved = true
+				}
+			}
+		}
+
+		for j, dj := range a.assignments {
+			for k := j + 1; k < len(a.assignments); k++ {
+				dk := a.assignments[k]
+				if dj < 0 || dk < 0 || dj == dk {
+					continue
+				}
+
+				delta := a.cost(dk, j) + a.cost(dj, k) - a.cost(dj, j) - a.cost(dk, k)
+				if !(delta < 0) {
+					continue
+				}
+				if a.loads[dj]-a.demands[j]+a.demands[k] > a.capacities[dj] ||
+					a.loads[dk]-a.demands[k]+a.demands[j] > a.capacities[dk] {
+					continue
+				}
+
+				a.move(j, dk)
+				a.move(k, dj)
+				dj = dk
+				improved = true
+			}
+		}
+
+		if !improved {
+			return
+		}
+	}
+}
+
+// result returns the result of the assignments.
+func (a *territoryAssigner) result(req TerritoryRequest) *TerritoryResult {
+	res := TerritoryResult{Territories: make([]Territory, len(a.costs)), Assignments: a.assignments}
+	for i := range res.Territories {
+		res.Territories[i] = Territory{Depot: i, coordinates: []Coordinate{req.Depots[i]}}
+	}
+
+	for j, i := range a.assignments {
+		if i < 0 {
+			res.Unassigned = append(res.Unassigned, j)
+			continue
+		}
+
+		t := &res.Territories[i]
+		c := a.cost(i, j)
+		t.Customers = append(t.Customers, j)
+		t.Load += a.demands[j]
+		t.Total += c
+		t.Max = max(t.Max, c)
+		t.coordinates = append(t.coordinates, req.Customers[j])
+		res.Total += c
+	}
+
+	for i := range res.Territories {
+		if t := &res.Territories[i]; len(t.Customers) > 0 {
+			t.Mean = t.Total / float64(len(t.Customers))
+		}
+	}
+
+	return &res
+}
+
+// GeoJSON returns the territories as a feature collection.
+// Each territory has a polygon feature of the convex hull of its depot and customers, if it has an area,
+// and a point feature of its depot. Features have kind, depot, customers, load, total, mean and max properties.
+// Unassigned customers aren't included.
+func (res TerritoryResult) GeoJSON() FeatureCollection {
+	fc := NewFeatureCollection()
+
+	for _, t := range res.Territories {
+		if len(t.coordinates) == 0 {
+			continue
+		}
+
+		properties := map[string]any{
+			"depot":     t.Depot,
+			"customers": len(t.Customers),
+			"load":      t.Load,
+			"total":     t.Total,
+			"mean":      t.Mean,
+			"max":       t.Max,
+		}
+
+		if hull := convexHull(t.coordinates); len(hull) >= 3 {
+			ring := append(hull, hull[0])
+			fc.Features = append(fc.Features, NewPolygonFeature([][]Coordinate{ring}, withProperties(properties, map[string]any{"kind": "territory"})))
+		}
+		fc.Features = append(fc.Features, NewPointFeature(t.coordinates[0], withProperties(properties, map[string]any{"kind": "depot"})))
+	}
+
+	return fc
+}
+
+// convexHull returns the convex hull of the coordinates in counterclockwise order, without collinear points.
+// It uses the monotone chain algorithm on longitudes and latitudes.
+func convexHull(coordinates []Coordinate) []Coordinate {
+	points := slices.Clone(coordinates)
+	slices.SortFunc(points, func(a, b Coordinate) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+	points = slices.Compact(points)
+	if len(points) < 3 {
+		return points
+	}
+
+	cross := func(o, a, b Coordinate) float64 {
+		return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+	}
+
+	hull := make([]Coordinate, 0, 2*len(points))
+	for _, p := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	for i, lower := len(points)-2, len(hull)+1; i >= 0; i-- {
+		p := points[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+
+	return hull[:len(hull)-1]
+}
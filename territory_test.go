@@ -0,0 +1,121 @@
+package gosrm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerritories(t *testing.T) {
+	var requests int32
+	srv := newTableTestServer(t, &requests)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	req := TerritoryRequest{
+		Profile:   ProfileCar,
+		Depots:    []Coordinate{{0, 0}, {1, 0}},
+		Customers: []Coordinate{{0.1, 0}, {0.2, 0.1}, {0.3, 0}, {0.9, 0}, {-5, 0}},
+		ChunkSize: 2,
+	}
+
+	res, err := Territories(context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), requests)
+	assert.Equal(t, []int{0, 0, 0, 1, -1}, res.Assignments)
+	assert.Equal(t, []int{4}, res.Unassigned)
+	assert.Equal(t, []int{0, 1, 2}, res.Territories[0].Customers)
+	assert.Equal(t, float64(3), res.Territories[0].Load)
+	assert.InDelta(t, 7000, res.Territories[0].Total, 0.1)
+	assert.InDelta(t, 7000.0/3, res.Territories[0].Mean, 0.1)
+	assert.InDelta(t, 3000, res.Territories[0].Max, 0.1)
+	assert.Equal(t, []int{3}, res.Territories[1].Customers)
+	assert.InDelta(t, 8000, res.Total, 0.1)
+
+	// Customers are balanced by capacities.
+	req.Capacities = []float64{2, 0}
+	req.Metric = TableDistances
+	res, err = Territories(context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 0, 1, 1, -1}, res.Assignments)
+	assert.Equal(t, float64(2), res.Territories[0].Load)
+	assert.InDelta(t, 10000+30000+70000+10000, res.Total, 1)
+
+	req.Demands = []float64{2, 1, 1, 1, 1}
+	req.Capacities = []float64{2, 2}
+	res, err = Territories(context.Background(), osrm, req)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, -1, 1, -1}, res.Assignments)
+	assert.Equal(t, []int{2, 4}, res.Unassigned)
+	assert.Equal(t, float64(2), res.Territories[0].Load)
+	assert.Equal(t, float64(2), res.Territories[1].Load)
+
+	for _, invalid := range []TerritoryRequest{
+		{Metric: "invalid", Depots: req.Depots},
+		{},
+		{Depots: req.Depots, Capacities: []float64{1}},
+		{Depots: req.Depots, Customers: req.Customers, Demands: []float64{1}},
+	} {
+		_, err := Territories(context.Background(), osrm, invalid)
+		assert.ErrorIs(t, err, ErrInvalidTerritories)
+	}
+}
+
+func TestTerritoryResult_GeoJSON(t *testing.T) {
+	srv := newTableTestServer(t, nil)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	res, err := Territories(context.Background(), osrm, TerritoryRequest{
+		Profile:   ProfileCar,
+		Depots:    []Coordinate{{0, 0}, {1, 0}},
+		Customers: []Coordinate{{0.1, 0}, {0.2, 0.1}, {0.3, 0}, {0.9, 0}},
+	})
+	assert.NoError(t, err)
+
+	fc := res.GeoJSON()
+	assert.Len(t, fc.Features, 3)
+
+	territory := fc.Features[0]
+	assert.Equal(t, "Polygon", territory.Geometry.Type)
+	assert.Equal(t, [][]Coordinate{{{0, 0}, {0.3, 0}, {0.2, 0.1}, {0, 0}}}, territory.Geometry.Coordinates)
+	assert.Equal(t, "territory", territory.Properties["kind"])
+	assert.Equal(t, 0, territory.Properties["depot"])
+	assert.Equal(t, 3, territory.Properties["customers"])
+
+	// Territories without an area only have their depots.
+	assert.Equal(t, "Point", fc.Features[1].Geometry.Type)
+	assert.Equal(t, "Point", fc.Features[2].Geometry.Type)
+	assert.Equal(t, 1, fc.Features[2].Properties["depot"])
+}
+
+func TestTerritoryAssigner_Improve(t *testing.T) {
+	a := newTerritoryAssigner([][]float64{{1, 5, 2}, {5, 1, 2}}, []float64{2, 2}, nil)
+	a.move(0, 1)
+	a.move(1, 0)
+	a.move(2, 0)
+
+	a.improve(10)
+	assert.Equal(t, []int{0, 1, 1}, a.assignments)
+	assert.Equal(t, []float64{1, 2}, a.loads)
+
+	// Customers are swapped when depots are full.
+	a = newTerritoryAssigner([][]float64{{1, 5}, {5, 1}}, []float64{1, 1}, nil)
+	a.move(0, 1)
+	a.move(1, 0)
+
+	a.improve(10)
+	assert.Equal(t, []int{0, 1}, a.assignments)
+}
+
+func TestConvexHull(t *testing.T) {
+	hull := convexHull([]Coordinate{{0, 0}, {1, 1}, {2, 0}, {2, 2}, {0, 2}, {1, 0}, {0, 0}})
+	assert.Equal(t, []Coordinate{{0, 0}, {2, 0}, {2, 2}, {0, 2}}, hull)
+
+	assert.Equal(t, []Coordinate{{0, 0}, {1, 1}}, convexHull([]Coordinate{{1, 1}, {0, 0}, {1, 1}}))
+}
@@ -0,0 +1,72 @@
+package trace
+
+import "github.com/mojixcoder/gosrm"
+
+// Score is the accuracy of a matching compared to the ground truth.
+// Segments are directed pairs of consecutive OSM nodes.
+type Score struct {
+	// Matched is the number of distinct segments of the matching.
+	Matched int
+
+	// Truth is the number of distinct segments of the ground truth.
+	Truth int
+
+	// Correct is the number of segments of the matching that are in the ground truth.
+	Correct int
+
+	// Precision is the fraction of the segments of the matching that are in the ground truth.
+	Precision float64
+
+	// Recall is the fraction of the segments of the ground truth that are in the matching.
+	Recall float64
+}
+
+// F1 returns the harmonic mean of precision and recall.
+func (s Score) F1() float64 {
+	if s.Precision+s.Recall == 0 {
+		return 0
+	}
+	return 2 * s.Precision * s.Recall / (s.Precision + s.Recall)
+}
+
+// ScoreMatch compares the matchings of a match response with the ground truth nodes, e.g. of a synthetic trace.
+// The response needs node annotations, see gosrm.AnnotationsNodes.
+func ScoreMatch[T gosrm.GeometryType](res *gosrm.MatchResponse[T], truth []uint64) Score {
+	expected := segments(truth)
+
+	matched := make(map[[2]uint64]bool)
+	for _, matching := range res.Matchings {
+		for _, leg := range matching.Legs {
+			for segment := range segments(leg.Annotation.Nodes) {
+				matched[segment] = true
+			}
+		}
+	}
+
+	s := Score{Matched: len(matched), Truth: len(expected)}
+	for segment := range matched {
+		if expected[segment] {
+			s.Correct++
+		}
+	}
+
+	if s.Matched > 0 {
+		s.Precision = float64(s.Correct) / float64(s.Matched)
+	}
+	if s.Truth > 0 {
+		s.Recall = float64(s.Correct) / float64(s.Truth)
+	}
+
+	return s
+}
+
+// segments returns the segments of a node sequence, repeated nodes aren't segments.
+func segments(nodes []uint64) map[[2]uint64]bool {
+	set := make(map[[2]uint64]bool)
+	for i := 1; i < len(nodes); i++ {
+		if nodes[i-1] != nodes[i] {
+			set[[2]uint64{nodes[i-1], nodes[i]}] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,27 @@
+package trace
+
+import (
+	"testing"
+
+	"github.com/mojixcoder/gosrm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreMatch(t *testing.T) {
+	leg := func(nodes ...uint64) gosrm.RouteLeg[string] {
+		return gosrm.RouteLeg[string]{Annotation: gosrm.Annotation{Nodes: nodes}}
+	}
+
+	res := &gosrm.MatchResponse[string]{Matchings: []gosrm.Matching[string]{
+		{RouteType: gosrm.RouteType[string]{Legs: []gosrm.RouteLeg[string]{leg(1, 2, 3), leg(3, 3, 4)}}},
+		{RouteType: gosrm.RouteType[string]{Legs: []gosrm.RouteLeg[string]{leg(4, 9), leg(2, 3)}}},
+	}}
+
+	s := ScoreMatch(res, []uint64{1, 2, 3, 4, 5})
+	assert.Equal(t, Score{Matched: 4, Truth: 4, Correct: 3, Precision: 0.75, Recall: 0.75}, s)
+	assert.InDelta(t, 0.75, s.F1(), 1e-9)
+
+	s = ScoreMatch(&gosrm.MatchResponse[string]{}, []uint64{1, 2})
+	assert.Equal(t, Score{Truth: 1}, s)
+	assert.Equal(t, float64(0), s.F1())
+}
@@ -0,0 +1,175 @@
+package trace
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/mojixcoder/gosrm"
+	"github.com/mojixcoder/gosrm/internal/geo"
+)
+
+// ErrMissingAnnotations is returned when a route doesn't have annotations that match its geometry.
+var ErrMissingAnnotations = errors.New("trace: route annotations don't match its geometry")
+
+// DefaultInterval is the default interval of synthetic points.
+const DefaultInterval time.Duration = 5 * time.Second
+
+type (
+	// SyntheticConfig is the config of synthetic traces.
+	SyntheticConfig struct {
+		// Interval is the travel time between points. Defaults to DefaultInterval.
+		Interval time.Duration
+
+		// Spacing is the travel distance between points, in meters. If it's set, it's used instead of the interval.
+		Spacing float64
+
+		// Noise is the standard deviation of Gaussian position noise, in meters.
+		Noise float64
+
+		// DropoutRate is the probability of a point being dropped, between 0 and 1.
+		// The first and the last points are never dropped.
+		DropoutRate float64
+
+		// Jitter is the max deviation of timestamps. Timestamps are kept increasing.
+		Jitter time.Duration
+
+		// Start is the timestamp of the first point in UNIX seconds.
+		Start int64
+
+		// Seed is the seed of the random generator, the same seed generates the same trace.
+		Seed uint64
+	}
+
+	// SyntheticTrace is a trace generated from a route with its ground truth.
+	SyntheticTrace struct {
+		// Points are the noisy points of the trace.
+		Points []Point
+
+		// Truth are the locations of the points on the route, before the noise is added.
+		Truth []gosrm.Coordinate
+
+		// Nodes are the OSM node IDs of the route, in order.
+		// It's nil if the route doesn't have node annotations.
+		Nodes []uint64
+	}
+)
+
+// Synthesize generates a trace of a route for testing map matching.
+// The route needs a full overview geometry and duration and distance annotations, nodes are used as ground truth.
+// Points are sampled along the route at the interval or spacing, travel times come from the annotations.
+func Synthesize[T gosrm.GeometryType](route gosrm.RouteType[T], config SyntheticConfig) (SyntheticTrace, error) {
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+
+	coordinates, err := routeCoordinates(route.Geometry)
+	if err != nil {
+		return SyntheticTrace{}, err
+	}
+
+	var trace SyntheticTrace
+
+	// times and distances are cumulative along the geometry.
+	times, distances := []float64{0}, []float64{0}
+	for _, leg := range route.Legs {
+		if len(leg.Annotation.Duration) != len(leg.Annotation.Distance) {
+			return SyntheticTrace{}, ErrMissingAnnotations
+		}
+		for i := range leg.Annotation.Duration {
+			times = append(times, times[len(times)-1]+float64(leg.Annotation.Duration[i]))
+			distances = append(distances, distances[len(distances)-1]+float64(leg.Annotation.Distance[i]))
+		}
+		trace.Nodes = appendNodes(trace.Nodes, leg.Annotation.Nodes)
+	}
+
+	if len(coordinates) < 2 || len(times) != len(coordinates) {
+		return SyntheticTrace{}, ErrMissingAnnotations
+	}
+
+	// Points are sampled along the distance or the time.
+	along, step := times, config.Interval.Seconds()
+	if config.Spacing > 0 {
+		along, step = distances, config.Spacing
+	}
+
+	r := rand.New(rand.NewPCG(config.Seed, config.Seed))
+	total := along[len(along)-1]
+
+	for x := 0.0; ; x += step {
+		last := x >= total
+		if last {
+			x = total
+		}
+
+		// The segment from coordinates[i] to coordinates[i+1] contains x.
+		i := min(max(sort.SearchFloat64s(along, x)-1, 0), len(along)-2)
+		f := 0.0
+		if l := along[i+1] - along[i]; l > 0 {
+			f = (x - along[i]) / l
+		}
+
+		dropped := x > 0 && !last && r.Float64() < config.DropoutRate
+		if !dropped {
+			truth := gosrm.Coordinate(geo.Interpolate(coordinates[i], coordinates[i+1], f))
+			seconds := times[i] + f*(times[i+1]-times[i])
+
+			trace.Truth = append(trace.Truth, truth)
+			trace.Points = append(trace.Points, Point{
+				Location:  geo.Offset(truth, r.NormFloat64()*config.Noise, r.NormFloat64()*config.Noise),
+				Timestamp: config.Start + int64(math.Round(seconds)),
+			})
+		}
+
+		if last {
+			break
+		}
+	}
+
+	jitter(trace.Points, config.Jitter, r)
+
+	return trace, nil
+}
+
+// jitter moves timestamps of the points randomly within max deviation and keeps them increasing.
+func jitter(points []Point, deviation time.Duration, r *rand.Rand) {
+	d := int64(deviation / time.Second)
+
+	for i := range points {
+		if d > 0 {
+			points[i].Timestamp += r.Int64N(2*d+1) - d
+		}
+		if i > 0 && points[i].Timestamp <= points[i-1].Timestamp {
+			points[i].Timestamp = points[i-1].Timestamp + 1
+		}
+	}
+}
+
+// routeCoordinates returns the coordinates of a route geometry.
+// string geometries are polylines with precision of 5, as polyline6 geometries can't be decoded into string,
+// see gosrm.ErrGeometryMismatch.
+func routeCoordinates[T gosrm.GeometryType](g T) ([]gosrm.Coordinate, error) {
+	switch geom := any(g).(type) {
+	case string:
+		return gosrm.DecodePolyline(geom, 5)
+	case gosrm.LineString:
+		return geom.Coordinates, nil
+	case gosrm.AnyGeometry:
+		return geom.Coordinates(), nil
+	}
+
+	return nil, nil
+}
+
+// appendNodes appends nodes to a node sequence, leading nodes that overlap with its trailing nodes are skipped.
+func appendNodes(sequence, nodes []uint64) []uint64 {
+	for n := min(len(sequence), len(nodes)); n > 0; n-- {
+		if slices.Equal(sequence[len(sequence)-n:], nodes[:n]) {
+			return append(sequence, nodes[n:]...)
+		}
+	}
+	return append(sequence, nodes...)
+}
@@ -0,0 +1,88 @@
+package trace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mojixcoder/gosrm"
+	"github.com/stretchr/testify/assert"
+)
+
+// testRoute returns a route east along the equator for 100 seconds and then north for 50 seconds.
+func testRoute() gosrm.RouteType[gosrm.LineString] {
+	east := float32(gosrm.HaversineDistance(gosrm.Coordinate{0, 0}, gosrm.Coordinate{0.01, 0}))
+	north := float32(gosrm.HaversineDistance(gosrm.Coordinate{0.01, 0}, gosrm.Coordinate{0.01, 0.01}))
+
+	return gosrm.RouteType[gosrm.LineString]{
+		Geometry: gosrm.LineString{Coordinates: []gosrm.Coordinate{{0, 0}, {0.01, 0}, {0.01, 0.01}}},
+		Legs: []gosrm.RouteLeg[gosrm.LineString]{
+			{Annotation: gosrm.Annotation{Duration: []float32{100}, Distance: []float32{east}, Nodes: []uint64{1, 2}}},
+			{Annotation: gosrm.Annotation{Duration: []float32{50}, Distance: []float32{north}, Nodes: []uint64{2, 3}}},
+		},
+	}
+}
+
+func TestSynthesize(t *testing.T) {
+	trace, err := Synthesize(testRoute(), SyntheticConfig{Interval: 10 * time.Second, Start: 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, trace.Nodes)
+	assert.Len(t, trace.Points, 16)
+	assert.Equal(t, trace.Truth, locations(trace.Points))
+	assert.Equal(t, int64(1120), trace.Points[12].Timestamp)
+	assert.InDelta(t, 0.01, trace.Truth[12][0], 1e-9)
+	assert.InDelta(t, 0.004, trace.Truth[12][1], 1e-9)
+	assert.Equal(t, gosrm.Coordinate{0.01, 0.01}, trace.Truth[15])
+
+	trace, err = Synthesize(testRoute(), SyntheticConfig{Spacing: 500})
+	assert.NoError(t, err)
+	assert.Len(t, trace.Points, 6)
+	assert.InDelta(t, 500, gosrm.HaversineDistance(trace.Truth[0], trace.Truth[1]), 1)
+	assert.InDelta(t, 45, trace.Points[1].Timestamp, 1)
+
+	_, err = Synthesize(gosrm.RouteType[gosrm.LineString]{Geometry: testRoute().Geometry}, SyntheticConfig{})
+	assert.ErrorIs(t, err, ErrMissingAnnotations)
+}
+
+func TestSynthesize_Noise(t *testing.T) {
+	config := SyntheticConfig{Interval: time.Second, Noise: 10, DropoutRate: 0.3, Jitter: 3 * time.Second, Seed: 42}
+
+	trace, err := Synthesize(testRoute(), config)
+	assert.NoError(t, err)
+
+	same, err := Synthesize(testRoute(), config)
+	assert.NoError(t, err)
+	assert.Equal(t, trace, same)
+
+	// 30% of the 151 points are dropped except the first and the last ones.
+	assert.InDelta(t, 106, len(trace.Points), 15)
+	assert.Equal(t, gosrm.Coordinate{0, 0}, trace.Truth[0])
+	assert.Equal(t, gosrm.Coordinate{0.01, 0.01}, trace.Truth[len(trace.Truth)-1])
+
+	// The mean distance of 2D Gaussian noise is its standard deviation times sqrt(pi/2).
+	var total float64
+	for i, p := range trace.Points {
+		total += gosrm.HaversineDistance(p.Location, trace.Truth[i])
+	}
+	assert.InDelta(t, 12.5, total/float64(len(trace.Points)), 2)
+
+	for i := 1; i < len(trace.Points); i++ {
+		assert.Greater(t, trace.Points[i].Timestamp, trace.Points[i-1].Timestamp)
+	}
+	assert.InDelta(t, 150, trace.Points[len(trace.Points)-1].Timestamp, 3)
+}
+
+func TestAppendNodes(t *testing.T) {
+	assert.Equal(t, []uint64{1, 2}, appendNodes(nil, []uint64{1, 2}))
+	assert.Equal(t, []uint64{1, 2, 3}, appendNodes([]uint64{1, 2}, []uint64{2, 3}))
+	assert.Equal(t, []uint64{1, 2, 3, 4}, appendNodes([]uint64{1, 2, 3}, []uint64{2, 3, 4}))
+	assert.Equal(t, []uint64{1, 2, 3, 4}, appendNodes([]uint64{1, 2}, []uint64{3, 4}))
+}
+
+// locations returns the locations of points.
+func locations(points []Point) []gosrm.Coordinate {
+	var coordinates []gosrm.Coordinate
+	for _, p := range points {
+		coordinates = append(coordinates, p.Location)
+	}
+	return coordinates
+}
@@ -0,0 +1,354 @@
+// Package trace preprocesses raw GPS traces before they are matched using OSRM match service.
+// Points are sorted and deduplicated by time, speed outliers are removed, stationary periods are collapsed
+// and the trace is simplified. The result holds a match request with timestamps, radiuses and bearings
+// aligned to its coordinates.
+// Synthesize generates noisy traces of routes with known ground truth and ScoreMatch scores matchings against it.
+package trace
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/mojixcoder/gosrm"
+)
+
+// ErrShortTrace is returned when a trace has less than 2 points after preprocessing.
+var ErrShortTrace = errors.New("trace: trace has less than 2 points")
+
+const (
+	// DefaultMaxSpeed is the default max speed between points, in meters per second.
+	DefaultMaxSpeed float64 = 50
+
+	// DefaultStationaryRadius is the default radius of stationary periods, in meters.
+	DefaultStationaryRadius float64 = 10
+
+	// DefaultStationaryDuration is the default min duration of stationary periods.
+	DefaultStationaryDuration time.Duration = 30 * time.Second
+
+	// DefaultTolerance is the default tolerance of simplification, in meters.
+	DefaultTolerance float64 = 5
+
+	// DefaultBearingRange is the default range of bearings, in degrees.
+	DefaultBearingRange uint16 = 45
+
+	// DefaultUERE is the default user equivalent range error that HDOP is multiplied by, in meters.
+	DefaultUERE float64 = 5
+
+	// DefaultMinRadius is the default min radius of points, in meters.
+	DefaultMinRadius float64 = 5
+
+	// DefaultMaxRadius is the default max radius of points, in meters.
+	DefaultMaxRadius float64 = 50
+)
+
+type (
+	// Point is a GPS point of a trace.
+	Point struct {
+		// Location of the point.
+		Location gosrm.Coordinate
+
+		// Timestamp of the point in UNIX seconds.
+		Timestamp int64
+
+		// Accuracy is the horizontal accuracy of the point, in meters. It's 0 if it's unknown.
+		Accuracy float64
+
+		// HDOP is the horizontal dilution of precision of the point. It's 0 if it's unknown.
+		// It's only used if the accuracy is unknown.
+		HDOP float64
+	}
+
+	// Config is the config of preprocessing.
+	// Steps with a negative threshold are skipped, e.g. a negative tolerance doesn't simplify the trace.
+	Config struct {
+		// MaxSpeed is the max speed between points, faster points are outliers, in meters per second.
+		// Defaults to DefaultMaxSpeed.
+		MaxSpeed float64
+
+		// StationaryRadius is the max distance of stationary points from the first point of their period, in meters.
+		// Defaults to DefaultStationaryRadius.
+		StationaryRadius float64
+
+		// StationaryDuration is the min duration of stationary periods.
+		// Defaults to DefaultStationaryDuration.
+		StationaryDuration time.Duration
+
+		// Tolerance is the max distance of removed points from the simplified trace, in meters.
+		// Defaults to DefaultTolerance.
+		Tolerance float64
+
+		// BearingRange is the range of derived bearings, in degrees.
+		// Defaults to DefaultBearingRange.
+		BearingRange uint16
+
+		// UERE is the user equivalent range error that HDOP is multiplied by to get the radius, in meters.
+		// Defaults to DefaultUERE.
+		UERE float64
+
+		// MinRadius is the min radius of points, in meters.
+		// Defaults to DefaultMinRadius.
+		MinRadius float64
+
+		// MaxRadius is the max radius of points, in meters.
+		// Defaults to DefaultMaxRadius.
+		MaxRadius float64
+	}
+
+	// Result is a preprocessed trace.
+	Result struct {
+		// Request holds the coordinates of the points.
+		Request gosrm.Request
+
+		// Points are the points of the preprocessed trace.
+		Points []Point
+
+		// Timestamps are the timestamps of the points in UNIX seconds.
+		Timestamps []int64
+
+		// Radiuses are the radiuses of the points derived from their accuracy or HDOP, in meters.
+		// Points without either use gosrm.DefaultMatchRadius.
+		Radiuses []float32
+
+		// Bearings are the directions of travel at the points, derived from the next point.
+		// The last point uses the direction from the previous one.
+		Bearings []gosrm.Bearing
+	}
+)
+
+// Options returns the options of the timestamps, radiuses and bearings of the result.
+func (r Result) Options() []gosrm.Option {
+	return []gosrm.Option{
+		gosrm.WithTimestamps(r.Timestamps),
+		gosrm.WithRadiuses(r.Radiuses),
+		gosrm.WithBearings(r.Bearings),
+	}
+}
+
+// Preprocess preprocesses a trace and returns a match request of the profile with aligned options.
+// The points aren't modified.
+func Preprocess(points []Point, profile gosrm.Profile, config Config) (Result, error) {
+	config = withDefaults(config)
+
+	points = Deduplicate(points)
+	if config.MaxSpeed > 0 {
+		points = RemoveOutliers(points, config.MaxSpeed)
+	}
+	if config.StationaryRadius > 0 {
+		points = CollapseStationary(points, config.StationaryRadius, config.StationaryDuration)
+	}
+	if config.Tolerance > 0 {
+		points = Simplify(points, config.Tolerance)
+	}
+
+	if len(points) < 2 {
+		return Result{}, ErrShortTrace
+	}
+
+	res := Result{
+		Request:    gosrm.Request{Profile: profile, Coordinates: make([]gosrm.Coordinate, len(points))},
+		Points:     points,
+		Timestamps: make([]int64, len(points)),
+		Radiuses:   make([]float32, len(points)),
+		Bearings:   Bearings(points, config.BearingRange),
+	}
+
+	for i, p := range points {
+		res.Request.Coordinates[i] = p.Location
+		res.Timestamps[i] = p.Timestamp
+		res.Radiuses[i] = radius(p, config)
+	}
+
+	return res, nil
+}
+
+// withDefaults returns the config with defaults of unset fields.
+func withDefaults(config Config) Config {
+	if config.MaxSpeed == 0 {
+		config.MaxSpeed = DefaultMaxSpeed
+	}
+	if config.StationaryRadius == 0 {
+		config.StationaryRadius = DefaultStationaryRadius
+	}
+	if config.StationaryDuration <= 0 {
+		config.StationaryDuration = DefaultStationaryDuration
+	}
+	if config.Tolerance == 0 {
+		config.Tolerance = DefaultTolerance
+	}
+	if config.BearingRange == 0 {
+		config.BearingRange = DefaultBearingRange
+	}
+	if config.UERE <= 0 {
+		config.UERE = DefaultUERE
+	}
+	if config.MinRadius <= 0 {
+		config.MinRadius = DefaultMinRadius
+	}
+	if config.MaxRadius <= 0 {
+		config.MaxRadius = DefaultMaxRadius
+	}
+
+	return config
+}
+
+// radius returns the radius of a point, in meters.
+func radius(p Point, config Config) float32 {
+	var r float64
+	switch {
+	case p.Accuracy > 0:
+		r = p.Accuracy
+	case p.HDOP > 0:
+		r = p.HDOP * config.UERE
+	default:
+		return gosrm.DefaultMatchRadius
+	}
+
+	return float32(min(max(r, config.MinRadius), config.MaxRadius))
+}
+
+// Deduplicate returns the points sorted by timestamp, only the first point of each timestamp is kept.
+func Deduplicate(points []Point) []Point {
+	sorted := slices.Clone(points)
+	slices.SortStableFunc(sorted, func(a, b Point) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+
+	return slices.CompactFunc(sorted, func(a, b Point) bool {
+		return a.Timestamp == b.Timestamp
+	})
+}
+
+// RemoveOutliers returns the points without the ones that are reached faster than maxSpeed, in meters per second.
+// The speed to a point is measured from the last kept point, so a jump and the points after it are removed
+// until the trace gets back to a reachable location. The first point is removed if it's an outlier of the next two.
+// The points must be sorted and deduplicated by timestamp.
+func RemoveOutliers(points []Point, maxSpeed float64) []Point {
+	if len(points) > 2 && speed(points[0], points[1]) > maxSpeed && speed(points[1], points[2]) <= maxSpeed {
+		points = points[1:]
+	}
+
+	var kept []Point
+	for _, p := range points {
+		if n := len(kept); n > 0 && speed(kept[n-1], p) > maxSpeed {
+			continue
+		}
+		kept = append(kept, p)
+	}
+
+	return kept
+}
+
+// speed returns the speed between two points, in meters per second.
+func speed(a, b Point) float64 {
+	return gosrm.HaversineDistance(a.Location, b.Location) / float64(b.Timestamp-a.Timestamp)
+}
+
+// CollapseStationary returns the points with stationary periods collapsed to one point.
+// A period is stationary if its points are within radius of its first point, in meters, and it lasts at least
+// minDuration. The period is replaced by its first point moved to the centroid of the period.
+// The points must be sorted and deduplicated by timestamp.
+func CollapseStationary(points []Point, radius float64, minDuration time.Duration) []Point {
+	var collapsed []Point
+
+	for i := 0; i < len(points); {
+		j := i + 1
+		for j < len(points) && gosrm.HaversineDistance(points[i].Location, points[j].Location) <= radius {
+			j++
+		}
+
+		if time.Duration(points[j-1].Timestamp-points[i].Timestamp)*time.Second < minDuration {
+			collapsed = append(collapsed, points[i])
+			i++
+			continue
+		}
+
+		p := points[i]
+		p.Location = gosrm.Coordinate{}
+		for _, q := range points[i:j] {
+			p.Location[0] += q.Location[0] / float64(j-i)
+			p.Location[1] += q.Location[1] / float64(j-i)
+		}
+		collapsed = append(collapsed, p)
+		i = j
+	}
+
+	return collapsed
+}
+
+// Simplify returns the points simplified with Douglas-Peucker algorithm.
+// Removed points are within tolerance of the simplified trace, in meters. Kept points keep their timestamps.
+func Simplify(points []Point, tolerance float64) []Point {
+	if len(points) < 3 {
+		return slices.Clone(points)
+	}
+
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+
+	stack := [][2]int{{0, len(points) - 1}}
+	for len(stack) > 0 {
+		first, last := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+
+		farthest, distance := -1, tolerance
+		for i := first + 1; i < last; i++ {
+			if d := segmentDistance(points[i].Location, points[first].Location, points[last].Location); d > distance {
+				farthest, distance = i, d
+			}
+		}
+
+		if farthest >= 0 {
+			keep[farthest] = true
+			stack = append(stack, [2]int{first, farthest}, [2]int{farthest, last})
+		}
+	}
+
+	var simplified []Point
+	for i, p := range points {
+		if keep[i] {
+			simplified = append(simplified, p)
+		}
+	}
+
+	return simplified
+}
+
+// segmentDistance returns the distance of p from the segment a-b, in meters.
+// It uses an equirectangular projection around p, which is accurate for short segments.
+func segmentDistance(p, a, b gosrm.Coordinate) float64 {
+	scale := math.Cos(p[1] * math.Pi / 180)
+	ax, ay := (a[0]-p[0])*scale, a[1]-p[1]
+	bx, by := (b[0]-p[0])*scale, b[1]-p[1]
+
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = min(max(-(ax*dx+ay*dy)/l, 0), 1)
+	}
+
+	return gosrm.HaversineDistance(p, gosrm.Coordinate{p[0] + (ax+t*dx)/scale, p[1] + ay + t*dy})
+}
+
+// Bearings returns the directions of travel at the points with given range, in degrees.
+// Each point uses the direction to the next point and the last one uses the direction from the previous one.
+func Bearings(points []Point, bearingRange uint16) []gosrm.Bearing {
+	if len(points) < 2 {
+		return nil
+	}
+
+	bearings := make([]gosrm.Bearing, len(points))
+	for i := range points {
+		a, b := i, i+1
+		if b == len(points) {
+			a, b = i-1, i
+		}
+
+		value := uint16(math.Round(gosrm.InitialBearing(points[a].Location, points[b].Location))) % 360
+		bearings[i] = gosrm.Bearing{Value: value, Range: bearingRange}
+	}
+
+	return bearings
+}
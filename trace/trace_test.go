@@ -0,0 +1,118 @@
+package trace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mojixcoder/gosrm"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPoints returns points moving east along the equator at 10 meters per second, one every 10 seconds.
+func testPoints(n int) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{Location: gosrm.Coordinate{float64(i) * 100 / 111195, 0}, Timestamp: int64(i * 10)}
+	}
+	return points
+}
+
+// timestamps returns the timestamps of points.
+func timestamps(points []Point) []int64 {
+	var ts []int64
+	for _, p := range points {
+		ts = append(ts, p.Timestamp)
+	}
+	return ts
+}
+
+func TestDeduplicate(t *testing.T) {
+	points := []Point{{Timestamp: 20}, {Timestamp: 10, Accuracy: 1}, {Timestamp: 10, Accuracy: 2}, {Timestamp: 30}}
+
+	deduplicated := Deduplicate(points)
+	assert.Equal(t, []int64{10, 20, 30}, timestamps(deduplicated))
+	assert.Equal(t, float64(1), deduplicated[0].Accuracy)
+	assert.Equal(t, int64(20), points[0].Timestamp)
+}
+
+func TestRemoveOutliers(t *testing.T) {
+	points := testPoints(5)
+	points[2].Location[1] = 0.1
+
+	assert.Equal(t, []int64{0, 10, 30, 40}, timestamps(RemoveOutliers(points, 50)))
+
+	// The first point is an outlier of the next two.
+	points = testPoints(4)
+	points[0].Location[1] = -0.1
+	assert.Equal(t, []int64{10, 20, 30}, timestamps(RemoveOutliers(points, 50)))
+
+	assert.Len(t, RemoveOutliers(testPoints(4), 50), 4)
+	assert.Empty(t, RemoveOutliers(nil, 50))
+}
+
+func TestCollapseStationary(t *testing.T) {
+	points := testPoints(3)
+	for i := 0; i < 4; i++ {
+		p := points[1]
+		p.Timestamp += int64(i+1) * 10
+		p.Location[1] = float64(i%2) * 0.00002
+		points = append(points, p)
+	}
+	points[2].Timestamp = 60
+	points = Deduplicate(points)
+
+	collapsed := CollapseStationary(points, 10, 30*time.Second)
+	assert.Equal(t, []int64{0, 10, 60}, timestamps(collapsed))
+	assert.InDelta(t, points[1].Location[0], collapsed[1].Location[0], 1e-9)
+	assert.InDelta(t, 0.00002*2/5, collapsed[1].Location[1], 1e-9)
+
+	// Short periods aren't stationary.
+	assert.Len(t, CollapseStationary(points, 10, time.Hour), len(points))
+}
+
+func TestSimplify(t *testing.T) {
+	points := testPoints(5)
+	points[2].Location[1] = 0.0001
+
+	assert.Equal(t, []int64{0, 20, 40}, timestamps(Simplify(points, 6)))
+	assert.Len(t, Simplify(points, 20), 2)
+	assert.Len(t, Simplify(points[:2], 5), 2)
+}
+
+func TestBearings(t *testing.T) {
+	points := []Point{
+		{Location: gosrm.Coordinate{0, 0}},
+		{Location: gosrm.Coordinate{0.001, 0}},
+		{Location: gosrm.Coordinate{0.001, 0.001}},
+	}
+
+	assert.Equal(t, []gosrm.Bearing{{Value: 90, Range: 30}, {Value: 0, Range: 30}, {Value: 0, Range: 30}}, Bearings(points, 30))
+	assert.Nil(t, Bearings(points[:1], 30))
+}
+
+func TestPreprocess(t *testing.T) {
+	points := testPoints(6)
+	points[0].Accuracy = 3
+	points[1].HDOP = 2
+	points[2].Accuracy = 100
+	points[4].Location[1] = 0.1
+	points = append(points, points[5])
+
+	res, err := Preprocess(points, gosrm.ProfileCar, Config{Tolerance: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, gosrm.ProfileCar, res.Request.Profile)
+	assert.Len(t, res.Request.Coordinates, 5)
+	assert.Equal(t, []int64{0, 10, 20, 30, 50}, res.Timestamps)
+	assert.Equal(t, []float32{5, 10, 50, gosrm.DefaultMatchRadius, gosrm.DefaultMatchRadius}, res.Radiuses)
+	assert.Equal(t, uint16(90), res.Bearings[0].Value)
+	assert.Equal(t, DefaultBearingRange, res.Bearings[0].Range)
+
+	assert.Len(t, res.Options(), 3)
+
+	res, err = Preprocess(testPoints(4), gosrm.ProfileCar, Config{})
+	assert.NoError(t, err)
+	assert.Len(t, res.Points, 2)
+
+	_, err = Preprocess(testPoints(1), gosrm.ProfileCar, Config{})
+	assert.ErrorIs(t, err, ErrShortTrace)
+}
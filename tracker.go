@@ -0,0 +1,355 @@
+package gosrm
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrEmptyGeometry is returned when a route has no geometry to track, e.g. when it was requested without overview.
+var ErrEmptyGeometry = errors.New("gosrm: route has no geometry")
+
+const (
+	// DefaultOffRouteDistance is the default max distance of a position from the route before it's off-route, in meters.
+	DefaultOffRouteDistance float64 = 50
+
+	// DefaultOffRouteBearing is the default max difference of heading and route bearing before a position is off-route, in degrees.
+	DefaultOffRouteBearing float64 = 90
+
+	// DefaultTrackerSearchDistance is the default distance around the last position searched for the next one, in meters.
+	DefaultTrackerSearchDistance float64 = 500
+)
+
+type (
+	// TrackerConfig is the config of a route tracker.
+	TrackerConfig struct {
+		// OffRouteDistance is the max distance of a position from the route, in meters.
+		// Defaults to DefaultOffRouteDistance.
+		OffRouteDistance float64
+
+		// OffRouteBearing is the max difference of the heading and the bearing of the route, in degrees.
+		// Defaults to DefaultOffRouteBearing.
+		OffRouteBearing float64
+
+		// SearchDistance is the distance around the last position on the route which is searched first, in meters.
+		// The whole route is only searched if the position is off-route within that distance.
+		// Defaults to DefaultTrackerSearchDistance.
+		SearchDistance float64
+	}
+
+	// RouteProgress is the progress along a route.
+	RouteProgress struct {
+		// Location is the position projected on the route.
+		Location Coordinate
+
+		// DistanceFromRoute is the distance of the position from the route, in meters.
+		DistanceFromRoute float64
+
+		// Bearing is the bearing of the route at the projected location, in degrees.
+		Bearing float64
+
+		// DistanceTraveled is the distance along the route from its start, in meters.
+		DistanceTraveled float64
+
+		// DistanceRemaining is the distance along the route to its end, in meters.
+		DistanceRemaining float64
+
+		// DurationRemaining is the estimated duration to the end of the route, in seconds.
+		DurationRemaining float64
+
+		// LegIndex is the index of the current leg.
+		LegIndex int
+
+		// LegDistanceRemaining is the distance to the end of the current leg, in meters.
+		LegDistanceRemaining float64
+
+		// StepIndex is the index of the current step in the current leg, it's 0 if the route has no steps.
+		StepIndex int
+
+		// StepDistanceRemaining is the distance to the end of the current step, in meters.
+		StepDistanceRemaining float64
+
+		// OffRoute is true if the position is too far from the route or its heading deviates too much from the route.
+		OffRoute bool
+	}
+
+	// RouteTracker tracks the progress of positions along a route using linear referencing.
+	// Positions are searched near the last one first, so streaming updates only look at a small part of the route.
+	// It's not safe for concurrent use.
+	RouteTracker struct {
+		config TrackerConfig
+
+		points []Coordinate
+
+		// distances[i] is the distance along the route to points[i], in meters.
+		distances []float64
+
+		// durations[i] is the duration along the route to points[i], in seconds.
+		durations []float64
+
+		// legEnds[k] is the distance along the route to the end of the k-th leg, in meters.
+		legEnds []float64
+
+		// stepEnds[k][s] is the distance along the route to the end of the s-th step of the k-th leg, in meters.
+		stepEnds [][]float64
+
+		progress RouteProgress
+		tracking bool
+	}
+)
+
+// NewRouteTracker returns a new tracker of the route.
+// The route needs a full overview geometry or steps. If annotations with durations are requested,
+// remaining durations use them, otherwise the durations of legs are spread over their distance.
+// Legs and steps are located by their distances, scaled to the length of the geometry.
+func NewRouteTracker[T GeometryType](route RouteType[T], config TrackerConfig) (*RouteTracker, error) {
+	if config.OffRouteDistance <= 0 {
+		config.OffRouteDistance = DefaultOffRouteDistance
+	}
+	if config.OffRouteBearing <= 0 {
+		config.OffRouteBearing = DefaultOffRouteBearing
+	}
+	if config.SearchDistance <= 0 {
+		config.SearchDistance = DefaultTrackerSearchDistance
+	}
+
+	points, err := trackedPoints(route)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) < 2 {
+		return nil, ErrEmptyGeometry
+	}
+
+	t := RouteTracker{config: config, points: points, distances: make([]float64, len(points))}
+	for i := 1; i < len(points); i++ {
+		t.distances[i] = t.distances[i-1] + HaversineDistance(points[i-1], points[i])
+	}
+	length := t.distances[len(points)-1]
+
+	t.legEnds, t.stepEnds = []float64{length}, [][]float64{nil}
+
+	var legDistance float64
+	for _, leg := range route.Legs {
+		legDistance += float64(leg.Distance)
+	}
+
+	if legDistance > 0 {
+		t.legEnds, t.stepEnds = nil, nil
+
+		var legStart float64
+		for _, leg := range route.Legs {
+			legEnd := legStart + length*float64(leg.Distance)/legDistance
+			t.legEnds = append(t.legEnds, legEnd)
+			t.stepEnds = append(t.stepEnds, scaledEnds(leg.Steps, legStart, legEnd))
+			legStart = legEnd
+		}
+
+		// Avoid floating point errors at the end of the route.
+		t.legEnds[len(t.legEnds)-1] = length
+	}
+
+	t.durations = trackedDurations(route, t.distances, t.legEnds)
+
+	return &t, nil
+}
+
+// scaledEnds returns the distances along the route to the ends of the steps of a leg,
+// their distances are scaled to fit between start and end.
+func scaledEnds[T GeometryType](steps []RouteStep[T], start, end float64) []float64 {
+	var total float64
+	for _, step := range steps {
+		total += float64(step.Distance)
+	}
+
+	ends := make([]float64, len(steps))
+	var traveled float64
+	for i, step := range steps {
+		traveled += float64(step.Distance)
+		ends[i] = end
+		if total > 0 {
+			ends[i] = start + (end-start)*traveled/total
+		}
+	}
+
+	return ends
+}
+
+// trackedPoints returns the geometry of the route, from its overview or the geometries of its steps.
+func trackedPoints[T GeometryType](route RouteType[T]) ([]Coordinate, error) {
+	points, err := coordinatesOf(route.Geometry)
+	if err != nil || len(points) > 0 {
+		return points, err
+	}
+
+	for _, leg := range route.Legs {
+		for _, step := range leg.Steps {
+			stepPoints, err := coordinatesOf(step.Geometry)
+			if err != nil {
+				return nil, err
+			}
+
+			// Consecutive steps share their first and last coordinates.
+			if n := len(points); n > 0 && len(stepPoints) > 0 && points[n-1] == stepPoints[0] {
+				stepPoints = stepPoints[1:]
+			}
+			points = append(points, stepPoints...)
+		}
+	}
+
+	return points, nil
+}
+
+// trackedDurations returns the duration along the route to each point.
+// Annotation durations are used if they match the geometry, otherwise leg durations are spread over their distance.
+func trackedDurations[T GeometryType](route RouteType[T], distances, legEnds []float64) []float64 {
+	durations := make([]float64, len(distances))
+
+	var annotated []float32
+	for _, leg := range route.Legs {
+		annotated = append(annotated, leg.Annotation.Duration...)
+	}
+
+	if len(annotated) == len(distances)-1 {
+		for i, d := range annotated {
+			durations[i+1] = durations[i] + float64(d)
+		}
+		return durations
+	}
+
+	legDurations := make([]float64, len(legEnds))
+	if len(route.Legs) == len(legEnds) {
+		for k, leg := range route.Legs {
+			legDurations[k] = float64(leg.Duration)
+		}
+	} else {
+		legDurations[0] = float64(route.Duration)
+	}
+
+	// elapsed is the duration to the start of the leg of the point.
+	var elapsed, legStart float64
+	leg := 0
+	for i, d := range distances {
+		for leg < len(legEnds)-1 && d > legEnds[leg] {
+			elapsed += legDurations[leg]
+			legStart = legEnds[leg]
+			leg++
+		}
+
+		if legLength := legEnds[leg] - legStart; legLength > 0 {
+			durations[i] = elapsed + legDurations[leg]*(d-legStart)/legLength
+		} else {
+			durations[i] = elapsed
+		}
+	}
+
+	return durations
+}
+
+// Progress returns the progress of the last update.
+func (t *RouteTracker) Progress() RouteProgress {
+	return t.progress
+}
+
+// Length returns the length of the route geometry, in meters.
+func (t *RouteTracker) Length() float64 {
+	return t.distances[len(t.distances)-1]
+}
+
+// Update projects a position on the route and returns the progress.
+// heading is the direction of travel in degrees clockwise from north, a negative heading is unknown and not checked.
+func (t *RouteTracker) Update(location Coordinate, heading float64) RouteProgress {
+	segment, fraction, distance := -1, 0.0, math.Inf(1)
+
+	if t.tracking {
+		from := t.segmentAt(t.progress.DistanceTraveled - t.config.SearchDistance)
+		to := t.segmentAt(t.progress.DistanceTraveled + t.config.SearchDistance)
+		segment, fraction, distance = t.project(location, from, to)
+	}
+
+	if distance > t.config.OffRouteDistance {
+		if s, f, d := t.project(location, 0, len(t.points)-2); d < distance {
+			segment, fraction, distance = s, f, d
+		}
+	}
+
+	a, b := t.points[segment], t.points[segment+1]
+	traveled := t.distances[segment] + fraction*(t.distances[segment+1]-t.distances[segment])
+	elapsed := t.durations[segment] + fraction*(t.durations[segment+1]-t.durations[segment])
+
+	p := RouteProgress{
+		Location:          interpolate(a, b, fraction),
+		DistanceFromRoute: distance,
+		Bearing:           InitialBearing(a, b),
+		DistanceTraveled:  traveled,
+		DistanceRemaining: t.Length() - traveled,
+		DurationRemaining: t.durations[len(t.durations)-1] - elapsed,
+	}
+
+	// Legs and steps start once the end of the previous one is reached, so arrival steps are current at the end of legs.
+	p.LegIndex = indexAfter(t.legEnds, traveled)
+	p.LegDistanceRemaining = t.legEnds[p.LegIndex] - traveled
+	p.StepDistanceRemaining = p.LegDistanceRemaining
+	if ends := t.stepEnds[p.LegIndex]; len(ends) > 0 {
+		p.StepIndex = indexAfter(ends, traveled)
+		p.StepDistanceRemaining = ends[p.StepIndex] - traveled
+	}
+
+	p.OffRoute = distance > t.config.OffRouteDistance ||
+		(heading >= 0 && bearingDifference(heading, p.Bearing) > t.config.OffRouteBearing)
+
+	t.progress, t.tracking = p, true
+
+	return p
+}
+
+// indexAfter returns the index of the first end that is after distance, or the last index if there is none.
+func indexAfter(ends []float64, distance float64) int {
+	i := sort.Search(len(ends), func(i int) bool { return ends[i] > distance })
+	return min(i, len(ends)-1)
+}
+
+// segmentAt returns the index of the segment at a distance along the route.
+func (t *RouteTracker) segmentAt(distance float64) int {
+	i := sort.SearchFloat64s(t.distances, distance) - 1
+	return min(max(i, 0), len(t.points)-2)
+}
+
+// locate returns the location and the elapsed duration at a distance along the route.
+func (t *RouteTracker) locate(distance float64) (Coordinate, float64) {
+	segment := t.segmentAt(distance)
+
+	var fraction float64
+	if length := t.distances[segment+1] - t.distances[segment]; length > 0 {
+		fraction = min(max((distance-t.distances[segment])/length, 0), 1)
+	}
+
+	location := interpolate(t.points[segment], t.points[segment+1], fraction)
+	return location, t.durations[segment] + fraction*(t.durations[segment+1]-t.durations[segment])
+}
+
+// locateElapsed returns the location at an elapsed duration along the route.
+func (t *RouteTracker) locateElapsed(elapsed float64) Coordinate {
+	segment := sort.SearchFloat64s(t.durations, elapsed) - 1
+	segment = min(max(segment, 0), len(t.points)-2)
+
+	var fraction float64
+	if duration := t.durations[segment+1] - t.durations[segment]; duration > 0 {
+		fraction = min(max((elapsed-t.durations[segment])/duration, 0), 1)
+	}
+
+	return interpolate(t.points[segment], t.points[segment+1], fraction)
+}
+
+// project returns the closest projection of a location on the segments from..to.
+func (t *RouteTracker) project(location Coordinate, from, to int) (segment int, fraction, distance float64) {
+	segment, distance = from, math.Inf(1)
+
+	for i := from; i <= to; i++ {
+		if f, d := projectOnSegment(location, t.points[i], t.points[i+1]); d < distance {
+			segment, fraction, distance = i, f, d
+		}
+	}
+
+	return segment, fraction, distance
+}
@@ -0,0 +1,137 @@
+package gosrm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTrackedRoute returns a route east along the equator and then north, with a leg for each direction.
+func testTrackedRoute() RouteType[LineString] {
+	east := HaversineDistance(Coordinate{0, 0}, Coordinate{0.02, 0})
+	north := HaversineDistance(Coordinate{0.02, 0}, Coordinate{0.02, 0.01})
+
+	return RouteType[LineString]{
+		Distance: float32(east + north),
+		Duration: 300,
+		Geometry: LineString{Coordinates: []Coordinate{{0, 0}, {0.02, 0}, {0.02, 0.01}}},
+		Legs: []RouteLeg[LineString]{
+			{
+				Distance: float32(east),
+				Duration: 200,
+				Steps: []RouteStep[LineString]{
+					{Distance: float32(east / 2)},
+					{Distance: float32(east / 2)},
+					{Distance: 0},
+				},
+			},
+			{
+				Distance: float32(north),
+				Duration: 100,
+				Steps: []RouteStep[LineString]{
+					{Distance: float32(north)},
+					{Distance: 0},
+				},
+			},
+		},
+	}
+}
+
+func TestRouteTracker_Update(t *testing.T) {
+	tracker, err := NewRouteTracker(testTrackedRoute(), TrackerConfig{})
+	assert.NoError(t, err)
+	assert.InDelta(t, 3336, tracker.Length(), 1)
+	assert.Equal(t, RouteProgress{}, tracker.Progress())
+
+	p := tracker.Update(Coordinate{0.005, 0.0001}, 90)
+	assert.Equal(t, p, tracker.Progress())
+	assert.InDelta(t, 0.005, p.Location[0], 1e-9)
+	assert.InDelta(t, 0, p.Location[1], 1e-9)
+	assert.InDelta(t, 11.1, p.DistanceFromRoute, 0.1)
+	assert.InDelta(t, 90, p.Bearing, 1e-6)
+	assert.InDelta(t, 556, p.DistanceTraveled, 1)
+	assert.InDelta(t, 2780, p.DistanceRemaining, 1)
+	assert.InDelta(t, 250, p.DurationRemaining, 0.1)
+	assert.Equal(t, 0, p.LegIndex)
+	assert.InDelta(t, 1668, p.LegDistanceRemaining, 1)
+	assert.Equal(t, 0, p.StepIndex)
+	assert.InDelta(t, 556, p.StepDistanceRemaining, 1)
+	assert.False(t, p.OffRoute)
+
+	p = tracker.Update(Coordinate{0.015, 0}, -1)
+	assert.Equal(t, 0, p.LegIndex)
+	assert.Equal(t, 1, p.StepIndex)
+	assert.InDelta(t, 556, p.StepDistanceRemaining, 1)
+
+	p = tracker.Update(Coordinate{0.0201, 0.005}, 10)
+	assert.Equal(t, 1, p.LegIndex)
+	assert.Equal(t, 0, p.StepIndex)
+	assert.InDelta(t, 0, p.Bearing, 1e-6)
+	assert.InDelta(t, 556, p.DistanceRemaining, 1)
+	assert.InDelta(t, 50, p.DurationRemaining, 0.1)
+	assert.False(t, p.OffRoute)
+
+	// Heading in the opposite direction.
+	p = tracker.Update(Coordinate{0.0201, 0.005}, 180)
+	assert.True(t, p.OffRoute)
+
+	// Too far from the route.
+	p = tracker.Update(Coordinate{0.01, 0.005}, -1)
+	assert.True(t, p.OffRoute)
+	assert.InDelta(t, 556, p.DistanceFromRoute, 1)
+
+	// Arrival.
+	p = tracker.Update(Coordinate{0.02, 0.0101}, -1)
+	assert.Equal(t, 1, p.LegIndex)
+	assert.Equal(t, 1, p.StepIndex)
+	assert.InDelta(t, 0, p.DistanceRemaining, 1e-6)
+	assert.InDelta(t, 0, p.DurationRemaining, 1e-6)
+	assert.False(t, p.OffRoute)
+}
+
+func TestRouteTracker_SearchDistance(t *testing.T) {
+	tracker, err := NewRouteTracker(testTrackedRoute(), TrackerConfig{SearchDistance: 100})
+	assert.NoError(t, err)
+
+	p := tracker.Update(Coordinate{0.001, 0}, -1)
+	assert.InDelta(t, 111, p.DistanceTraveled, 1)
+
+	// Positions outside the searched distance are found by searching the whole route.
+	p = tracker.Update(Coordinate{0.02, 0.005}, -1)
+	assert.InDelta(t, 2780, p.DistanceTraveled, 1)
+	assert.False(t, p.OffRoute)
+}
+
+func TestNewRouteTracker(t *testing.T) {
+	route := testTrackedRoute()
+	route.Legs[0].Annotation.Duration = []float32{150}
+	route.Legs[1].Annotation.Duration = []float32{150}
+
+	tracker, err := NewRouteTracker(route, TrackerConfig{})
+	assert.NoError(t, err)
+	assert.InDelta(t, 262.5, tracker.Update(Coordinate{0.005, 0}, -1).DurationRemaining, 0.1)
+
+	// Geometry of steps is used if there is no overview.
+	route.Geometry = LineString{}
+	route.Legs[0].Steps[0].Geometry = LineString{Coordinates: []Coordinate{{0, 0}, {0.01, 0}}}
+	route.Legs[0].Steps[1].Geometry = LineString{Coordinates: []Coordinate{{0.01, 0}, {0.02, 0}}}
+	route.Legs[1].Steps[0].Geometry = LineString{Coordinates: []Coordinate{{0.02, 0}, {0.02, 0.01}}}
+
+	tracker, err = NewRouteTracker(route, TrackerConfig{})
+	assert.NoError(t, err)
+	assert.InDelta(t, 3336, tracker.Length(), 1)
+
+	// Route duration is spread over the route without legs.
+	tracker, err = NewRouteTracker(RouteType[string]{Duration: 100, Geometry: EncodePolyline([]Coordinate{{0, 0}, {0.01, 0}}, 5)}, TrackerConfig{})
+	assert.NoError(t, err)
+	p := tracker.Update(Coordinate{0.0025, 0}, -1)
+	assert.InDelta(t, 75, p.DurationRemaining, 0.1)
+	assert.Equal(t, 0, p.LegIndex)
+	assert.InDelta(t, 834, p.StepDistanceRemaining, 1)
+
+	_, err = NewRouteTracker(RouteType[LineString]{}, TrackerConfig{})
+	assert.ErrorIs(t, err, ErrEmptyGeometry)
+
+	_, err = NewRouteTracker(RouteType[string]{Geometry: "_"}, TrackerConfig{})
+	assert.ErrorIs(t, err, ErrInvalidPolyline)
+}
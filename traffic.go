@@ -0,0 +1,372 @@
+package gosrm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// ErrInvalidTrafficModel is returned when a traffic model is not valid.
+var ErrInvalidTrafficModel = errors.New("gosrm: invalid traffic model")
+
+type (
+	// TrafficProfile is a weekly profile of traffic, the multipliers of free-flow durations by day of week and time of day.
+	// The zero value has a multiplier of 1 at all times.
+	TrafficProfile struct {
+		// Multipliers[day][slot] is the multiplier of durations in a time of day slot of a day, indexed by time.Weekday.
+		// Slots of a day are equal, e.g. 24 slots are hourly multipliers. Days without slots have a multiplier of 1.
+		Multipliers [7][]float64
+	}
+
+	// TrafficSegment is a segment between two OSM nodes, see AnnotationsNodes.
+	TrafficSegment struct {
+		// From is the OSM node ID the segment starts at.
+		From uint64
+
+		// To is the OSM node ID the segment ends at.
+		To uint64
+	}
+
+	// TrafficModel is the traffic profiles of roads.
+	// Segment profiles are used first, then class profiles and then the default profile.
+	TrafficModel struct {
+		// Location is the time zone of the profiles. Defaults to UTC.
+		Location *time.Location
+
+		// Default is the profile of roads without a segment or class profile.
+		Default TrafficProfile
+
+		// Classes are the profiles of road classes, see Intersection.Classes.
+		// If an intersection has several classes, the first one with a profile is used.
+		Classes map[string]TrafficProfile
+
+		// Segments are the profiles of node pairs.
+		Segments map[TrafficSegment]TrafficProfile
+	}
+
+	// TrafficLegETA is the time-dependent ETA of a route leg.
+	TrafficLegETA struct {
+		// Departure is the departure time from the start of the leg.
+		Departure time.Time
+
+		// Arrival is the arrival time at the end of the leg.
+		Arrival time.Time
+
+		// Arrivals are the arrival times at the end of each pair of coordinates of the annotation of the leg.
+		Arrivals []time.Time
+	}
+
+	// TrafficETA is the time-dependent ETA of a route.
+	TrafficETA struct {
+		// Departure is the departure time.
+		Departure time.Time
+
+		// Arrival is the arrival time at the end of the route.
+		Arrival time.Time
+
+		// Duration is the time-dependent duration of the route.
+		Duration time.Duration
+
+		// FreeFlowDuration is the duration of the route without traffic.
+		FreeFlowDuration time.Duration
+
+		// Legs are the ETAs of the legs.
+		Legs []TrafficLegETA
+	}
+)
+
+// NewTrafficProfile returns a profile with a multiplier of 1 in the given number of equal slots of each day.
+func NewTrafficProfile(slots int) TrafficProfile {
+	var p TrafficProfile
+	for day := range p.Multipliers {
+		p.Multipliers[day] = slices.Repeat([]float64{1}, slots)
+	}
+	return p
+}
+
+// Set sets the multiplier of the slots of a day which overlap the period from..to, as wall clock durations since midnight.
+// The day needs slots, see NewTrafficProfile.
+func (p *TrafficProfile) Set(day time.Weekday, from, to time.Duration, multiplier float64) {
+	slots := p.Multipliers[day]
+	if len(slots) == 0 {
+		return
+	}
+
+	length := 24 * time.Hour / time.Duration(len(slots))
+	for i := range slots {
+		if start := time.Duration(i) * length; start < to && start+length > from {
+			slots[i] = multiplier
+		}
+	}
+}
+
+// Multiplier returns the multiplier at a time, in the time zone of the time.
+func (p TrafficProfile) Multiplier(t time.Time) float64 {
+	multiplier, _ := p.slot(t)
+	return multiplier
+}
+
+// Arrival returns the arrival time of traveling a free-flow duration from the departure time,
+// in the time zone of the departure time. Durations are integrated over the slots they pass,
+// so a slower slot that starts on the way only slows down the rest of the travel.
+// Multipliers which aren't positive and finite are treated as 1.
+func (p TrafficProfile) Arrival(departure time.Time, freeFlow time.Duration) time.Time {
+	t, remaining := departure, freeFlow.Seconds()
+
+	for remaining > 0 {
+		multiplier, end := p.slot(t)
+
+		// available is the free-flow duration that can be traveled until the end of the slot.
+		available := end.Sub(t).Seconds() / multiplier
+		if remaining <= available {
+			return t.Add(seconds(remaining * multiplier))
+		}
+
+		remaining -= available
+		t = end
+	}
+
+	return t
+}
+
+// slot returns the multiplier at a time and the end of its slot.
+// Slots are in wall clock time, so slots of days with a DST change can be shorter or longer.
+func (p TrafficProfile) slot(t time.Time) (float64, time.Time) {
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+
+	slots := p.Multipliers[t.Weekday()]
+	if len(slots) == 0 {
+		return 1, wallClock(t, 24*time.Hour, 24*time.Hour-clock)
+	}
+
+	length := 24 * time.Hour / time.Duration(len(slots))
+	i := min(int(clock/length), len(slots)-1)
+	end := time.Duration(i+1) * length
+	if i == len(slots)-1 {
+		end = 24 * time.Hour
+	}
+
+	multiplier := slots[i]
+	if !(multiplier > 0) || math.IsInf(multiplier, 1) {
+		multiplier = 1
+	}
+
+	return multiplier, wallClock(t, end, end-clock)
+}
+
+// wallClock returns the time of the day of t at a wall clock duration since midnight.
+// Wall clock times that are skipped or repeated by a DST change can be before t, then t plus elapsed is returned.
+func wallClock(t time.Time, clock, elapsed time.Duration) time.Time {
+	w := time.Date(t.Year(), t.Month(), t.Day(), int(clock/time.Hour), int(clock%time.Hour/time.Minute),
+		int(clock%time.Minute/time.Second), int(clock%time.Second), t.Location())
+	if !w.After(t) {
+		return t.Add(elapsed)
+	}
+	return w
+}
+
+// validate returns ErrInvalidTrafficModel if the profile has a multiplier which is not positive.
+func (p TrafficProfile) validate() error {
+	for _, slots := range p.Multipliers {
+		for _, m := range slots {
+			if !(m > 0) || math.IsInf(m, 1) {
+				return fmt.Errorf("%w: multipliers must be positive", ErrInvalidTrafficModel)
+			}
+		}
+	}
+	return nil
+}
+
+// validate returns ErrInvalidTrafficModel if the model has a profile which is not valid.
+func (m TrafficModel) validate() error {
+	if err := m.Default.validate(); err != nil {
+		return err
+	}
+	for _, p := range m.Classes {
+		if err := p.validate(); err != nil {
+			return err
+		}
+	}
+	for _, p := range m.Segments {
+		if err := p.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// location returns the time zone of the model.
+func (m TrafficModel) location() *time.Location {
+	if m.Location == nil {
+		return time.UTC
+	}
+	return m.Location
+}
+
+// RouteETA returns the time-dependent ETA of a route departing at a time.
+// Legs are traveled back to back, and each pair of coordinates of their annotations uses the profile of its nodes
+// or its road class. Segment profiles need AnnotationsNodes, and class profiles need steps whose geometries match
+// the annotations, i.e. full geometries. Legs without duration annotations use the default profile.
+func RouteETA[T GeometryType](route RouteType[T], departure time.Time, model TrafficModel) (*TrafficETA, error) {
+	if err := model.validate(); err != nil {
+		return nil, err
+	}
+
+	t := departure.In(model.location())
+	eta := TrafficETA{Departure: t, FreeFlowDuration: seconds(float64(route.Duration))}
+
+	for _, leg := range route.Legs {
+		legETA := TrafficLegETA{Departure: t}
+
+		durations := leg.Annotation.Duration
+		if len(durations) == 0 {
+			t = model.Default.Arrival(t, seconds(float64(leg.Duration)))
+		} else {
+			profiles := model.legProfiles(leg.Annotation, legClasses(leg, model.Classes))
+
+			legETA.Arrivals = make([]time.Time, len(durations))
+			for i, d := range durations {
+				t = profiles[i].Arrival(t, seconds(float64(d)))
+				legETA.Arrivals[i] = t
+			}
+		}
+
+		legETA.Arrival = t
+		eta.Legs = append(eta.Legs, legETA)
+	}
+
+	eta.Arrival = t
+	eta.Duration = t.Sub(eta.Departure)
+
+	return &eta, nil
+}
+
+// legProfiles returns the profile of each pair of coordinates of an annotation, classes are their road classes.
+func (m TrafficModel) legProfiles(a Annotation, classes []string) []TrafficProfile {
+	profiles := make([]TrafficProfile, len(a.Duration))
+	nodes := len(a.Nodes) == len(a.Duration)+1
+
+	for i := range profiles {
+		profiles[i] = m.Default
+
+		if nodes {
+			if p, ok := m.Segments[TrafficSegment{From: a.Nodes[i], To: a.Nodes[i+1]}]; ok {
+				profiles[i] = p
+				continue
+			}
+		}
+		if classes != nil {
+			if p, ok := m.Classes[classes[i]]; ok {
+				profiles[i] = p
+			}
+		}
+	}
+
+	return profiles
+}
+
+// legClasses returns the road class of each pair of coordinates of the annotation of a leg,
+// the first class with a profile of the last intersection before it. Intersections are located on the geometries
+// of the steps, so it returns nil if the leg has no classes or the geometries don't match the annotation.
+func legClasses[T GeometryType](leg RouteLeg[T], profiles map[string]TrafficProfile) []string {
+	if len(profiles) == 0 || len(leg.Steps) == 0 {
+		return nil
+	}
+
+	// starts[k] is the index of the point the k-th intersection is located at.
+	var (
+		points  []Coordinate
+		starts  []int
+		classes []string
+	)
+	for _, step := range leg.Steps {
+		stepPoints, err := coordinatesOf(step.Geometry)
+		if err != nil {
+			return nil
+		}
+
+		from := max(len(points)-1, 0)
+		for _, p := range stepPoints {
+			// Consecutive steps share their first and last coordinates.
+			if n := len(points); n == 0 || points[n-1] != p {
+				points = append(points, p)
+			}
+		}
+
+		for _, intersection := range step.Intersections {
+			from = closestPoint(points, intersection.Location, from)
+			starts = append(starts, from)
+
+			var class string
+			for _, c := range intersection.Classes {
+				if _, ok := profiles[c]; ok {
+					class = c
+					break
+				}
+			}
+			classes = append(classes, class)
+		}
+	}
+
+	n := len(leg.Annotation.Duration)
+	if len(points)-1 != n {
+		return nil
+	}
+
+	segments := make([]string, n)
+	k := -1
+	for i := range segments {
+		for k+1 < len(starts) && starts[k+1] <= i {
+			k++
+		}
+		if k >= 0 {
+			segments[i] = classes[k]
+		}
+	}
+
+	return segments
+}
+
+// closestPoint returns the index of the closest point to a location, from the index from.
+func closestPoint(points []Coordinate, location Coordinate, from int) int {
+	closest, distance := from, math.Inf(1)
+	for i := from; i < len(points); i++ {
+		if d := HaversineDistance(points[i], location); d < distance {
+			closest, distance = i, d
+		}
+	}
+	return closest
+}
+
+// TrafficTable returns the response of table service with time-dependent durations departing at a time.
+// Table service has no nodes or road classes of routes, so durations use the default profile of the model.
+// Unlike Table, values of the pairs that can't be routed are positive infinity. Options are passed to table service.
+func TrafficTable(ctx context.Context, osrm OSRMClient, req Request, departure time.Time, model TrafficModel, opts ...Option) (*TableResponse, error) {
+	if err := model.validate(); err != nil {
+		return nil, err
+	}
+
+	res, err := tableInf(ctx, osrm, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+
+	t := departure.In(model.location())
+	for _, row := range res.Durations {
+		for j, d := range row {
+			if math.IsInf(float64(d), 0) || math.IsNaN(float64(d)) {
+				continue
+			}
+			row[j] = float32(model.Default.Arrival(t, seconds(float64(d))).Sub(t).Seconds())
+		}
+	}
+
+	return res, nil
+}
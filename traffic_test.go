@@ -0,0 +1,158 @@
+package gosrm
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newRushHourProfile returns an hourly profile which doubles durations from 7 to 9 on Mondays.
+func newRushHourProfile() TrafficProfile {
+	p := NewTrafficProfile(24)
+	p.Set(time.Monday, 7*time.Hour, 9*time.Hour, 2)
+	return p
+}
+
+func TestTrafficProfile(t *testing.T) {
+	monday := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	p := newRushHourProfile()
+
+	assert.Equal(t, float64(1), p.Multipliers[time.Monday][6])
+	assert.Equal(t, float64(2), p.Multipliers[time.Monday][7])
+	assert.Equal(t, float64(2), p.Multipliers[time.Monday][8])
+	assert.Equal(t, float64(1), p.Multipliers[time.Monday][9])
+	assert.Equal(t, float64(1), p.Multipliers[time.Tuesday][7])
+
+	assert.Equal(t, float64(2), p.Multiplier(monday.Add(7*time.Hour+30*time.Minute)))
+	assert.Equal(t, float64(1), p.Multiplier(monday.Add(9*time.Hour)))
+
+	// Half an hour is free-flow and the other half is doubled.
+	assert.Equal(t, monday.Add(8*time.Hour), p.Arrival(monday.Add(6*time.Hour+30*time.Minute), time.Hour))
+
+	// Travel through the rush hour.
+	assert.Equal(t, monday.Add(10*time.Hour), p.Arrival(monday.Add(6*time.Hour), 3*time.Hour))
+
+	// Days without slots and the zero profile have no traffic.
+	p.Multipliers[time.Tuesday] = nil
+	assert.Equal(t, monday.Add(26*time.Hour), p.Arrival(monday.Add(23*time.Hour), 3*time.Hour))
+	assert.Equal(t, monday.Add(time.Hour), TrafficProfile{}.Arrival(monday, time.Hour))
+
+	// Multipliers which aren't positive and finite are treated as 1.
+	p.Multipliers[time.Monday][0] = -1
+	p.Multipliers[time.Monday][1] = math.Inf(1)
+	assert.Equal(t, float64(1), p.Multiplier(monday))
+	assert.Equal(t, monday.Add(2*time.Hour), p.Arrival(monday, 2*time.Hour))
+}
+
+func TestTrafficProfile_DST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+
+	// Clocks are set forward from 2:00 to 3:00 on Sunday, March 8, 2026.
+	p := NewTrafficProfile(24)
+	p.Set(time.Sunday, 5*time.Hour, 6*time.Hour, 2)
+	sunday := func(hour, minute int) time.Time { return time.Date(2026, 3, 8, hour, minute, 0, 0, loc) }
+
+	assert.Equal(t, float64(2), p.Multiplier(sunday(5, 30)))
+	assert.Equal(t, float64(1), p.Multiplier(sunday(4, 30)))
+	assert.Equal(t, sunday(6, 30), p.Arrival(sunday(4, 0), 2*time.Hour))
+	assert.Equal(t, sunday(3, 30), p.Arrival(sunday(1, 30), time.Hour))
+
+	// Clocks are set back from 2:00 to 1:00 on Sunday, November 1, 2026, so the slot from 1:00 to 2:00 is 2 hours long.
+	p.Set(time.Sunday, time.Hour, 2*time.Hour, 2)
+	first := time.Date(2026, 11, 1, 1, 30, 0, 0, loc)
+	assert.Equal(t, "EDT", first.Format("MST"))
+	assert.Equal(t, time.Date(2026, 11, 1, 2, 15, 0, 0, loc), p.Arrival(first, time.Hour))
+}
+
+func TestRouteETA(t *testing.T) {
+	everyday := func(multiplier float64) TrafficProfile {
+		p := NewTrafficProfile(1)
+		for day := range p.Multipliers {
+			p.Multipliers[day][0] = multiplier
+		}
+		return p
+	}
+
+	model := TrafficModel{
+		Classes:  map[string]TrafficProfile{"motorway": everyday(2)},
+		Segments: map[TrafficSegment]TrafficProfile{{From: 2, To: 3}: everyday(3)},
+	}
+
+	route := RouteType[LineString]{
+		Duration: 5400,
+		Legs: []RouteLeg[LineString]{
+			{Duration: 3600, Annotation: Annotation{Duration: []float32{1800, 1800}, Nodes: []uint64{1, 2, 3}}},
+			{
+				Duration:   1200,
+				Annotation: Annotation{Duration: []float32{600, 600}},
+				Steps: []RouteStep[LineString]{
+					{
+						Geometry: LineString{Coordinates: []Coordinate{{0, 0}, {0.1, 0}, {0.2, 0}}},
+						Intersections: []Intersection{
+							{Location: Coordinate{0, 0}},
+							{Location: Coordinate{0.1, 0}, Classes: []string{"toll", "motorway"}},
+						},
+					},
+					{
+						Geometry:      LineString{Coordinates: []Coordinate{{0.2, 0}, {0.2, 0}}},
+						Intersections: []Intersection{{Location: Coordinate{0.2, 0}}},
+					},
+				},
+			},
+			{Duration: 600},
+		},
+	}
+
+	departure := time.Date(2026, 1, 5, 6, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time {
+		return departure.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+	}
+
+	eta, err := RouteETA(route, departure, model)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []time.Time{at(0, 30), at(2, 0)}, eta.Legs[0].Arrivals)
+	assert.Equal(t, []time.Time{at(2, 10), at(2, 30)}, eta.Legs[1].Arrivals)
+	assert.Equal(t, at(2, 30), eta.Legs[2].Departure)
+	assert.Nil(t, eta.Legs[2].Arrivals)
+	assert.Equal(t, at(2, 40), eta.Arrival)
+	assert.Equal(t, 160*time.Minute, eta.Duration)
+	assert.Equal(t, 90*time.Minute, eta.FreeFlowDuration)
+
+	// Profiles are in the time zone of the model.
+	model = TrafficModel{Location: time.FixedZone("UTC+2", 2*3600), Default: newRushHourProfile()}
+	eta, err = RouteETA(RouteType[LineString]{Legs: []RouteLeg[LineString]{{Duration: 3600}}}, departure.Add(-90*time.Minute), model)
+	assert.NoError(t, err)
+	assert.Equal(t, at(0, 0), eta.Arrival.UTC())
+	assert.Equal(t, model.Location, eta.Arrival.Location())
+
+	model.Classes = map[string]TrafficProfile{"ferry": {Multipliers: [7][]float64{{0}}}}
+	_, err = RouteETA(route, departure, model)
+	assert.ErrorIs(t, err, ErrInvalidTrafficModel)
+}
+
+func TestTrafficTable(t *testing.T) {
+	srv := newTableTestServer(t, nil)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	req := Request{Profile: ProfileCar, Coordinates: []Coordinate{{0, 0}, {0.36, 0}, {-1, 0}}}
+	departure := time.Date(2026, 1, 5, 6, 30, 0, 0, time.UTC)
+
+	res, err := TrafficTable(context.Background(), osrm, req, departure, TrafficModel{Default: newRushHourProfile()})
+	assert.NoError(t, err)
+	assert.Equal(t, float32(0), res.Durations[0][0])
+	assert.InDelta(t, 5400, res.Durations[0][1], 0.01)
+	assert.True(t, math.IsInf(float64(res.Durations[0][2]), 1))
+
+	_, err = TrafficTable(context.Background(), osrm, req, departure, TrafficModel{Default: TrafficProfile{Multipliers: [7][]float64{{-1}}}})
+	assert.ErrorIs(t, err, ErrInvalidTrafficModel)
+}
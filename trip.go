@@ -25,12 +25,38 @@ type TripResponse[T GeometryType] struct {
 func Trip[T GeometryType](ctx context.Context, osrm OSRMClient, req Request, opts ...Option) (*TripResponse[T], error) {
 	u := req.buildURLPath(*osrm.baseURL, tripServiceURL)
 
+	osrm.injectHints(u, req)
 	osrm.applyOpts(u, opts)
 
+	format := requestedGeometries(u)
+	if err := checkGeometryType[T](format); err != nil {
+		return nil, err
+	}
+
 	var res TripResponse[T]
 	if err := osrm.get(ctx, u.String(), &res); err != nil {
 		return nil, err
 	}
 
+	for i := range res.Trips {
+		if err := decodeGeometries(&res.Trips[i], format); err != nil {
+			return nil, err
+		}
+	}
+
+	if keys := osrm.hintKeys(req); keys != nil {
+		waypoints := make([]Waypoint, len(res.Waypoints))
+		for i := range res.Waypoints {
+			waypoints[i] = res.Waypoints[i].Waypoint
+		}
+		osrm.updateHints(keys, waypoints, res.Response)
+	}
+
 	return &res, nil
 }
+
+// TripAny is Trip with AnyGeometry geometries, which can hold all of the geometry formats,
+// so it doesn't need a type parameter that matches the geometries option.
+func TripAny(ctx context.Context, osrm OSRMClient, req Request, opts ...Option) (*TripResponse[AnyGeometry], error) {
+	return Trip[AnyGeometry](ctx, osrm, req, opts...)
+}
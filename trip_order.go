@@ -0,0 +1,241 @@
+package gosrm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+// ErrInvalidTrip is returned when a trip response doesn't match its request.
+var ErrInvalidTrip = errors.New("gosrm: trip response doesn't match the request")
+
+// tripImprovementEpsilon is the min improvement of a move in seconds, it prevents cycling because of floating point errors.
+const tripImprovementEpsilon float64 = 1e-6
+
+type (
+	// TripOrder is the visiting order of the input coordinates of a trip.
+	TripOrder struct {
+		// Indices of the input coordinates in visiting order.
+		Indices []int
+
+		// Coordinates are the input coordinates in visiting order.
+		Coordinates []Coordinate
+
+		// IDs are the input IDs in visiting order, nil if the request has no IDs.
+		IDs []string
+	}
+
+	// ImprovedTrip is a trip improved by ImproveTrip.
+	ImprovedTrip[T GeometryType] struct {
+		// Order is the improved visiting order.
+		Order TripOrder
+
+		// InitialDuration is the duration of the trip before the improvement, computed by table service, in seconds.
+		InitialDuration float64
+
+		// Duration is the duration of the improved trip, computed by table service, in seconds.
+		Duration float64
+
+		// Route is the route through the improved order. Round trips return to the first coordinate.
+		Route *RouteResponse[T]
+	}
+
+	// tripConstraints are the constraints of trip options.
+	tripConstraints struct {
+		roundTrip  bool
+		firstFixed bool
+		lastFixed  bool
+	}
+)
+
+// Request returns the request of the coordinates in visiting order.
+func (o TripOrder) Request(profile Profile) Request {
+	return Request{Profile: profile, Coordinates: o.Coordinates, IDs: o.IDs}
+}
+
+// VisitOrder returns the visiting order of the input coordinates of each trip.
+// req must be the request of the response.
+func (res TripResponse[T]) VisitOrder(req Request) ([]TripOrder, error) {
+	if len(res.Waypoints) != len(req.Coordinates) {
+		return nil, fmt.Errorf("%w: %d waypoints for %d coordinates", ErrInvalidTrip, len(res.Waypoints), len(req.Coordinates))
+	}
+
+	orders := make([]TripOrder, len(res.Trips))
+	for _, wp := range res.Waypoints {
+		if int(wp.TripsIndex) >= len(orders) {
+			return nil, fmt.Errorf("%w: trips index %d out of range", ErrInvalidTrip, wp.TripsIndex)
+		}
+		orders[wp.TripsIndex].Indices = append(orders[wp.TripsIndex].Indices, -1)
+	}
+
+	for i, wp := range res.Waypoints {
+		indices := orders[wp.TripsIndex].Indices
+		if int(wp.WaypointIndex) >= len(indices) || indices[wp.WaypointIndex] != -1 {
+			return nil, fmt.Errorf("%w: invalid waypoint index %d", ErrInvalidTrip, wp.WaypointIndex)
+		}
+		indices[wp.WaypointIndex] = i
+	}
+
+	withIDs := len(req.IDs) == len(req.Coordinates)
+	for i := range orders {
+		orders[i].Coordinates = make([]Coordinate, len(orders[i].Indices))
+		for k, idx := range orders[i].Indices {
+			orders[i].Coordinates[k] = req.Coordinates[idx]
+		}
+
+		if withIDs {
+			orders[i].IDs = make([]string, len(orders[i].Indices))
+			for k, idx := range orders[i].Indices {
+				orders[i].IDs[k] = req.IDs[idx]
+			}
+		}
+	}
+
+	return orders, nil
+}
+
+// ImproveTrip improves the visiting order of each trip of the response using 2-opt and or-opt moves
+// on a duration matrix computed by table service, and fetches the route of the improved orders.
+// req must be the request of the response and tripOpts the options of the trip request,
+// the improvement respects their roundtrip, source and destination options.
+// Round trips keep their first coordinate, source first keeps the first input coordinate first
+// and destination last keeps the last input coordinate last.
+// routeOpts are passed to route service.
+func ImproveTrip[T GeometryType](ctx context.Context, osrm OSRMClient, req Request, res *TripResponse[T], tripOpts []Option, routeOpts ...Option) ([]ImprovedTrip[T], error) {
+	orders, err := res.VisitOrder(req)
+	if err != nil {
+		return nil, err
+	}
+
+	constraints := newTripConstraints(tripOpts)
+
+	trips := make([]ImprovedTrip[T], len(orders))
+	for i, order := range orders {
+		trip, err := improveTrip[T](ctx, osrm, req, order, constraints, routeOpts)
+		if err != nil {
+			return nil, err
+		}
+		trips[i] = trip
+	}
+
+	return trips, nil
+}
+
+// improveTrip improves the visiting order of a trip and fetches its route.
+func improveTrip[T GeometryType](ctx context.Context, osrm OSRMClient, req Request, order TripOrder, c tripConstraints, routeOpts []Option) (ImprovedTrip[T], error) {
+	table, err := TableChunked(ctx, osrm, order.Request(req.Profile), nil, nil, 0, WithAnnotations(AnnotationsDuration))
+	if err != nil {
+		return ImprovedTrip[T]{}, err
+	}
+
+	// c.firstFixed and c.lastFixed only apply to trips that contain the first and last input coordinates.
+	n := len(order.Indices)
+	lo, hi := 0, n-1
+	if c.roundTrip || (c.firstFixed && n > 0 && order.Indices[0] == 0) {
+		lo = 1
+	}
+	if c.lastFixed && n > 0 && order.Indices[n-1] == len(req.Coordinates)-1 {
+		hi = n - 2
+	}
+
+	cost := func(perm []int) float64 {
+		var total float64
+		for k := 1; k < len(perm); k++ {
+			total += float64(table.Durations[perm[k-1]][perm[k]])
+		}
+		if c.roundTrip && len(perm) > 1 {
+			total += float64(table.Durations[perm[len(perm)-1]][perm[0]])
+		}
+		return total
+	}
+
+	perm := make([]int, n)
+	for k := range perm {
+		perm[k] = k
+	}
+
+	trip := ImprovedTrip[T]{InitialDuration: cost(perm)}
+	best := trip.InitialDuration
+
+	accept := func(candidate []int) bool {
+		if total := cost(candidate); total < best-tripImprovementEpsilon {
+			perm, best = candidate, total
+			return true
+		}
+		return false
+	}
+
+	for improved := true; improved; {
+		improved = false
+
+		// 2-opt, reverses a segment.
+		for i := lo; i < hi; i++ {
+			for k := i + 1; k <= hi; k++ {
+				candidate := slices.Clone(perm)
+				slices.Reverse(candidate[i : k+1])
+				improved = accept(candidate) || improved
+			}
+		}
+
+		// Or-opt, moves a segment of 1 to 3 coordinates to another position.
+		for size := 1; size <= 3; size++ {
+			for i := lo; i+size-1 <= hi; i++ {
+				segment := slices.Clone(perm[i : i+size])
+				rest := slices.Delete(slices.Clone(perm), i, i+size)
+
+				for pos := lo; pos <= hi-size+1; pos++ {
+					if pos != i && accept(slices.Insert(slices.Clone(rest), pos, segment...)) {
+						improved = true
+						break
+					}
+				}
+			}
+		}
+	}
+
+	trip.Duration = best
+	trip.Order = TripOrder{Indices: make([]int, n), Coordinates: make([]Coordinate, n)}
+	if order.IDs != nil {
+		trip.Order.IDs = make([]string, n)
+	}
+	for k, p := range perm {
+		trip.Order.Indices[k] = order.Indices[p]
+		trip.Order.Coordinates[k] = order.Coordinates[p]
+		if order.IDs != nil {
+			trip.Order.IDs[k] = order.IDs[p]
+		}
+	}
+
+	routeReq := trip.Order.Request(req.Profile)
+	if c.roundTrip && n > 1 {
+		routeReq.Coordinates = append(slices.Clone(routeReq.Coordinates), routeReq.Coordinates[0])
+		if routeReq.IDs != nil {
+			routeReq.IDs = append(slices.Clone(routeReq.IDs), routeReq.IDs[0])
+		}
+	}
+
+	trip.Route, err = Route[T](ctx, osrm, routeReq, routeOpts...)
+	if err != nil {
+		return ImprovedTrip[T]{}, err
+	}
+
+	return trip, nil
+}
+
+// newTripConstraints returns the constraints of trip options, using OSRM defaults for missing options.
+func newTripConstraints(opts []Option) tripConstraints {
+	u := &url.URL{}
+	for _, opt := range opts {
+		opt.apply(u)
+	}
+
+	q := u.Query()
+
+	return tripConstraints{
+		roundTrip:  q.Get("roundtrip") != "false",
+		firstFixed: q.Get("source") == string(SourceFirst),
+		lastFixed:  q.Get("destination") == string(DestinationLast),
+	}
+}
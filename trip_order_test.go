@@ -0,0 +1,125 @@
+package gosrm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTripResponse_VisitOrder(t *testing.T) {
+	req := Request{
+		Coordinates: []Coordinate{{0, 0}, {1, 1}, {2, 2}, {3, 3}, {4, 4}},
+		IDs:         []string{"a", "b", "c", "d", "e"},
+	}
+	res := TripResponse[string]{
+		Trips: make([]RouteType[string], 2),
+		Waypoints: []TripWaypoint{
+			{TripsIndex: 0, WaypointIndex: 0},
+			{TripsIndex: 1, WaypointIndex: 1},
+			{TripsIndex: 0, WaypointIndex: 2},
+			{TripsIndex: 0, WaypointIndex: 1},
+			{TripsIndex: 1, WaypointIndex: 0},
+		},
+	}
+
+	orders, err := res.VisitOrder(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []TripOrder{
+		{Indices: []int{0, 3, 2}, Coordinates: []Coordinate{{0, 0}, {3, 3}, {2, 2}}, IDs: []string{"a", "d", "c"}},
+		{Indices: []int{4, 1}, Coordinates: []Coordinate{{4, 4}, {1, 1}}, IDs: []string{"e", "b"}},
+	}, orders)
+	assert.Equal(t, Request{Profile: ProfileCar, Coordinates: orders[1].Coordinates, IDs: orders[1].IDs}, orders[1].Request(ProfileCar))
+
+	req.IDs = nil
+	orders, err = res.VisitOrder(req)
+	assert.NoError(t, err)
+	assert.Nil(t, orders[0].IDs)
+
+	_, err = res.VisitOrder(Request{Coordinates: req.Coordinates[:4]})
+	assert.ErrorIs(t, err, ErrInvalidTrip)
+
+	res.Waypoints[1].WaypointIndex = 0
+	_, err = res.VisitOrder(req)
+	assert.ErrorIs(t, err, ErrInvalidTrip)
+
+	res.Waypoints[1].TripsIndex = 2
+	_, err = res.VisitOrder(req)
+	assert.ErrorIs(t, err, ErrInvalidTrip)
+}
+
+func TestImproveTrip(t *testing.T) {
+	var routes [][]Coordinate
+
+	mux := http.NewServeMux()
+	mux.Handle("/table/", tableTestHandler(t, nil))
+	mux.HandleFunc("/route/", func(w http.ResponseWriter, r *http.Request) {
+		routes = append(routes, parseTestCoordinates(r.URL.Path))
+		assert.True(t, strings.Contains(r.URL.RawQuery, "steps=true"))
+		w.Write([]byte(`{"code":"Ok","routes":[{"geometry":"","duration":1}],"waypoints":[]}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	osrm, err := New(srv.URL)
+	assert.NoError(t, err)
+
+	req := Request{
+		Profile:     ProfileCar,
+		Coordinates: []Coordinate{{1, 1}, {1.3, 1}, {1.1, 1}, {1.2, 1}},
+		IDs:         []string{"a", "b", "c", "d"},
+	}
+	res := &TripResponse[string]{
+		Trips: make([]RouteType[string], 1),
+		Waypoints: []TripWaypoint{
+			{WaypointIndex: 0},
+			{WaypointIndex: 1},
+			{WaypointIndex: 2},
+			{WaypointIndex: 3},
+		},
+	}
+
+	trips, err := ImproveTrip(context.Background(), osrm, req, res, []Option{WithRoundTrip(false), WithSource(SourceFirst)}, WithSteps(true))
+	assert.NoError(t, err)
+	assert.Len(t, trips, 1)
+	assert.Equal(t, []int{0, 2, 3, 1}, trips[0].Order.Indices)
+	assert.Equal(t, []string{"a", "c", "d", "b"}, trips[0].Order.IDs)
+	assert.InDelta(t, 6000, trips[0].InitialDuration, 1)
+	assert.InDelta(t, 3000, trips[0].Duration, 1)
+	assert.Equal(t, float32(1), trips[0].Route.Routes[0].Duration)
+	assert.Equal(t, trips[0].Order.Coordinates, routes[0])
+
+	trips, err = ImproveTrip(context.Background(), osrm, req, res, []Option{WithRoundTrip(false), WithSource(SourceFirst), WithDestination(DestinationLast)}, WithSteps(true))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2, 1, 3}, trips[0].Order.Indices)
+	assert.InDelta(t, 4000, trips[0].Duration, 1)
+
+	// Round trips return to the first coordinate.
+	trips, err = ImproveTrip(context.Background(), osrm, req, res, nil, WithSteps(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, trips[0].Order.Indices[0])
+	assert.InDelta(t, 8000, trips[0].InitialDuration, 1)
+	assert.InDelta(t, 6000, trips[0].Duration, 1)
+	assert.Len(t, routes[2], 5)
+	assert.Equal(t, routes[2][0], routes[2][4])
+
+	_, err = ImproveTrip(context.Background(), osrm, Request{}, res, nil)
+	assert.ErrorIs(t, err, ErrInvalidTrip)
+
+	srv.Close()
+	_, err = ImproveTrip(context.Background(), osrm, req, res, nil)
+	assert.Error(t, err)
+}
+
+func TestNewTripConstraints(t *testing.T) {
+	assert.Equal(t, tripConstraints{roundTrip: true}, newTripConstraints(nil))
+	assert.Equal(t, tripConstraints{firstFixed: true, lastFixed: true}, newTripConstraints([]Option{
+		WithRoundTrip(false),
+		WithSource(SourceFirst),
+		WithDestination(DestinationLast),
+	}))
+	assert.Equal(t, tripConstraints{roundTrip: true}, newTripConstraints([]Option{WithSource(SourceAny), WithDestination(DestinationAny)}))
+}
@@ -1,6 +1,10 @@
 package gosrm
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 type (
 	// Coordinate is a {Lng, Lat} point.
@@ -18,9 +22,9 @@ type (
 	}
 
 	// GeometryType is the type used to represent multiple geometry formats.
-	// polyline5, polyline6, geojson.
+	// string can hold polyline, LineString can hold geojson and AnyGeometry can hold all of them.
 	GeometryType interface {
-		string | LineString
+		string | LineString | AnyGeometry
 	}
 
 	// Bearing is the {value},{range} bearing type.
@@ -288,7 +292,51 @@ type (
 	}
 )
 
+// ResponseError is the error of a request that couldn't be processed as expected by OSRM.
+// Services don't return it, their responses have the code instead, see Response.Err.
+type ResponseError struct {
+	// Code is the error code returned by OSRM.
+	Code Code
+
+	// Message is the human-readable error message returned by OSRM.
+	Message string
+}
+
+// Error implements the error interface.
+func (err ResponseError) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("gosrm: %s", err.Code)
+	}
+	return fmt.Sprintf("gosrm: %s: %s", err.Code, err.Message)
+}
+
+// Omitted returns true if the tracepoint was omitted by map matching, i.e. it was null in the response.
+func (tp Tracepoint) Omitted() bool {
+	return tp == Tracepoint{}
+}
+
+// tracepointAlias is used to avoid recursion in JSON methods of Tracepoint.
+type tracepointAlias Tracepoint
+
+// MarshalJSON implements the json.Marshaler interface.
+// Omitted tracepoints are encoded as null.
+func (tp Tracepoint) MarshalJSON() ([]byte, error) {
+	if tp.Omitted() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(tracepointAlias(tp))
+}
+
 // IsOk returns true if request could be processed as expected by OSRM.
 func (res Response) IsOk() bool {
 	return res.Code == CodeOK
 }
+
+// Err returns a ResponseError if request couldn't be processed as expected by OSRM, otherwise nil.
+// Use errors.As to get the code of the error.
+func (res Response) Err() error {
+	if res.IsOk() {
+		return nil
+	}
+	return ResponseError{Code: res.Code, Message: res.Message}
+}
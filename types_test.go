@@ -1,6 +1,8 @@
 package gosrm
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -13,3 +15,19 @@ func TestResponse_IsOk(t *testing.T) {
 	res.Code = CodeInvalidQuery
 	assert.False(t, res.IsOk())
 }
+
+func TestResponse_Err(t *testing.T) {
+	res := Response{Code: CodeOK}
+	assert.NoError(t, res.Err())
+
+	res.Code = CodeNoRoute
+	assert.Equal(t, ResponseError{Code: CodeNoRoute}, res.Err())
+	assert.EqualError(t, res.Err(), "gosrm: NoRoute")
+
+	res.Message = "Impossible route between points"
+	assert.EqualError(t, res.Err(), "gosrm: NoRoute: Impossible route between points")
+
+	var resErr ResponseError
+	assert.True(t, errors.As(fmt.Errorf("wrapped: %w", res.Err()), &resErr))
+	assert.Equal(t, CodeNoRoute, resErr.Code)
+}
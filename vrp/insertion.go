@@ -0,0 +1,264 @@
+package vrp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mojixcoder/gosrm"
+)
+
+type (
+	// Insertion is a way of inserting a pickup and drop-off into a route.
+	Insertion struct {
+		// PickupIndex is the index of the pickup in the new route.
+		PickupIndex int
+
+		// DropoffIndex is the index of the drop-off in the new route, it's always after the pickup.
+		DropoffIndex int
+
+		// AddedDuration is the duration added to the route.
+		AddedDuration time.Duration
+
+		// AddedDistance is the distance added to the route, in meters.
+		AddedDistance float64
+
+		// RideDuration is the duration from the pickup to the drop-off in the new route.
+		RideDuration time.Duration
+
+		// DetourRatio is the ride duration divided by the direct duration from the pickup to the drop-off.
+		DetourRatio float64
+	}
+
+	// InsertionEvaluator evaluates insertions of pickups and drop-offs into routes using table service.
+	// Durations and distances between coordinates are cached, so probing the same route repeatedly
+	// only requests the values of the new pickups and drop-offs.
+	// It's safe for concurrent use.
+	InsertionEvaluator struct {
+		osrm      gosrm.OSRMClient
+		profile   gosrm.Profile
+		chunkSize int
+		opts      []gosrm.Option
+
+		mu    sync.RWMutex
+		cells map[[2]gosrm.Coordinate]cell
+	}
+
+	// cell is the duration and distance from a coordinate to another.
+	cell struct {
+		duration float64
+		distance float64
+	}
+)
+
+// NewInsertionEvaluator returns a new insertion evaluator.
+// Options are passed to table service, see gosrm.TableChunked for chunkSize.
+func NewInsertionEvaluator(osrm gosrm.OSRMClient, profile gosrm.Profile, chunkSize int, opts ...gosrm.Option) *InsertionEvaluator {
+	return &InsertionEvaluator{
+		osrm:      osrm,
+		profile:   profile,
+		chunkSize: chunkSize,
+		opts:      append([]gosrm.Option{gosrm.WithAnnotations(gosrm.AnnotationsDurationDistance)}, opts...),
+		cells:     make(map[[2]gosrm.Coordinate]cell),
+	}
+}
+
+// Reset removes the cached durations and distances, e.g. when traffic data is updated.
+func (e *InsertionEvaluator) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	clear(e.cells)
+}
+
+// Insertions returns the feasible insertions of a pickup and drop-off into a route, ranked by added duration.
+// The first coordinate of the route is the current location of the vehicle, so nothing is inserted before it.
+// The route is open, the vehicle doesn't return to its first coordinate.
+// If maxDetour is positive, insertions with a detour ratio greater than maxDetour are not feasible.
+// Insertions through unreachable coordinates are not feasible.
+func (e *InsertionEvaluator) Insertions(ctx context.Context, route []gosrm.Coordinate, pickup, dropoff gosrm.Coordinate, maxDetour float64) ([]Insertion, error) {
+	if len(route) == 0 {
+		return nil, fmt.Errorf("%w: route must have at least one coordinate", ErrInvalidProblem)
+	}
+
+	stops := []gosrm.Coordinate{pickup, dropoff}
+
+	if err := e.fetch(ctx, route[:len(route)-1], route[1:]); err != nil {
+		return nil, err
+	}
+	if err := e.fetch(ctx, stops, append(slices.Clone(route[1:]), stops...)); err != nil {
+		return nil, err
+	}
+	if err := e.fetch(ctx, route, stops); err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	direct := e.cells[[2]gosrm.Coordinate{pickup, dropoff}]
+	if math.IsInf(direct.duration, 0) {
+		return nil, nil
+	}
+
+	// elapsed[i] is the duration from the first coordinate of the route to the i-th one.
+	elapsed := make([]float64, len(route))
+	for i := 1; i < len(route); i++ {
+		elapsed[i] = elapsed[i-1] + e.cells[[2]gosrm.Coordinate{route[i-1], route[i]}].duration
+	}
+
+	var insertions []Insertion
+
+	// The pickup is inserted after route[p-1] and the drop-off after route[d-1], or right after the pickup if p == d.
+	for p := 1; p <= len(route); p++ {
+		for d := p; d <= len(route); d++ {
+			added, ride := e.detour(route, p, d, pickup, dropoff, elapsed)
+			if math.IsInf(added.duration, 0) || math.IsNaN(added.duration) || math.IsInf(added.distance, 0) {
+				continue
+			}
+
+			ratio := 1.0
+			if direct.duration > 0 {
+				ratio = ride / direct.duration
+			}
+			if maxDetour > 0 && ratio > maxDetour+epsilon {
+				continue
+			}
+
+			insertions = append(insertions, Insertion{
+				PickupIndex:   p,
+				DropoffIndex:  d + 1,
+				AddedDuration: seconds(added.duration),
+				AddedDistance: added.distance,
+				RideDuration:  seconds(ride),
+				DetourRatio:   ratio,
+			})
+		}
+	}
+
+	slices.SortStableFunc(insertions, func(a, b Insertion) int {
+		return cmp.Or(cmp.Compare(a.AddedDuration, b.AddedDuration), cmp.Compare(a.AddedDistance, b.AddedDistance))
+	})
+
+	return insertions, nil
+}
+
+// detour returns the added cost of inserting the pickup after route[p-1] and the drop-off after route[d-1]
+// and the ride duration from the pickup to the drop-off.
+// The cache must be locked by the caller.
+func (e *InsertionEvaluator) detour(route []gosrm.Coordinate, p, d int, pickup, dropoff gosrm.Coordinate, elapsed []float64) (cell, float64) {
+	if p == d {
+		added := e.replace(route, p, pickup, dropoff)
+		return added, e.cells[[2]gosrm.Coordinate{pickup, dropoff}].duration
+	}
+
+	added := e.replace(route, p, pickup)
+	dropoffAdded := e.replace(route, d, dropoff)
+	added.duration += dropoffAdded.duration
+	added.distance += dropoffAdded.distance
+
+	ride := e.cells[[2]gosrm.Coordinate{pickup, route[p]}].duration +
+		elapsed[d-1] - elapsed[p] +
+		e.cells[[2]gosrm.Coordinate{route[d-1], dropoff}].duration
+
+	return added, ride
+}
+
+// replace returns the added cost of visiting the given coordinates between route[i-1] and route[i].
+// If i is the length of the route, the coordinates are appended.
+// The cache must be locked by the caller.
+func (e *InsertionEvaluator) replace(route []gosrm.Coordinate, i int, coordinates ...gosrm.Coordinate) cell {
+	path := append([]gosrm.Coordinate{route[i-1]}, coordinates...)
+	if i < len(route) {
+		path = append(path, route[i])
+	}
+
+	var added cell
+	for k := 1; k < len(path); k++ {
+		c := e.cells[[2]gosrm.Coordinate{path[k-1], path[k]}]
+		added.duration += c.duration
+		added.distance += c.distance
+	}
+
+	if i < len(route) {
+		c := e.cells[[2]gosrm.Coordinate{route[i-1], route[i]}]
+		added.duration -= c.duration
+		added.distance -= c.distance
+	}
+
+	return added
+}
+
+// fetch requests the durations and distances from sources to destinations which are not cached.
+// Only the sources and destinations of missing values are requested.
+func (e *InsertionEvaluator) fetch(ctx context.Context, sources, destinations []gosrm.Coordinate) error {
+	var missingSources, missingDestinations []gosrm.Coordinate
+
+	e.mu.RLock()
+	for _, src := range sources {
+		for _, dst := range destinations {
+			if _, ok := e.cells[[2]gosrm.Coordinate{src, dst}]; ok {
+				continue
+			}
+			if !slices.Contains(missingSources, src) {
+				missingSources = append(missingSources, src)
+			}
+			if !slices.Contains(missingDestinations, dst) {
+				missingDestinations = append(missingDestinations, dst)
+			}
+		}
+	}
+	e.mu.RUnlock()
+
+	if len(missingSources) == 0 {
+		return nil
+	}
+
+	req := gosrm.Request{Profile: e.profile}
+	indices := make(map[gosrm.Coordinate]int)
+	indexOf := func(c gosrm.Coordinate) int {
+		idx, ok := indices[c]
+		if !ok {
+			idx = len(req.Coordinates)
+			indices[c] = idx
+			req.Coordinates = append(req.Coordinates, c)
+		}
+		return idx
+	}
+
+	srcIndices := make([]int, len(missingSources))
+	for i, c := range missingSources {
+		srcIndices[i] = indexOf(c)
+	}
+	dstIndices := make([]int, len(missingDestinations))
+	for i, c := range missingDestinations {
+		dstIndices[i] = indexOf(c)
+	}
+
+	res, err := gosrm.TableChunked(ctx, e.osrm, req, srcIndices, dstIndices, e.chunkSize, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, src := range missingSources {
+		for j, dst := range missingDestinations {
+			var c cell
+			if res.Durations != nil {
+				c.duration = float64(res.Durations[i][j])
+			}
+			if res.Distances != nil {
+				c.distance = float64(res.Distances[i][j])
+			}
+			e.cells[[2]gosrm.Coordinate{src, dst}] = c
+		}
+	}
+
+	return nil
+}
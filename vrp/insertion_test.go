@@ -0,0 +1,87 @@
+package vrp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mojixcoder/gosrm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertionEvaluator_Insertions(t *testing.T) {
+	var requests int32
+	srv := newTableTestServer(t, &requests)
+	defer srv.Close()
+
+	osrm, err := gosrm.New(srv.URL)
+	assert.NoError(t, err)
+
+	e := NewInsertionEvaluator(osrm, gosrm.ProfileCar, 0)
+	route := []gosrm.Coordinate{{0, 0}, {2, 0}, {4, 0}}
+
+	insertions, err := e.Insertions(context.Background(), route, gosrm.Coordinate{1, 0}, gosrm.Coordinate{3, 0}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), requests)
+	assert.Len(t, insertions, 6)
+	assert.Equal(t, Insertion{
+		PickupIndex:   1,
+		DropoffIndex:  3,
+		AddedDuration: 0,
+		AddedDistance: 0,
+		RideDuration:  2000 * time.Second,
+		DetourRatio:   1,
+	}, insertions[0])
+
+	for i := 1; i < len(insertions); i++ {
+		assert.LessOrEqual(t, insertions[i-1].AddedDuration, insertions[i].AddedDuration)
+	}
+
+	// Appending both to the end of the route.
+	last := insertions[len(insertions)-1]
+	assert.Equal(t, 3, last.PickupIndex)
+	assert.Equal(t, 4, last.DropoffIndex)
+	assert.Equal(t, 5000*time.Second, last.AddedDuration)
+	assert.InDelta(t, 5000, last.AddedDistance, 1e-3)
+
+	insertions, err = e.Insertions(context.Background(), route, gosrm.Coordinate{1, 0}, gosrm.Coordinate{3, 0}, 1.5)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), requests)
+	assert.Len(t, insertions, 4)
+	for _, ins := range insertions {
+		assert.InDelta(t, 1, ins.DetourRatio, 1e-6)
+	}
+
+	// Only the values of the new pickup and drop-off are requested.
+	insertions, err = e.Insertions(context.Background(), route, gosrm.Coordinate{4, 1}, gosrm.Coordinate{4, 2}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), requests)
+	assert.Equal(t, 3, insertions[0].PickupIndex)
+	assert.Equal(t, 4, insertions[0].DropoffIndex)
+	assert.Equal(t, 2000*time.Second, insertions[0].AddedDuration)
+
+	e.Reset()
+	_, err = e.Insertions(context.Background(), route, gosrm.Coordinate{4, 1}, gosrm.Coordinate{4, 2}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(8), requests)
+}
+
+func TestInsertionEvaluator_Insertions_Errors(t *testing.T) {
+	srv := newTableTestServer(t, nil)
+	osrm, err := gosrm.New(srv.URL)
+	assert.NoError(t, err)
+
+	e := NewInsertionEvaluator(osrm, gosrm.ProfileCar, 0)
+
+	_, err = e.Insertions(context.Background(), nil, gosrm.Coordinate{1, 0}, gosrm.Coordinate{3, 0}, 0)
+	assert.ErrorIs(t, err, ErrInvalidProblem)
+
+	// A route with only the vehicle location.
+	insertions, err := e.Insertions(context.Background(), []gosrm.Coordinate{{0, 0}}, gosrm.Coordinate{1, 0}, gosrm.Coordinate{3, 0}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []Insertion{{PickupIndex: 1, DropoffIndex: 2, AddedDuration: 3000 * time.Second, AddedDistance: 3000, RideDuration: 2000 * time.Second, DetourRatio: 1}}, insertions)
+
+	srv.Close()
+	_, err = e.Insertions(context.Background(), []gosrm.Coordinate{{0, 0}}, gosrm.Coordinate{5, 0}, gosrm.Coordinate{6, 0}, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,438 @@
+package vrp
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+type (
+	// solver holds the state of a problem being solved.
+	solver struct {
+		p Problem
+
+		durations [][]float64
+		distances [][]float64
+		cost      [][]float64
+
+		// routes[v] are the job indices served by the v-th vehicle in visiting order.
+		routes [][]int
+
+		// costs[v] is the cost of routes[v].
+		costs []float64
+
+		unassigned []int
+	}
+
+	// schedule is the simulated schedule of a route.
+	schedule struct {
+		departure time.Duration
+		arrival   time.Duration
+		stops     []Stop
+		duration  float64
+		distance  float64
+	}
+)
+
+// newSolver returns a new solver.
+func newSolver(p Problem, durations, distances [][]float32) *solver {
+	s := solver{
+		p:         p,
+		durations: toFloat64(durations),
+		distances: toFloat64(distances),
+		routes:    make([][]int, len(p.Vehicles)),
+		costs:     make([]float64, len(p.Vehicles)),
+	}
+
+	if s.distances == nil {
+		s.distances = make([][]float64, len(s.durations))
+		for i := range s.distances {
+			s.distances[i] = make([]float64, len(s.durations[i]))
+		}
+	}
+
+	s.cost = s.durations
+	if p.Objective == ObjectiveDistance {
+		s.cost = s.distances
+	}
+
+	s.unassigned = make([]int, len(p.Jobs))
+	for i := range s.unassigned {
+		s.unassigned[i] = i
+	}
+
+	return &s
+}
+
+// startLoc returns the location index of the start of a vehicle.
+func (s *solver) startLoc(v int) int {
+	return v
+}
+
+// endLoc returns the location index of the end of a vehicle.
+func (s *solver) endLoc(v int) int {
+	return len(s.p.Vehicles) + v
+}
+
+// jobLoc returns the location index of a job.
+func (s *solver) jobLoc(j int) int {
+	return 2*len(s.p.Vehicles) + j
+}
+
+// simulate simulates the route of a vehicle and returns its schedule.
+// ok is false if the route violates any of the constraints.
+func (s *solver) simulate(v int, route []int) (sch schedule, ok bool) {
+	vehicle := s.p.Vehicles[v]
+
+	load := make([]int, len(vehicle.Capacity))
+	for _, j := range route {
+		for d, demand := range s.p.Jobs[j].Demand {
+			load[d] += demand
+		}
+	}
+	for d := range load {
+		if load[d] > vehicle.Capacity[d] {
+			return sch, false
+		}
+	}
+
+	// Leave as late as possible without waiting at the first job, so waiting doesn't count against max duration.
+	sch.departure = vehicle.Shift.Start
+	if len(route) > 0 {
+		travel := seconds(s.durations[s.startLoc(v)][s.jobLoc(route[0])])
+		if latest := s.p.Jobs[route[0]].TimeWindow.Start - travel; latest > sch.departure {
+			sch.departure = latest
+		}
+	}
+
+	t := sch.departure
+	prev := s.startLoc(v)
+
+	for _, j := range route {
+		job := s.p.Jobs[j]
+		loc := s.jobLoc(j)
+
+		if math.IsInf(s.durations[prev][loc], 0) || math.IsInf(s.distances[prev][loc], 0) {
+			return sch, false
+		}
+
+		sch.duration += s.durations[prev][loc]
+		sch.distance += s.distances[prev][loc]
+
+		arrival := t + seconds(s.durations[prev][loc])
+		start := max(arrival, job.TimeWindow.Start)
+		if job.TimeWindow.End > 0 && start > job.TimeWindow.End {
+			return sch, false
+		}
+
+		for d, demand := range job.Demand {
+			load[d] -= demand
+		}
+
+		t = start + job.Service
+		sch.stops = append(sch.stops, Stop{
+			JobIndex:     j,
+			Job:          job,
+			Arrival:      arrival,
+			ServiceStart: start,
+			Departure:    t,
+			Load:         slices.Clone(load),
+		})
+		prev = loc
+	}
+
+	end := s.endLoc(v)
+	if math.IsInf(s.durations[prev][end], 0) || math.IsInf(s.distances[prev][end], 0) {
+		return sch, false
+	}
+
+	sch.duration += s.durations[prev][end]
+	sch.distance += s.distances[prev][end]
+	sch.arrival = t + seconds(s.durations[prev][end])
+
+	if vehicle.Shift.End > 0 && sch.arrival > vehicle.Shift.End {
+		return sch, false
+	}
+	if vehicle.MaxDuration > 0 && sch.arrival-sch.departure > vehicle.MaxDuration {
+		return sch, false
+	}
+
+	return sch, true
+}
+
+// routeCost returns the cost of a route, ok is false if the route is not feasible.
+// Empty routes cost nothing since the vehicle is not used.
+func (s *solver) routeCost(v int, route []int) (float64, bool) {
+	if len(route) == 0 {
+		return 0, true
+	}
+
+	sch, ok := s.simulate(v, route)
+	if !ok {
+		return 0, false
+	}
+
+	if s.p.Objective == ObjectiveDistance {
+		return sch.distance, true
+	}
+	return sch.duration, true
+}
+
+// construct assigns jobs to vehicles using cheapest insertion.
+// In each iteration, the job with the cheapest feasible insertion among all vehicles and positions is inserted.
+func (s *solver) construct() {
+	for len(s.unassigned) > 0 {
+		bestIdx, bestVehicle, bestPos, bestCost := -1, -1, -1, math.Inf(1)
+
+		for idx, j := range s.unassigned {
+			for v := range s.routes {
+				if pos, cost, ok := s.bestInsertion(j, v, bestCost); ok {
+					bestIdx, bestVehicle, bestPos, bestCost = idx, v, pos, cost
+				}
+			}
+		}
+
+		if bestIdx == -1 {
+			// None of the remaining jobs can be inserted.
+			return
+		}
+
+		j := s.unassigned[bestIdx]
+		s.routes[bestVehicle] = slices.Insert(s.routes[bestVehicle], bestPos, j)
+		s.costs[bestVehicle], _ = s.routeCost(bestVehicle, s.routes[bestVehicle])
+		s.unassigned = slices.Delete(s.unassigned, bestIdx, bestIdx+1)
+	}
+}
+
+// bestInsertion returns the cheapest feasible position of a job in the route of a vehicle.
+// Only insertions cheaper than limit are considered, ok is false if there is none.
+func (s *solver) bestInsertion(j, v int, limit float64) (int, float64, bool) {
+	route := s.routes[v]
+	loc := s.jobLoc(j)
+
+	bestPos, bestDelta := -1, limit
+	for pos := 0; pos <= len(route); pos++ {
+		prev, next := s.startLoc(v), s.endLoc(v)
+		if pos > 0 {
+			prev = s.jobLoc(route[pos-1])
+		}
+		if pos < len(route) {
+			next = s.jobLoc(route[pos])
+		}
+
+		// The cost of a route is its travel cost, so the delta is known before checking the constraints.
+		delta := s.cost[prev][loc] + s.cost[loc][next]
+		if len(route) > 0 {
+			delta -= s.cost[prev][next]
+		}
+		if math.IsNaN(delta) || delta >= bestDelta {
+			continue
+		}
+
+		if _, ok := s.simulate(v, slices.Insert(slices.Clone(route), pos, j)); ok {
+			bestPos, bestDelta = pos, delta
+		}
+	}
+
+	return bestPos, bestDelta, bestPos != -1
+}
+
+// improve improves the routes using local search until no improving move is found.
+// Unassigned jobs are inserted again after each round since moves may free up room for them.
+func (s *solver) improve() {
+	for iter := 0; s.p.MaxIterations == 0 || iter < s.p.MaxIterations; iter++ {
+		improved := false
+
+		for v := range s.routes {
+			improved = s.twoOpt(v) || improved
+			improved = s.orOpt(v) || improved
+		}
+
+		for v1 := range s.routes {
+			for v2 := range s.routes {
+				if v1 == v2 {
+					continue
+				}
+				improved = s.relocate(v1, v2) || improved
+				if v1 < v2 {
+					improved = s.exchange(v1, v2) || improved
+				}
+			}
+		}
+
+		if n := len(s.unassigned); n > 0 {
+			s.construct()
+			improved = improved || len(s.unassigned) < n
+		}
+
+		if !improved {
+			return
+		}
+	}
+}
+
+// accept replaces the route of a vehicle if it's feasible and cheaper than the current one.
+func (s *solver) accept(v int, route []int) bool {
+	cost, ok := s.routeCost(v, route)
+	if !ok || cost >= s.costs[v]-epsilon {
+		return false
+	}
+
+	s.routes[v], s.costs[v] = route, cost
+
+	return true
+}
+
+// epsilon is the min improvement of a move, it prevents cycling because of floating point errors.
+const epsilon float64 = 1e-6
+
+// twoOpt reverses segments of the route of a vehicle.
+func (s *solver) twoOpt(v int) bool {
+	improved := false
+
+	for i := 0; i < len(s.routes[v])-1; i++ {
+		for k := i + 1; k < len(s.routes[v]); k++ {
+			route := slices.Clone(s.routes[v])
+			slices.Reverse(route[i : k+1])
+
+			if s.accept(v, route) {
+				improved = true
+			}
+		}
+	}
+
+	return improved
+}
+
+// orOpt moves segments of 1 to 3 consecutive jobs to other positions of the route of a vehicle.
+func (s *solver) orOpt(v int) bool {
+	improved := false
+
+	for size := 1; size <= 3; size++ {
+		for i := 0; i+size <= len(s.routes[v]); i++ {
+			segment := slices.Clone(s.routes[v][i : i+size])
+			rest := slices.Delete(slices.Clone(s.routes[v]), i, i+size)
+
+			for pos := 0; pos <= len(rest); pos++ {
+				if pos == i {
+					continue
+				}
+
+				if s.accept(v, slices.Insert(slices.Clone(rest), pos, segment...)) {
+					improved = true
+					break
+				}
+			}
+		}
+	}
+
+	return improved
+}
+
+// relocate moves jobs from the route of v1 to the route of v2.
+func (s *solver) relocate(v1, v2 int) bool {
+	improved := false
+
+	for i := 0; i < len(s.routes[v1]); i++ {
+		j := s.routes[v1][i]
+		from := slices.Delete(slices.Clone(s.routes[v1]), i, i+1)
+
+		fromCost, ok := s.routeCost(v1, from)
+		if !ok {
+			continue
+		}
+
+		for pos := 0; pos <= len(s.routes[v2]); pos++ {
+			to := slices.Insert(slices.Clone(s.routes[v2]), pos, j)
+
+			toCost, ok := s.routeCost(v2, to)
+			if !ok || fromCost+toCost >= s.costs[v1]+s.costs[v2]-epsilon {
+				continue
+			}
+
+			s.routes[v1], s.costs[v1] = from, fromCost
+			s.routes[v2], s.costs[v2] = to, toCost
+			improved = true
+			i--
+
+			break
+		}
+	}
+
+	return improved
+}
+
+// exchange swaps jobs between the routes of v1 and v2.
+func (s *solver) exchange(v1, v2 int) bool {
+	improved := false
+
+	for i := range s.routes[v1] {
+		for k := range s.routes[v2] {
+			r1, r2 := slices.Clone(s.routes[v1]), slices.Clone(s.routes[v2])
+			r1[i], r2[k] = r2[k], r1[i]
+
+			c1, ok1 := s.routeCost(v1, r1)
+			c2, ok2 := s.routeCost(v2, r2)
+			if !ok1 || !ok2 || c1+c2 >= s.costs[v1]+s.costs[v2]-epsilon {
+				continue
+			}
+
+			s.routes[v1], s.costs[v1] = r1, c1
+			s.routes[v2], s.costs[v2] = r2, c2
+			improved = true
+		}
+	}
+
+	return improved
+}
+
+// solution returns the solution of the current routes.
+func (s *solver) solution() Solution {
+	sol := Solution{Unassigned: slices.Clone(s.unassigned)}
+	slices.Sort(sol.Unassigned)
+
+	for v, route := range s.routes {
+		if len(route) == 0 {
+			continue
+		}
+
+		sch, _ := s.simulate(v, route)
+		r := Route{
+			VehicleIndex: v,
+			Vehicle:      s.p.Vehicles[v],
+			Stops:        sch.stops,
+			Departure:    sch.departure,
+			Arrival:      sch.arrival,
+			Duration:     seconds(sch.duration),
+			Distance:     sch.distance,
+		}
+
+		sol.Routes = append(sol.Routes, r)
+		sol.Duration += r.Duration
+		sol.Distance += r.Distance
+	}
+
+	return sol
+}
+
+// seconds converts seconds to duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// toFloat64 converts a float32 matrix to float64.
+func toFloat64(m [][]float32) [][]float64 {
+	if m == nil {
+		return nil
+	}
+
+	out := make([][]float64, len(m))
+	for i, row := range m {
+		out[i] = make([]float64, len(row))
+		for j, v := range row {
+			out[i][j] = float64(v)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,284 @@
+// Package vrp solves vehicle routing problems using OSRM table service.
+// It supports multiple vehicles with capacities, time windows, service times, multiple depots and max shift durations.
+// Solutions are found using cheapest insertion and improved by local search (2-opt, or-opt, relocate and exchange),
+// they are approximations and not guaranteed to be optimal.
+// InsertionEvaluator ranks the insertions of pickup and drop-off pairs into existing routes, e.g. for ride-sharing.
+package vrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mojixcoder/gosrm"
+)
+
+// ErrInvalidProblem is returned when a problem is not valid.
+var ErrInvalidProblem = errors.New("vrp: invalid problem")
+
+// Objective is the cost that is minimized.
+type Objective uint8
+
+const (
+	// ObjectiveDuration minimizes the total travel duration.
+	ObjectiveDuration Objective = iota
+
+	// ObjectiveDistance minimizes the total travel distance.
+	ObjectiveDistance
+)
+
+type (
+	// TimeWindow is a time interval, times are offsets from the start of planning.
+	TimeWindow struct {
+		// Start is the start of the window.
+		Start time.Duration
+
+		// End is the end of the window. If it's 0 the window has no end.
+		End time.Duration
+	}
+
+	// Job is a location that has to be visited by a vehicle.
+	Job struct {
+		// ID is the caller-defined ID of the job.
+		ID string
+
+		// Location of the job.
+		Location gosrm.Coordinate
+
+		// Demand is the amount of each capacity dimension the job needs.
+		// Jobs are deliveries, so demands are loaded at the start of the route.
+		Demand []int
+
+		// Service is the time spent at the job.
+		Service time.Duration
+
+		// TimeWindow is the window the service has to start in.
+		// Vehicles that arrive early wait until the start of the window.
+		TimeWindow TimeWindow
+	}
+
+	// Vehicle is a vehicle that serves jobs.
+	Vehicle struct {
+		// ID is the caller-defined ID of the vehicle.
+		ID string
+
+		// Start is the depot location the vehicle starts from.
+		Start gosrm.Coordinate
+
+		// End is the depot location the vehicle ends at. If it's nil the vehicle returns to start.
+		End *gosrm.Coordinate
+
+		// Capacity of each dimension, jobs' demands have the same dimensions.
+		Capacity []int
+
+		// Shift is the window the vehicle works in.
+		// The vehicle doesn't leave start before shift start and has to reach end before shift end.
+		Shift TimeWindow
+
+		// MaxDuration is the max duration of the route from leaving start to reaching end. If it's 0 there is no limit.
+		MaxDuration time.Duration
+	}
+
+	// Problem is a vehicle routing problem.
+	Problem struct {
+		// Profile is used to compute the travel durations and distances.
+		Profile gosrm.Profile
+
+		// Vehicles that can be used.
+		Vehicles []Vehicle
+
+		// Jobs that have to be served.
+		Jobs []Job
+
+		// Objective is the cost that is minimized.
+		Objective Objective
+
+		// MaxIterations is the max number of local search iterations. If it's 0 there is no limit.
+		MaxIterations int
+	}
+
+	// Stop is a job served by a vehicle.
+	Stop struct {
+		// JobIndex is the index of the job in the problem.
+		JobIndex int
+
+		// Job is the served job.
+		Job Job
+
+		// Arrival is the time the vehicle arrives at the job.
+		Arrival time.Duration
+
+		// ServiceStart is the time the service starts, after waiting for the time window if the vehicle is early.
+		ServiceStart time.Duration
+
+		// Departure is the time the vehicle leaves the job.
+		Departure time.Duration
+
+		// Load is the load of the vehicle after serving the job.
+		Load []int
+	}
+
+	// Route is the ordered stops of a vehicle.
+	Route struct {
+		// VehicleIndex is the index of the vehicle in the problem.
+		VehicleIndex int
+
+		// Vehicle is the vehicle serving the route.
+		Vehicle Vehicle
+
+		// Stops are the served jobs in visiting order.
+		Stops []Stop
+
+		// Departure is the time the vehicle leaves start.
+		Departure time.Duration
+
+		// Arrival is the time the vehicle reaches end.
+		Arrival time.Duration
+
+		// Duration is the total travel duration of the route.
+		Duration time.Duration
+
+		// Distance is the total travel distance of the route, in meters.
+		Distance float64
+	}
+
+	// Solution is a solution of a vehicle routing problem.
+	Solution struct {
+		// Routes of the vehicles which serve at least one job.
+		Routes []Route
+
+		// Unassigned are indices of the jobs that couldn't be served by any vehicle.
+		Unassigned []int
+
+		// Duration is the total travel duration of the routes.
+		Duration time.Duration
+
+		// Distance is the total travel distance of the routes, in meters.
+		Distance float64
+	}
+)
+
+// Locations returns the locations of the problem in the order which is used for matrices.
+// Vehicle starts come first, then vehicle ends and then jobs.
+func (p Problem) Locations() []gosrm.Coordinate {
+	locations := make([]gosrm.Coordinate, 0, 2*len(p.Vehicles)+len(p.Jobs))
+
+	for _, v := range p.Vehicles {
+		locations = append(locations, v.Start)
+	}
+	for _, v := range p.Vehicles {
+		if v.End != nil {
+			locations = append(locations, *v.End)
+		} else {
+			locations = append(locations, v.Start)
+		}
+	}
+	for _, j := range p.Jobs {
+		locations = append(locations, j.Location)
+	}
+
+	return locations
+}
+
+// validate validates the problem.
+func (p Problem) validate() error {
+	if len(p.Vehicles) == 0 {
+		return fmt.Errorf("%w: no vehicles", ErrInvalidProblem)
+	}
+
+	dims := len(p.Vehicles[0].Capacity)
+	for _, v := range p.Vehicles {
+		if len(v.Capacity) != dims {
+			return fmt.Errorf("%w: vehicle %q has %d capacity dimensions, expected %d", ErrInvalidProblem, v.ID, len(v.Capacity), dims)
+		}
+	}
+
+	for _, j := range p.Jobs {
+		if len(j.Demand) != 0 && len(j.Demand) != dims {
+			return fmt.Errorf("%w: job %q has %d demand dimensions, expected %d", ErrInvalidProblem, j.ID, len(j.Demand), dims)
+		}
+	}
+
+	return nil
+}
+
+// Request returns the route request of the route, from start through the stops to end.
+// IDs of the request are the vehicle ID with ":start" and ":end" suffixes for start and end and job IDs for the stops,
+// so the hints of start and end don't overwrite each other in a hint store.
+// It can be used in route service to get the geometry of the route.
+func (r Route) Request(profile gosrm.Profile) gosrm.Request {
+	req := gosrm.Request{
+		Profile:     profile,
+		Coordinates: make([]gosrm.Coordinate, 0, len(r.Stops)+2),
+		IDs:         make([]string, 0, len(r.Stops)+2),
+	}
+
+	req.Coordinates = append(req.Coordinates, r.Vehicle.Start)
+	req.IDs = append(req.IDs, r.Vehicle.ID+":start")
+
+	for _, s := range r.Stops {
+		req.Coordinates = append(req.Coordinates, s.Job.Location)
+		req.IDs = append(req.IDs, s.Job.ID)
+	}
+
+	end := r.Vehicle.Start
+	if r.Vehicle.End != nil {
+		end = *r.Vehicle.End
+	}
+	req.Coordinates = append(req.Coordinates, end)
+	req.IDs = append(req.IDs, r.Vehicle.ID+":end")
+
+	return req
+}
+
+// Solve solves the problem.
+// The duration and distance matrix of the problem is computed by table service in chunks of at most chunkSize locations,
+// see gosrm.TableChunked. Options are passed to table service.
+func Solve(ctx context.Context, osrm gosrm.OSRMClient, p Problem, chunkSize int, opts ...gosrm.Option) (Solution, error) {
+	if err := p.validate(); err != nil {
+		return Solution{}, err
+	}
+
+	tableOpts := append([]gosrm.Option{gosrm.WithAnnotations(gosrm.AnnotationsDurationDistance)}, opts...)
+
+	res, err := gosrm.TableChunked(ctx, osrm, gosrm.Request{Profile: p.Profile, Coordinates: p.Locations()}, nil, nil, chunkSize, tableOpts...)
+	if err != nil {
+		return Solution{}, err
+	}
+
+	return SolveMatrix(p, res.Durations, res.Distances)
+}
+
+// SolveMatrix solves the problem with the given duration and distance matrices.
+// Matrices are indexed by the locations of the problem, see Problem.Locations.
+// Infinite values are treated as unreachable. If distances is nil all distances are 0.
+func SolveMatrix(p Problem, durations, distances [][]float32) (Solution, error) {
+	if err := p.validate(); err != nil {
+		return Solution{}, err
+	}
+
+	n := 2*len(p.Vehicles) + len(p.Jobs)
+	if !isSquare(durations, n) || (distances != nil && !isSquare(distances, n)) {
+		return Solution{}, fmt.Errorf("%w: matrices must be %dx%d", ErrInvalidProblem, n, n)
+	}
+
+	s := newSolver(p, durations, distances)
+	s.construct()
+	s.improve()
+
+	return s.solution(), nil
+}
+
+// isSquare returns true if the matrix has n rows of n values.
+func isSquare(m [][]float32, n int) bool {
+	if len(m) != n {
+		return false
+	}
+	for _, row := range m {
+		if len(row) != n {
+			return false
+		}
+	}
+	return true
+}
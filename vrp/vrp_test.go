@@ -0,0 +1,296 @@
+package vrp
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mojixcoder/gosrm"
+	"github.com/stretchr/testify/assert"
+)
+
+// testMatrix returns matrices of the locations where the distance is the euclidean distance of coordinates in km
+// and duration is 1 second per meter.
+func testMatrix(locations []gosrm.Coordinate) ([][]float32, [][]float32) {
+	durations := make([][]float32, len(locations))
+	distances := make([][]float32, len(locations))
+
+	for i, a := range locations {
+		durations[i] = make([]float32, len(locations))
+		distances[i] = make([]float32, len(locations))
+
+		for j, b := range locations {
+			d := float32(math.Hypot(a[0]-b[0], a[1]-b[1]) * 1000)
+			distances[i][j] = d
+			durations[i][j] = d
+		}
+	}
+
+	return durations, distances
+}
+
+func solveTest(t *testing.T, p Problem) Solution {
+	durations, distances := testMatrix(p.Locations())
+	sol, err := SolveMatrix(p, durations, distances)
+	assert.NoError(t, err)
+	return sol
+}
+
+func jobIDs(r Route) []string {
+	var ids []string
+	for _, s := range r.Stops {
+		ids = append(ids, s.Job.ID)
+	}
+	return ids
+}
+
+func TestSolveMatrix_Order(t *testing.T) {
+	p := Problem{
+		Vehicles: []Vehicle{{ID: "v", Start: gosrm.Coordinate{0, 0}}},
+		Jobs: []Job{
+			{ID: "c", Location: gosrm.Coordinate{3, 0}},
+			{ID: "a", Location: gosrm.Coordinate{1, 0}},
+			{ID: "d", Location: gosrm.Coordinate{4, 0}},
+			{ID: "b", Location: gosrm.Coordinate{2, 0}},
+		},
+	}
+
+	sol := solveTest(t, p)
+	assert.Empty(t, sol.Unassigned)
+	assert.Len(t, sol.Routes, 1)
+
+	ids := jobIDs(sol.Routes[0])
+	if ids[0] == "d" {
+		assert.Equal(t, []string{"d", "c", "b", "a"}, ids)
+	} else {
+		assert.Equal(t, []string{"a", "b", "c", "d"}, ids)
+	}
+
+	r := sol.Routes[0]
+	assert.Equal(t, 8000*time.Second, r.Duration)
+	assert.InDelta(t, 8000, r.Distance, 1e-3)
+	assert.Equal(t, r.Duration, sol.Duration)
+	assert.Equal(t, time.Duration(0), r.Departure)
+	assert.Equal(t, 8000*time.Second, r.Arrival)
+}
+
+func TestSolveMatrix_Capacity(t *testing.T) {
+	p := Problem{
+		Vehicles: []Vehicle{
+			{ID: "v1", Start: gosrm.Coordinate{0, 0}, Capacity: []int{2}},
+			{ID: "v2", Start: gosrm.Coordinate{0, 0}, Capacity: []int{2}},
+		},
+		Jobs: []Job{
+			{ID: "a", Location: gosrm.Coordinate{1, 0}, Demand: []int{1}},
+			{ID: "b", Location: gosrm.Coordinate{2, 0}, Demand: []int{1}},
+			{ID: "c", Location: gosrm.Coordinate{0, 1}, Demand: []int{1}},
+			{ID: "d", Location: gosrm.Coordinate{0, 2}, Demand: []int{1}},
+			{ID: "e", Location: gosrm.Coordinate{0, 3}, Demand: []int{3}},
+		},
+	}
+
+	sol := solveTest(t, p)
+	assert.Equal(t, []int{4}, sol.Unassigned)
+	assert.Len(t, sol.Routes, 2)
+
+	for _, r := range sol.Routes {
+		assert.Len(t, r.Stops, 2)
+		assert.Equal(t, []int{1}, r.Stops[0].Load)
+		assert.Equal(t, []int{0}, r.Stops[1].Load)
+	}
+}
+
+func TestSolveMatrix_TimeWindows(t *testing.T) {
+	p := Problem{
+		Vehicles: []Vehicle{{ID: "v", Start: gosrm.Coordinate{0, 0}, Shift: TimeWindow{Start: time.Hour, End: 10 * time.Hour}}},
+		Jobs: []Job{
+			{ID: "late", Location: gosrm.Coordinate{1, 0}, TimeWindow: TimeWindow{Start: 5 * time.Hour}, Service: 10 * time.Minute},
+			{ID: "early", Location: gosrm.Coordinate{2, 0}, TimeWindow: TimeWindow{End: 2 * time.Hour}},
+			{ID: "impossible", Location: gosrm.Coordinate{3, 0}, TimeWindow: TimeWindow{End: time.Minute}},
+		},
+	}
+
+	sol := solveTest(t, p)
+	assert.Equal(t, []int{2}, sol.Unassigned)
+	assert.Equal(t, []string{"early", "late"}, jobIDs(sol.Routes[0]))
+
+	r := sol.Routes[0]
+	assert.Equal(t, time.Hour, r.Departure)
+	assert.Equal(t, time.Hour+2000*time.Second, r.Stops[0].Arrival)
+
+	late := r.Stops[1]
+	assert.Equal(t, time.Hour+3000*time.Second, late.Arrival)
+	assert.Equal(t, 5*time.Hour, late.ServiceStart)
+	assert.Equal(t, 5*time.Hour+10*time.Minute, late.Departure)
+	assert.Equal(t, late.Departure+1000*time.Second, r.Arrival)
+}
+
+func TestSolveMatrix_MaxDuration(t *testing.T) {
+	end := gosrm.Coordinate{0, 4}
+	p := Problem{
+		Objective: ObjectiveDistance,
+		Vehicles: []Vehicle{
+			{ID: "short", Start: gosrm.Coordinate{0, 0}, MaxDuration: 2500 * time.Second},
+			{ID: "depot", Start: gosrm.Coordinate{0, 3}, End: &end},
+		},
+		Jobs: []Job{
+			{ID: "near_short", Location: gosrm.Coordinate{1, 0}, TimeWindow: TimeWindow{Start: time.Hour}},
+			{ID: "far", Location: gosrm.Coordinate{0, 2}},
+		},
+	}
+
+	sol := solveTest(t, p)
+	assert.Empty(t, sol.Unassigned)
+	assert.Len(t, sol.Routes, 2)
+
+	// Waiting for the time window of the first job doesn't count against max duration.
+	assert.Equal(t, "short", sol.Routes[0].Vehicle.ID)
+	assert.Equal(t, []string{"near_short"}, jobIDs(sol.Routes[0]))
+	assert.Equal(t, time.Hour-1000*time.Second, sol.Routes[0].Departure)
+
+	// far is closer to short but its round trip is longer than max duration.
+	assert.Equal(t, "depot", sol.Routes[1].Vehicle.ID)
+	assert.Equal(t, []string{"far"}, jobIDs(sol.Routes[1]))
+	assert.Equal(t, 3000*time.Second, sol.Routes[1].Duration)
+}
+
+func TestSolveMatrix_Unreachable(t *testing.T) {
+	p := Problem{
+		Vehicles: []Vehicle{{ID: "v", Start: gosrm.Coordinate{0, 0}}},
+		Jobs:     []Job{{ID: "a", Location: gosrm.Coordinate{1, 0}}, {ID: "island", Location: gosrm.Coordinate{2, 0}}},
+	}
+
+	durations, distances := testMatrix(p.Locations())
+	inf := float32(math.Inf(1))
+	for i := range durations {
+		durations[i][3], durations[3][i] = inf, inf
+	}
+	durations[3][3] = 0
+
+	sol, err := SolveMatrix(p, durations, distances)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, sol.Unassigned)
+	assert.Equal(t, []string{"a"}, jobIDs(sol.Routes[0]))
+}
+
+func TestSolveMatrix_Invalid(t *testing.T) {
+	_, err := SolveMatrix(Problem{}, nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidProblem)
+
+	p := Problem{Vehicles: []Vehicle{{Capacity: []int{1}}, {}}}
+	_, err = SolveMatrix(p, nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidProblem)
+
+	p = Problem{Vehicles: []Vehicle{{Capacity: []int{1}}}, Jobs: []Job{{Demand: []int{1, 2}}}}
+	_, err = SolveMatrix(p, nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidProblem)
+
+	p = Problem{Vehicles: []Vehicle{{}}}
+	_, err = SolveMatrix(p, [][]float32{{0}}, nil)
+	assert.ErrorIs(t, err, ErrInvalidProblem)
+
+	_, err = SolveMatrix(p, [][]float32{{0, 0}, {0}}, nil)
+	assert.ErrorIs(t, err, ErrInvalidProblem)
+
+	_, err = SolveMatrix(p, [][]float32{{0, 0}, {0, 0}}, [][]float32{{0, 0}, {}})
+	assert.ErrorIs(t, err, ErrInvalidProblem)
+
+	sol, err := SolveMatrix(p, [][]float32{{0, 0}, {0, 0}}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, sol.Routes)
+}
+
+func TestRoute_Request(t *testing.T) {
+	end := gosrm.Coordinate{5, 5}
+	r := Route{
+		Vehicle: Vehicle{ID: "v", Start: gosrm.Coordinate{0, 0}},
+		Stops:   []Stop{{Job: Job{ID: "a", Location: gosrm.Coordinate{1, 1}}}},
+	}
+
+	req := r.Request(gosrm.ProfileCar)
+	assert.Equal(t, gosrm.ProfileCar, req.Profile)
+	assert.Equal(t, []gosrm.Coordinate{{0, 0}, {1, 1}, {0, 0}}, req.Coordinates)
+	assert.Equal(t, []string{"v:start", "a", "v:end"}, req.IDs)
+
+	r.Vehicle.End = &end
+	assert.Equal(t, []gosrm.Coordinate{{0, 0}, {1, 1}, {5, 5}}, r.Request(gosrm.ProfileCar).Coordinates)
+}
+
+// newTableTestServer returns a fake OSRM table service which uses testMatrix.
+func newTableTestServer(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests != nil {
+			atomic.AddInt32(requests, 1)
+		}
+
+		assert.Equal(t, "duration,distance", r.URL.Query().Get("annotations"))
+
+		parts := strings.Split(r.URL.Path, "/")
+		var locations []gosrm.Coordinate
+		for _, pair := range strings.Split(strings.TrimSuffix(parts[len(parts)-1], ".json"), ";") {
+			lngLat := strings.Split(pair, ",")
+			lng, _ := strconv.ParseFloat(lngLat[0], 64)
+			lat, _ := strconv.ParseFloat(lngLat[1], 64)
+			locations = append(locations, gosrm.Coordinate{lng, lat})
+		}
+
+		q := r.URL.Query()
+		sources := strings.Split(q.Get("sources"), ";")
+		destinations := strings.Split(q.Get("destinations"), ";")
+
+		var blockSources, blockDestinations []gosrm.Coordinate
+		for _, idx := range sources {
+			i, _ := strconv.Atoi(idx)
+			blockSources = append(blockSources, locations[i])
+		}
+		for _, idx := range destinations {
+			i, _ := strconv.Atoi(idx)
+			blockDestinations = append(blockDestinations, locations[i])
+		}
+
+		durations, distances := testMatrix(append(blockSources, blockDestinations...))
+		for i := range blockSources {
+			durations[i] = durations[i][len(blockSources):]
+			distances[i] = distances[i][len(blockSources):]
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"code":      "Ok",
+			"durations": durations[:len(blockSources)],
+			"distances": distances[:len(blockSources)],
+		})
+	}))
+}
+
+func TestSolve(t *testing.T) {
+	srv := newTableTestServer(t, nil)
+	defer srv.Close()
+
+	osrm, err := gosrm.New(srv.URL)
+	assert.NoError(t, err)
+
+	p := Problem{
+		Profile:  gosrm.ProfileCar,
+		Vehicles: []Vehicle{{ID: "v", Start: gosrm.Coordinate{0, 0}}},
+		Jobs:     []Job{{ID: "b", Location: gosrm.Coordinate{2, 0}}, {ID: "a", Location: gosrm.Coordinate{1, 0}}},
+	}
+
+	sol, err := Solve(context.Background(), osrm, p, 0)
+	assert.NoError(t, err)
+	assert.Len(t, sol.Routes, 1)
+	assert.Equal(t, 4000*time.Second, sol.Duration)
+
+	_, err = Solve(context.Background(), osrm, Problem{}, 0)
+	assert.ErrorIs(t, err, ErrInvalidProblem)
+
+	srv.Close()
+	_, err = Solve(context.Background(), osrm, p, 0)
+	assert.Error(t, err)
+}